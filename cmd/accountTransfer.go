@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// accountTransferCmd represents the accountTransfer command
+var accountTransferCmd = &cobra.Command{
+	Use:   "transfer <recipient> <amount>",
+	Short: "Transfer SOL to another account",
+	Long: `This command transfers SOL from the account of the key to recipient
+address. Amount is in SOL, use ALL to transfer entire balance less fee:
+solana-kms account transfer --keyfile=/tmp/key <recipient> 1.5
+
+Transaction is signed with the key, sent to the validator and confirmed at
+commitment level of Solana config. Transfers to accounts without balance
+are refused unless --allow-unfunded-recipient is set, since a mistyped
+address would otherwise silently receive the funds.
+
+Fee is paid by the sender unless --fee-payer names another key file, in
+which case ALL transfers entire balance of the sender.`,
+	Args: cobra.ExactArgs(2),
+	RunE: run.AccountTransfer,
+}
+
+func init() {
+	accountCmd.AddCommand(accountTransferCmd)
+	f := accountTransferCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the sender")
+	f.Bool(b(flags.AllowUnfundedRecipient), false, "Allow transfer to an account without balance")
+	f.String(b(flags.Memo), "", "Memo to attach to the transaction")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Signing agent related subcommands",
+	Long: `Run a signing agent that decrypts keypair once and holds it in
+locked memory, and talk to it to sign messages and transactions without
+the private key ever leaving the agent`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("pl. use with a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+}
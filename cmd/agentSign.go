@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentSignCmd represents the agentSign command
+var agentSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign message or transaction using signing agent",
+	Long: `This command asks a running agent to sign input and prints base58
+encoded signature. Input is read from --input file or stdin.
+
+Agent refuses to sign a message that is a transaction requiring its
+signature. Use --transaction to sign a base64 encoded serialized
+transaction message, which must list agent key as a signer:
+echo -n hello | solana-kms agent sign
+solana-kms agent sign --transaction --input=/tmp/message.b64`,
+	RunE: run.AgentSign,
+}
+
+func init() {
+	agentCmd.AddCommand(agentSignCmd)
+	f := agentSignCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Socket), "", "Agent socket path (Env: SOLANA_KMS_AGENT_SOCK)")
+	f.String(b(flags.Input), "", "Input file, defaults to stdin")
+	f.Bool(b(flags.Transaction), false, "Input is a base64 encoded transaction message")
+}
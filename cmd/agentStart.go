@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentStartCmd represents the agentStart command
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start signing agent in the foreground",
+	Long: `This command decrypts keypair once, holds it in locked memory
+and serves sign-only requests over a Unix socket that only the current
+user can access. Key is wiped and agent exits after --ttl without any
+request, on agent stop or on interrupt. Private key is never exported.
+
+Socket defaults to $XDG_RUNTIME_DIR/solana-kms/agent.sock and can be
+set via --socket or SOLANA_KMS_AGENT_SOCK:
+solana-kms agent start --keyfile=/tmp/key --ttl=30m &`,
+	RunE: run.AgentStart,
+}
+
+func init() {
+	agentCmd.AddCommand(agentStartCmd)
+	f := agentStartCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Key file to serve")
+	f.String(b(flags.Socket), "", "Agent socket path (Env: SOLANA_KMS_AGENT_SOCK)")
+	f.Duration(b(flags.Ttl), 15*time.Minute, "Idle time after which key is wiped, 0 to disable")
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentStopCmd represents the agentStop command
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop signing agent",
+	Long:  `This command asks a running agent to wipe its key and exit`,
+	RunE:  run.AgentStop,
+}
+
+func init() {
+	agentCmd.AddCommand(agentStopCmd)
+	f := agentStopCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Socket), "", "Agent socket path (Env: SOLANA_KMS_AGENT_SOCK)")
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyCombineCmd represents the keyCombine command
+var keyCombineCmd = &cobra.Command{
+	Use:   "combine",
+	Short: "Recover key from Shamir shares",
+	Long: `This command combines Shamir shares produced by solana-kms key split
+to recover the seed and writes freshly encrypted keypair and seed files.
+
+Recover from encrypted share files, each decrypted using its KMS key:
+solana-kms key combine --keyfile=/tmp/key-recovered \
+	--share=/tmp/shares/<pubkey>.share-1,/tmp/shares/<pubkey>.share-4 \
+	--share-keys=k1,k4
+
+Recover from shares typed on the terminal as words:
+solana-kms key combine --keyfile=/tmp/key-recovered --mnemonic`,
+	RunE: run.KeyCombine,
+}
+
+func init() {
+	keyCmd.AddCommand(keyCombineCmd)
+	f := keyCombineCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Output key file")
+	f.StringSlice(b(flags.Share), nil, "Encrypted share file (can be repeated)")
+	f.StringSlice(b(flags.ShareKeys), nil, "KMS key name per share file (defaults to current key)")
+	f.Bool(b(flags.Mnemonic), false, "Read shares as words from terminal")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
+}
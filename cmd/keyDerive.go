@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyDeriveCmd represents the keyDerive command
+var keyDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive hierarchical keys from encrypted seed",
+	Long: `This command derives ed25519 keys from an encrypted seed file
+as per SLIP-0010, compatible with Phantom and solana-keygen --derivation-path.
+
+Derive public keys for account indices 0 through 9 using m/44'/501'/n'/0':
+solana-kms key derive --seedfile=/tmp/key.seed --index=0 --count=10
+
+Derive a single key for an explicit path:
+solana-kms key derive --seedfile=/tmp/key.seed --derivation-path="m/44'/501'/7'/0'"
+
+Use --outdir to also write KMS encrypted keypair and seed files for each
+derived key, named after its public key.`,
+	RunE: run.KeyDerive,
+}
+
+func init() {
+	keyCmd.AddCommand(keyDeriveCmd)
+	f := keyDeriveCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.SeedFile), "", "Input seed file (defaults to seed of config keypair)")
+	f.String(b(flags.DerivationPath), "", "Derivation path (--index and --count will be ignored)")
+	f.Uint32(b(flags.Index), 0, "First account index n in m/44'/501'/n'/0'")
+	f.Uint32(b(flags.Count), 1, "Number of account indices to derive")
+	f.String(b(flags.OutDir), "", "Output dir for encrypted keypair files")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
+}
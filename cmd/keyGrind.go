@@ -0,0 +1,60 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyGrindCmd represents the keyGrind command
+var keyGrindCmd = &cobra.Command{
+	Use:   "grind",
+	Short: "Search for a vanity address and encrypt it",
+	Long: `This command generates random keypairs on all CPU cores until
+the public key matches the requested prefix and/or suffix. The winning
+keypair and seed are encrypted using KMS before being written, so unlike
+solana-keygen grind, plaintext is never stored on disk.
+
+Each additional character makes the search about 58 times longer.
+Progress and the expected number of attempts are reported on stderr.
+
+Search for an address starting with "Pay" ignoring case and write it
+to a file named after the public key in the current directory:
+solana-kms key grind --prefix=Pay --ignore-case
+
+Search for an address ending with "xyz" and write it to given key file:
+solana-kms key grind --suffix=xyz --keyfile=/tmp/key`,
+	RunE: run.KeyGrind,
+}
+
+func init() {
+	keyCmd.AddCommand(keyGrindCmd)
+	f := keyGrindCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Output key file (defaults to public key in current dir)")
+	f.String(b(flags.Prefix), "", "Public key prefix")
+	f.String(b(flags.Suffix), "", "Public key suffix")
+	f.Bool(b(flags.IgnoreCase), false, "Case insensitive matching")
+	f.Int(b(flags.Threads), runtime.NumCPU(), "Number of threads")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
+}
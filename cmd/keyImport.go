@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyImportCmd represents the keyImport command
+var keyImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import existing keypair and encrypt it",
+	Long: `This command reads an existing plaintext keypair and writes
+KMS encrypted keypair and seed files.
+
+Keypair can be formatted as solana-keygen JSON array, base58 string
+(Phantom export) or hex string and is read from a file, stdin or
+a hidden terminal prompt when --input is not set:
+solana-kms key import --keyfile=/tmp/key
+solana-kms key import --keyfile=/tmp/key --input=${HOME}/.config/solana/id.json --shred
+cat id.json | solana-kms key import --keyfile=/tmp/key --input=-
+
+Use --shred to overwrite and remove plaintext input file after successful import.`,
+	RunE: run.KeyImport,
+}
+
+func init() {
+	keyCmd.AddCommand(keyImportCmd)
+	f := keyImportCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Output key file")
+	f.String(b(flags.Input), "", "Input plaintext keypair file, - for stdin (prompts when empty)")
+	f.Bool(b(flags.Shred), false, "Overwrite and remove plaintext input file after import")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
+}
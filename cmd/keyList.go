@@ -0,0 +1,42 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyListCmd represents the keyList command
+var keyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List named keys in keystore",
+	Long: `This command lists named keys in the keystore dir along with
+their public key, KMS backend and key version, creation time and labels.
+Key files are not decrypted, so no KMS access is required.
+
+Create a named key in keystore and list all keys:
+solana-kms key new --key-name=treasury --label=team=payments
+solana-kms key list
+
+Named keys can be used by other commands in place of --keyfile:
+solana-kms account balance --key-name=treasury`,
+	RunE: run.KeyList,
+}
+
+func init() {
+	keyCmd.AddCommand(keyListCmd)
+}
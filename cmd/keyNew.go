@@ -32,10 +32,10 @@ using Google KMS. The persisted file is ciphertext.
 
 First set following environment variables:
 export GOOGLE_APPLICATION_CREDENTIALS="kms-encrypter-decrypter-role-sa.json"
-export LOCATION=<kms location>
-export KEYRING=<keyring name>
-export KEY=<key name>
-export PROJECT=<project id>
+export KMS_LOCATION=<kms location>
+export KMS_KEYRING=<keyring name>
+export KMS_KEY=<key name>
+export GOOGLE_PROJECT_ID=<project id>
 
 Generate a new key as follows
 solana-kms key new --keyfile=/tmp/key
@@ -50,21 +50,30 @@ and recovered files will not match. Decrypting both will, however, result in
 exact same contents.
 
 You can verify as follows:
-gcloud kms decrypt \
-	--location=<location> \
-	--keyring=<keyring name> \
-	--key=<key name> \
-	--ciphertext-file=/tmp/key \
-	--plaintext-file=- \
-| base64
-
-gcloud kms decrypt \
-	--location=<location> \
-	--keyring=<keyring name> \
-	--key=<key name> \
-	--ciphertext-file=/tmp/key-recovered \
-	--plaintext-file=- \
-| base64
+solana-kms key show --keyfile=/tmp/key --pubkey
+solana-kms key show --keyfile=/tmp/key-recovered --pubkey
+
+Files are written in a versioned envelope format that records the backend,
+the KMS key version used for encryption, the public key and creation time.
+These can be inspected without decrypting:
+solana-kms key show --keyfile=/tmp/key --info
+
+A human transcribable backup can be generated using a BIP39 mnemonic, which is
+shown once on the terminal and never written to the output:
+solana-kms key new --keyfile=/tmp/key --mnemonic --mnemonic-words=24
+
+The same keypair can be recovered later using solana-kms key recover or
+solana-keygen recover.
+
+Keys can also be created by name in the keystore dir along with labels,
+and listed using solana-kms key list:
+solana-kms key new --key-name=treasury --label=team=payments
+
+Alternatively, a KMS native Ed25519 signing key can be created, in which case
+the private key is generated in KMS and never leaves it. The key file only
+references the KMS key version and records its public key. It requires an
+asymmetric signing key, which is created under the keyring if it does not exist:
+solana-kms key new --keyfile=/tmp/key --kms-native --kms-key=solana-signer
 `,
 	RunE: run.KeyNew,
 }
@@ -76,4 +85,11 @@ func init() {
 
 	f.String(b(flags.KeyFile), "", "Output key file")
 	f.String(b(flags.SeedFile), "", "Input seed file")
+	f.Bool(b(flags.Mnemonic), false, "Generate key from a new BIP39 mnemonic shown once on the terminal")
+	f.Int(b(flags.MnemonicWords), 12, "Number of words in BIP39 mnemonic (12 or 24)")
+	f.Bool(b(flags.MnemonicPassphrase), false, "Prompt for BIP39 passphrase")
+	f.StringSlice(b(flags.Label), nil, "Key label as key=value, stored in keystore metadata (can be repeated)")
+	f.Bool(b(flags.KmsNative), false, "Create KMS native Ed25519 signing key, private key never leaves KMS")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
 }
@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyRecoverCmd represents the keyRecover command
+var keyRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover keypair from BIP39 mnemonic",
+	Long: `This command rebuilds a keypair from a BIP39 seed phrase typed
+on the terminal and writes KMS encrypted keypair and seed files.
+
+Derivation is compatible with solana-keygen new and solana-keygen recover
+when no derivation path is used:
+solana-kms key recover --keyfile=/tmp/key
+
+Use --mnemonic-passphrase if the seed phrase was protected with a BIP39 passphrase.`,
+	RunE: run.KeyRecover,
+}
+
+func init() {
+	keyCmd.AddCommand(keyRecoverCmd)
+	f := keyRecoverCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Output key file")
+	f.Bool(b(flags.MnemonicPassphrase), false, "Prompt for BIP39 passphrase")
+	f.Bool(b(flags.Force), false, "Overwrite existing key files")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of overwritten key files")
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyRewrapCmd represents the keyRewrap command
+var keyRewrapCmd = &cobra.Command{
+	Use:   "rewrap",
+	Short: "Migrate key files to a different KMS key or backend",
+	Long: `This command decrypts keypair and seed files using the source
+KMS key, selected via the usual global flags, and re-encrypts them
+using the destination KMS key selected via --dest-* flags. Destination
+settings that are not provided default to the source settings.
+
+Plaintext key material is only held in memory and new ciphertext is
+verified to round trip before files are written to output dir. Source
+files are left untouched:
+solana-kms key rewrap --keyfile=/tmp/key \
+	--dest-google-project-id=new-project --outdir=/tmp/migrated
+
+Migrate all keypair files in a directory from Google KMS to AWS KMS:
+solana-kms key rewrap --indir=/tmp/keys \
+	--dest-backend=aws --dest-aws-kms-key-arn=arn:aws:kms:... \
+	--outdir=/tmp/migrated`,
+	RunE: run.KeyRewrap,
+}
+
+func init() {
+	keyCmd.AddCommand(keyRewrapCmd)
+	f := keyRewrapCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Key file to rewrap")
+	f.String(b(flags.InDir), "", "Input dir of key files to rewrap")
+	f.String(b(flags.OutDir), "", "Output dir for rewrapped key files")
+	f.String(b(flags.DestBackend), "", "Destination key encryption backend (gcp|aws|vault|local)")
+	f.String(b(flags.DestGoogleProjectID), "", "Destination Google project ID")
+	f.String(b(flags.DestKmsLocation), "", "Destination KMS location")
+	f.String(b(flags.DestKmsKeyring), "", "Destination KMS keyring name")
+	f.String(b(flags.DestKmsKey), "", "Destination KMS key name")
+	f.String(b(flags.DestGoogleAppCredentials), "", "Destination Google app credentials")
+	f.String(b(flags.DestAwsKmsKeyArn), "", "Destination AWS KMS key ARN")
+	f.String(b(flags.DestAwsRegion), "", "Destination AWS region")
+	f.String(b(flags.DestAwsProfile), "", "Destination AWS profile")
+	f.String(b(flags.DestAwsKmsEndpoint), "", "Destination AWS KMS endpoint")
+	f.String(b(flags.DestVaultAddr), "", "Destination Vault address")
+	f.String(b(flags.DestVaultToken), "", "Destination Vault token")
+	f.String(b(flags.DestVaultTransitMount), "", "Destination Vault transit mount")
+	f.String(b(flags.DestVaultTransitKey), "", "Destination Vault transit key")
+}
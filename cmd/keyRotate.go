@@ -0,0 +1,52 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyRotateCmd represents the keyRotate command
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt key files under current KMS key version",
+	Long: `This command decrypts keypair and seed files and re-encrypts
+them in place using the current primary version of the KMS key, or
+a named target key in the same keyring when --target-key is set.
+
+The public key is verified to remain unchanged and each file is
+replaced atomically:
+solana-kms key rotate --keyfile=/tmp/key
+
+Report which key version protects each file without changing anything:
+solana-kms key rotate --keyfile=/tmp/key --dry-run`,
+	RunE: run.KeyRotate,
+}
+
+func init() {
+	keyCmd.AddCommand(keyRotateCmd)
+	f := keyRotateCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Key file to rotate")
+	f.String(b(flags.TargetKey), "", "Target key name (defaults to current key)")
+	f.Bool(b(flags.DryRun), false, "Report key versions without rotating")
+	f.Bool(b(flags.Backup), false, "Keep timestamped backup of previous key files")
+}
@@ -28,7 +28,10 @@ var keyShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show Solana KMS private key",
 	Long: `This command shows JSON formatted private key after
-decrypting the keypair file as necessary.`,
+decrypting the keypair file as necessary.
+
+Use --info to display key file format, backend and the key version
+protecting the file without decrypting it.`,
 	RunE: run.KeyShow,
 }
 
@@ -39,4 +42,5 @@ func init() {
 
 	f.String(b(flags.KeyFile), "", "Input key file")
 	f.Bool(b(flags.PubKey), false, "Display public key")
+	f.Bool(b(flags.Info), false, "Display key file metadata without decrypting")
 }
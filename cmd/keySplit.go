@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keySplitCmd represents the keySplit command
+var keySplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split seed into Shamir shares for disaster recovery",
+	Long: `This command decrypts the seed file and splits it into a number of
+Shamir secret shares such that any threshold number of them can be
+combined to recover the key, while fewer reveal nothing about it.
+
+Write 5 shares, any 3 of which recover the key, each encrypted using a
+different KMS key in the same keyring:
+solana-kms key split --keyfile=/tmp/key --shares=5 --threshold=3 \
+	--share-keys=k1,k2,k3,k4,k5 --outdir=/tmp/shares
+
+Show shares as words on the terminal so they can be written down
+and handed to custodians, independent of any KMS key:
+solana-kms key split --keyfile=/tmp/key --shares=3 --threshold=2 --mnemonic
+
+Use solana-kms key combine to recover the key from shares.`,
+	RunE: run.KeySplit,
+}
+
+func init() {
+	keyCmd.AddCommand(keySplitCmd)
+	f := keySplitCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Key file whose seed is split (defaults to config keypair)")
+	f.String(b(flags.SeedFile), "", "Input seed file (defaults to seed of key file)")
+	f.Int(b(flags.Shares), 3, "Number of shares")
+	f.Int(b(flags.Threshold), 2, "Number of shares required to recover")
+	f.StringSlice(b(flags.ShareKeys), nil, "KMS key name per share (defaults to current key)")
+	f.String(b(flags.OutDir), "", "Output dir for encrypted share files")
+	f.Bool(b(flags.Mnemonic), false, "Show shares as words on terminal")
+}
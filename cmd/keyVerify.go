@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// keyVerifyCmd represents the keyVerify command
+var keyVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify integrity of keypair and seed files",
+	Long: `This command decrypts keypair and seed files and verifies that
+the keypair derived from the seed matches the stored keypair. File and
+KMS checksums are validated along the way. The command exits with
+non-zero status if any check fails, reporting the precise reason.
+
+Verify a key file against its expected public key:
+solana-kms key verify --keyfile=/tmp/key --pubkey=<expected public key>
+
+Audit all key files in a directory:
+solana-kms key verify --indir=/tmp/keys`,
+	RunE: run.KeyVerify,
+}
+
+func init() {
+	keyCmd.AddCommand(keyVerifyCmd)
+	f := keyVerifyCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Key file to verify")
+	f.String(b(flags.InDir), "", "Input dir of key files to audit")
+	f.String(b(flags.PubKey), "", "Expected public key")
+}
@@ -18,7 +18,9 @@ package cmd
 import (
 	"path/filepath"
 
+	"github.com/kubetrail/solana-kms/pkg/backend"
 	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -56,12 +58,27 @@ func init() {
 	f.String(b(flags.KmsLocation), "global", "KMS location (Env: KMS_LOCATION)")
 	f.String(b(flags.KmsKeyring), "", "KMS keyring name (Env: KMS_KEYRING)")
 	f.String(b(flags.KmsKey), "", "KMS key name (Env: KMS_KEY)")
+	f.String(b(flags.Backend), backend.Google, "Key encryption backend gcp|aws|vault|local (Env: KMS_BACKEND)")
+
+	f.String(b(flags.AwsKmsKeyArn), "", "AWS KMS key ARN (Env: AWS_KMS_KEY_ARN)")
+	f.String(b(flags.AwsRegion), "", "AWS region (Env: AWS_REGION)")
+	f.String(b(flags.AwsProfile), "", "AWS profile (Env: AWS_PROFILE)")
+	f.String(b(flags.AwsKmsEndpoint), "", "AWS KMS endpoint override (Env: AWS_KMS_ENDPOINT)")
+
+	f.String(b(flags.VaultAddr), "", "Vault server address (Env: VAULT_ADDR)")
+	f.String(b(flags.VaultToken), "", "Vault token (Env: VAULT_TOKEN)")
+	f.String(b(flags.VaultTransitMount), "transit", "Vault transit mount path (Env: VAULT_TRANSIT_MOUNT)")
+	f.String(b(flags.VaultTransitKey), "", "Vault transit key name (Env: VAULT_TRANSIT_KEY)")
 
 	f.String(b(flags.Config), "", "Solana config file (Env: SOLANA_CONFIG)")
+	f.String(b(flags.KeyName), "", "Name of key in keystore, used in place of --keyfile")
+	f.String(b(flags.Keystore), "", "Keystore dir, defaults to ~/.config/solana-kms/keys (Env: SOLANA_KMS_KEYSTORE)")
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// decrypted key material must never end up in a core dump
+	cobra.CheckErr(secure.DisableCoreDumps())
 
 	viper.AutomaticEnv() // read in environment variables that match
 }
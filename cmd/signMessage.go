@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// signMessageCmd represents the signMessage command
+var signMessageCmd = &cobra.Command{
+	Use:   "sign-message [message]",
+	Short: "Sign a message using KMS protected key",
+	Long: `This command signs a message passed as argument, or read from
+--input file or stdin, and prints its signature. It can be used to prove
+ownership of an address without sending a transaction:
+solana-kms sign-message --keyfile=/tmp/key --offchain "I own this address"
+
+Use --offchain to sign the message in Solana off-chain message format,
+which prefixes it with "\xffsolana offchain" signing domain and matches
+solana sign-offchain-message. Without it, message bytes are signed as is,
+however, a message that is a transaction requiring key's signature is
+refused.
+
+Signature is encoded as base58 by default, use --encoding to print it
+as base64 or hex instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: run.SignMessage,
+}
+
+func init() {
+	rootCmd.AddCommand(signMessageCmd)
+	f := signMessageCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Input), "", "Input message file, defaults to stdin when no message argument is given")
+	f.Bool(b(flags.Offchain), false, "Sign as Solana off-chain message")
+	f.String(b(flags.Encoding), "base58", "Signature encoding base58|base64|hex")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// signTxCmd represents the signTx command
+var signTxCmd = &cobra.Command{
+	Use:   "sign-tx",
+	Short: "Sign a serialized transaction without broadcasting it",
+	Long: `This command reads a serialized legacy or v0 transaction from --input
+file or stdin, displays its instructions, adds a signature of the key in
+its signer slot and prints the partially or fully signed transaction in
+the same encoding. Transaction is never broadcast, which makes it suitable
+for offline signing pipelines:
+cat tx.b64 | solana-kms sign-tx --keyfile=/tmp/key > signed.b64
+
+Instructions and the state of each required signature are written to
+stderr so that only the transaction is written to stdout. Transactions
+are base64 encoded by default, use --encoding=base58 otherwise.`,
+	RunE: run.SignTx,
+}
+
+func init() {
+	rootCmd.AddCommand(signTxCmd)
+	f := signTxCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Input), "", "Input transaction file, defaults to stdin")
+	f.String(b(flags.Encoding), "base64", "Transaction encoding base64|base58|hex")
+}
@@ -0,0 +1,40 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// stakeCmd represents the stake command
+var stakeCmd = &cobra.Command{
+	Use:   "stake",
+	Short: "Stake account related subcommands",
+	Long: `These commands manage stake accounts of the Stake program with the key
+as stake and withdraw authority and, unless --fee-payer is set, fee payer.
+Authorities can also be other keypairs, including KMS encrypted keypair
+files, set with --stake-authority and --withdraw-authority. Amounts are in
+SOL.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("pl. use a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stakeCmd)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeAuthorizeCmd represents the stakeAuthorize command
+var stakeAuthorizeCmd = &cobra.Command{
+	Use:   "authorize <stake>",
+	Short: "Change authorities of a stake account",
+	Long: `This command sets new stake and/or withdraw authority of a stake
+account, given as addresses or keypair files:
+solana-kms stake authorize --keyfile=/tmp/key \
+    --new-withdraw-authority=<address> <stake>
+
+Each change is signed by the current authority of the same type, which is
+the key unless --stake-authority or --withdraw-authority keypair files are
+set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: run.StakeAuthorize,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeAuthorizeCmd)
+	f := stakeAuthorizeCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.StakeAuthority), "", "Stake authority keypair file, defaults to the key")
+	f.String(b(flags.WithdrawAuthority), "", "Withdraw authority keypair file, defaults to the key")
+	f.String(b(flags.NewStakeAuthority), "", "New stake authority address or keypair file")
+	f.String(b(flags.NewWithdrawAuthority), "", "New withdraw authority address or keypair file")
+}
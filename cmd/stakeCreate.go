@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeCreateCmd represents the stakeCreate command
+var stakeCreateCmd = &cobra.Command{
+	Use:   "create <amount>",
+	Short: "Create a stake account",
+	Long: `This command creates a stake account funded with amount of SOL by the
+key and prints its address:
+solana-kms stake create --keyfile=/tmp/key 10
+
+Stake and withdraw authorities default to the key and can be set to other
+addresses or keypair files with --stake-authority and --withdraw-authority.
+Stake account address is derived from the key and --seed when set,
+otherwise it is a new keypair that only signs creation of the account and
+is discarded afterwards.`,
+	Args: cobra.ExactArgs(1),
+	RunE: run.StakeCreate,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeCreateCmd)
+	f := stakeCreateCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.Seed), "", "Seed to derive stake account address from the key")
+	f.String(b(flags.StakeAuthority), "", "Stake authority address or keypair file, defaults to the key")
+	f.String(b(flags.WithdrawAuthority), "", "Withdraw authority address or keypair file, defaults to the key")
+}
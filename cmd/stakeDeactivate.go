@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeDeactivateCmd represents the stakeDeactivate command
+var stakeDeactivateCmd = &cobra.Command{
+	Use:   "deactivate <stake>",
+	Short: "Deactivate delegated stake",
+	Long: `This command deactivates a delegated stake account, which can be
+withdrawn from once its stake has cooled down:
+solana-kms stake deactivate --keyfile=/tmp/key <stake>
+
+Deactivation is signed by the stake authority, which is the key unless
+--stake-authority keypair file is set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: run.StakeDeactivate,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeDeactivateCmd)
+	f := stakeDeactivateCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.StakeAuthority), "", "Stake authority keypair file, defaults to the key")
+}
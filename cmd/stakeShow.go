@@ -0,0 +1,45 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeShowCmd represents the stakeShow command
+var stakeShowCmd = &cobra.Command{
+	Use:   "show <stake>",
+	Short: "Show state of a stake account",
+	Long: `This command prints the state of a stake account as JSON, including its
+authorities, lockup and delegation:
+solana-kms stake show <stake>
+
+No key is needed to show a stake account.`,
+	Args: cobra.ExactArgs(1),
+	RunE: run.StakeShow,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeShowCmd)
+	f := stakeShowCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeSplitCmd represents the stakeSplit command
+var stakeSplitCmd = &cobra.Command{
+	Use:   "split <stake> <amount>",
+	Short: "Split stake into a new stake account",
+	Long: `This command moves amount of SOL from a stake account to a new stake
+account with the same authorities and delegation, and prints the address
+of the new account:
+solana-kms stake split --keyfile=/tmp/key <stake> 5
+
+New account is prefunded with rent exempt balance by the fee payer. Its
+address is derived from the key and --seed when set, otherwise it is a new
+keypair that is discarded after creating the account. Split is signed by
+the stake authority, which is the key unless --stake-authority keypair
+file is set.`,
+	Args: cobra.ExactArgs(2),
+	RunE: run.StakeSplit,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeSplitCmd)
+	f := stakeSplitCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.Seed), "", "Seed to derive new stake account address from the key")
+	f.String(b(flags.StakeAuthority), "", "Stake authority keypair file, defaults to the key")
+}
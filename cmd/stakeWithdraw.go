@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// stakeWithdrawCmd represents the stakeWithdraw command
+var stakeWithdrawCmd = &cobra.Command{
+	Use:   "withdraw <stake> <recipient> <amount>",
+	Short: "Withdraw SOL from a stake account",
+	Long: `This command withdraws SOL from a stake account to recipient. Use ALL as
+amount to withdraw the entire balance, which closes the stake account:
+solana-kms stake withdraw --keyfile=/tmp/key <stake> <recipient> ALL
+
+Withdrawal is signed by the withdraw authority, which is the key unless
+--withdraw-authority keypair file is set.`,
+	Args: cobra.ExactArgs(3),
+	RunE: run.StakeWithdraw,
+}
+
+func init() {
+	stakeCmd.AddCommand(stakeWithdrawCmd)
+	f := stakeWithdrawCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.WithdrawAuthority), "", "Withdraw authority keypair file, defaults to the key")
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// tokenCmd represents the token command
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "SPL token related subcommands",
+	Long: `These commands manage SPL tokens of the Token program with the key
+as mint authority, token account owner and, unless --fee-payer is set,
+fee payer. Amounts are decimal numbers of tokens, which are converted to
+base units using decimals of the mint.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("pl. use a subcommand")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+}
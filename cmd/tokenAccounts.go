@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenAccountsCmd represents the tokenAccounts command
+var tokenAccountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "List token accounts and balances",
+	Long: `This command lists all token accounts of the key, or of --pubkey,
+held by Token and Token-2022 programs along with their mint, balance in
+units of the mint, delegate, close authority and frozen state:
+solana-kms token accounts --keyfile=/tmp/key
+
+Addresses found in address_labels of Solana config are displayed by their
+label. Use --output=json for machine readable output.`,
+	Args: cobra.NoArgs,
+	RunE: run.TokenAccounts,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenAccountsCmd)
+	f := tokenAccountsCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.PubKey), "", "Public key (--keyfile will be ignored)")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.Output), "table", "Output format table|json")
+}
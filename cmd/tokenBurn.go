@@ -0,0 +1,45 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenBurnCmd represents the tokenBurn command
+var tokenBurnCmd = &cobra.Command{
+	Use:   "burn <mint> <amount>",
+	Short: "Burn tokens",
+	Long: `This command burns tokens held in the associated token account of the
+key. Use ALL as amount to burn the entire token balance:
+solana-kms token burn --keyfile=/tmp/key <mint> 10`,
+	Args: cobra.ExactArgs(2),
+	RunE: run.TokenBurn,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenBurnCmd)
+	f := tokenBurnCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+}
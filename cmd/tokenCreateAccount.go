@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenCreateAccountCmd represents the tokenCreateAccount command
+var tokenCreateAccountCmd = &cobra.Command{
+	Use:   "create-account <mint>",
+	Short: "Create an associated token account",
+	Long: `This command creates the associated token account for a mint and
+prints its address. Account is owned by the key unless --owner is set
+and its rent is paid by the fee payer:
+solana-kms token create-account --keyfile=/tmp/key <mint>`,
+	Args: cobra.ExactArgs(1),
+	RunE: run.TokenCreateAccount,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenCreateAccountCmd)
+	f := tokenCreateAccountCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.Owner), "", "Owner of the token account, defaults to the key")
+}
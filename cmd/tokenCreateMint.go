@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenCreateMintCmd represents the tokenCreateMint command
+var tokenCreateMintCmd = &cobra.Command{
+	Use:   "create-mint",
+	Short: "Create a new token mint",
+	Long: `This command creates a new token mint with the key as mint authority
+and prints the mint address:
+solana-kms token create-mint --keyfile=/tmp/key --decimals=6
+
+Mint address is a new keypair that only signs creation of the mint account
+and is discarded afterwards. Use --enable-freeze to also make the key the
+freeze authority of the mint.`,
+	Args: cobra.NoArgs,
+	RunE: run.TokenCreateMint,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenCreateMintCmd)
+	f := tokenCreateMintCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.Int(b(flags.Decimals), 9, "Number of decimal places of token amounts")
+	f.Bool(b(flags.EnableFreeze), false, "Set the key as freeze authority")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenMintToCmd represents the tokenMintTo command
+var tokenMintToCmd = &cobra.Command{
+	Use:   "mint-to <mint> <amount>",
+	Short: "Mint tokens",
+	Long: `This command mints tokens to the associated token account of the key,
+or of --owner, which must already exist. Key must be mint authority of
+the mint:
+solana-kms token mint-to --keyfile=/tmp/key <mint> 1000.5`,
+	Args: cobra.ExactArgs(2),
+	RunE: run.TokenMintTo,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenMintToCmd)
+	f := tokenMintToCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.String(b(flags.Owner), "", "Owner of the token account to mint to, defaults to the key")
+}
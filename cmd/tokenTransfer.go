@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// tokenTransferCmd represents the tokenTransfer command
+var tokenTransferCmd = &cobra.Command{
+	Use:   "transfer <mint> <recipient> <amount>",
+	Short: "Transfer tokens to another wallet",
+	Long: `This command transfers tokens from the associated token account of the
+key to that of the recipient wallet. Use ALL as amount to transfer the
+entire token balance:
+solana-kms token transfer --keyfile=/tmp/key <mint> <recipient> 10
+
+Transfers are refused when the recipient has no token account for the
+mint, use --fund-recipient to create it at the expense of the fee payer.`,
+	Args: cobra.ExactArgs(3),
+	RunE: run.TokenTransfer,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenTransferCmd)
+	f := tokenTransferCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.KeyFile), "", "Keypair file")
+	f.String(b(flags.Url), "", "Solana validator endpoint")
+	f.String(b(flags.FeePayer), "", "Fee payer keypair file, defaults to the key")
+	f.Bool(b(flags.FundRecipient), false, "Create token account of the recipient if needed")
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// verifyMessageCmd represents the verifyMessage command
+var verifyMessageCmd = &cobra.Command{
+	Use:   "verify-message [message]",
+	Short: "Verify message signature against a public key",
+	Long: `This command verifies signature of a message passed as argument, or
+read from --input file or stdin, against a base58 public key. No key is
+decrypted:
+solana-kms verify-message --offchain \
+  --pubkey=9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g \
+  --signature=<signature> \
+  "I own this address"
+
+Use the same --offchain and --encoding flags that were used for signing.
+Command fails when signature is not valid.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: run.VerifyMessage,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyMessageCmd)
+	f := verifyMessageCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.PubKey), "", "Public key of the signer")
+	f.String(b(flags.Signature), "", "Signature to verify")
+	f.String(b(flags.Input), "", "Input message file, defaults to stdin when no message argument is given")
+	f.Bool(b(flags.Offchain), false, "Message was signed as Solana off-chain message")
+	f.String(b(flags.Encoding), "base58", "Signature encoding base58|base64|hex")
+}
@@ -0,0 +1,52 @@
+// Package agent implements a signing agent that holds a signing key, such as a
+// decrypted private key in locked memory, and serves sign-only requests over a
+// Unix socket, along with a client to talk to it. Private key is never sent
+// over the socket.
+package agent
+
+import (
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/portto/solana-go-sdk/common"
+)
+
+const (
+	OpPublicKey       = "pubkey"           // get public key of the agent
+	OpSignMessage     = "sign-message"     // sign arbitrary message
+	OpSignTransaction = "sign-transaction" // sign serialized transaction message
+	OpStop            = "stop"             // wipe key and stop agent
+)
+
+// maxRequestSize caps size of a serialized request line. Transactions are limited
+// to 1232 bytes on the network, so this leaves plenty of room for messages.
+const maxRequestSize = 64 * 1024
+
+// Request is sent by client to the agent as a single line of JSON
+type Request struct {
+	Op   string `json:"op"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// Response is sent by agent to the client as a single line of JSON
+type Response struct {
+	PublicKey string `json:"publicKey,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// IsTransactionSigner reports whether message is a serialized transaction
+// message that requires publicKey to sign it, in which case signing it as an
+// arbitrary message would approve the transaction
+func IsTransactionSigner(message []byte, publicKey common.PublicKey) bool {
+	signers, err := transaction.Signers(message)
+	if err != nil {
+		return false
+	}
+
+	for _, signer := range signers {
+		if signer == publicKey {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+// testSigner signs using a private key held in a secure buffer
+type testSigner struct {
+	publicKey common.PublicKey
+	key       *secure.Buffer
+}
+
+func (s *testSigner) PublicKey() common.PublicKey {
+	return s.publicKey
+}
+
+func (s *testSigner) Sign(_ context.Context, message []byte) ([]byte, error) {
+	return secure.Sign(s.key.Bytes(), message)
+}
+
+func (s *testSigner) Close() error {
+	s.key.Destroy()
+	return nil
+}
+
+// startServer starts agent for a new random key and returns its public key
+// along with socket path and a channel that receives result of Serve
+func startServer(t *testing.T, ttl time.Duration) (common.PublicKey, string, <-chan error) {
+	t.Helper()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := common.PublicKeyFromBytes(privateKey[32:])
+
+	key, err := secure.Copy(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(&testSigner{publicKey: publicKey, key: key}, ttl)
+
+	socket := filepath.Join(t.TempDir(), "agent", "agent.sock")
+	listener, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(context.Background(), listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return publicKey, socket, errs
+}
+
+// transferMessage returns serialized transfer message paid for by from
+func transferMessage(t *testing.T, from, to common.PublicKey) []byte {
+	t.Helper()
+
+	message := types.NewMessage(types.NewMessageParam{
+		FeePayer: from,
+		Instructions: []types.Instruction{
+			sysprog.Transfer(sysprog.TransferParam{From: from, To: to, Amount: 1}),
+		},
+		RecentBlockhash: common.SystemProgramID.ToBase58(),
+	})
+
+	data, err := message.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestServer(t *testing.T) {
+	publicKey, socket, errs := startServer(t, 0)
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected socket permissions 0600, got %o", perm)
+	}
+
+	client, err := Dial(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	got, err := client.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != publicKey.ToBase58() {
+		t.Fatalf("expected public key %s, got %s", publicKey.ToBase58(), got)
+	}
+
+	message := []byte("hello")
+	signature, err := client.SignMessage(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey[:], message, signature) {
+		t.Fatal("message signature does not verify")
+	}
+
+	other := common.PublicKeyFromString("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g")
+	transaction := transferMessage(t, publicKey, other)
+	signature, err = client.SignTransaction(transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey[:], transaction, signature) {
+		t.Fatal("transaction signature does not verify")
+	}
+
+	if _, err := client.SignMessage(transaction); err == nil {
+		t.Fatal("expected signing transaction as a message to fail")
+	}
+
+	if _, err := client.SignTransaction(transferMessage(t, other, publicKey)); err == nil {
+		t.Fatal("expected signing transaction without agent signer to fail")
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Fatalf("expected socket to be removed, got %v", err)
+	}
+}
+
+func TestServerIdleTTL(t *testing.T) {
+	_, socket, errs := startServer(t, 50*time.Millisecond)
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not expire")
+	}
+
+	if _, err := Dial(socket); err == nil {
+		t.Fatal("expected agent to be unreachable after ttl")
+	}
+}
+
+func TestListen(t *testing.T) {
+	_, socket, _ := startServer(t, 0)
+
+	if _, err := Listen(socket); err == nil {
+		t.Fatal("expected listening on socket of running agent to fail")
+	}
+
+	// stale socket of an agent that is gone is replaced
+	stale := filepath.Join(filepath.Dir(socket), "stale.sock")
+	listener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = listener.Close()
+
+	listener, err = Listen(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = listener.Close()
+
+	// regular files are never removed
+	file := filepath.Join(filepath.Dir(socket), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Fatalf("expected listening on regular file to fail, got %v", err)
+	}
+
+	// socket dir that other users can reach is refused
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(filepath.Join(dir, "agent.sock")); err == nil ||
+		!strings.Contains(err.Error(), "must not be accessible") {
+		t.Fatalf("expected listening in group or world accessible dir to fail, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Client talks to a signing agent over its Unix socket
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// Dial connects to the agent listening on Unix socket at path
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		err := fmt.Errorf("could not connect to agent: %w", err)
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+
+	return &Client{
+		conn:    conn,
+		scanner: scanner,
+	}, nil
+}
+
+// PublicKey returns public key of the key held by the agent
+func (c *Client) PublicKey() (string, error) {
+	response, err := c.call(&Request{Op: OpPublicKey})
+	if err != nil {
+		return "", err
+	}
+
+	return response.PublicKey, nil
+}
+
+// SignMessage returns signature over message. Agent refuses to sign messages
+// that are transactions requiring its signature.
+func (c *Client) SignMessage(message []byte) ([]byte, error) {
+	response, err := c.call(&Request{Op: OpSignMessage, Data: message})
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Signature, nil
+}
+
+// SignTransaction returns signature over serialized transaction message
+func (c *Client) SignTransaction(message []byte) ([]byte, error) {
+	response, err := c.call(&Request{Op: OpSignTransaction, Data: message})
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Signature, nil
+}
+
+// Stop asks the agent to wipe its key and exit
+func (c *Client) Stop() error {
+	_, err := c.call(&Request{Op: OpStop})
+	return err
+}
+
+// Close closes connection to the agent
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call sends request and waits for response
+func (c *Client) call(request *Request) (*Response, error) {
+	if err := json.NewEncoder(c.conn).Encode(request); err != nil {
+		err := fmt.Errorf("could not send request to agent: %w", err)
+		return nil, err
+	}
+
+	if !c.scanner.Scan() {
+		err := c.scanner.Err()
+		if err == nil {
+			err = errors.New("connection closed")
+		}
+		err = fmt.Errorf("could not read response from agent: %w", err)
+		return nil, err
+	}
+
+	response := &Response{}
+	if err := json.Unmarshal(c.scanner.Bytes(), response); err != nil {
+		err := fmt.Errorf("invalid response from agent: %w", err)
+		return nil, err
+	}
+
+	if len(response.Error) > 0 {
+		err := fmt.Errorf("agent error: %s", response.Error)
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/portto/solana-go-sdk/common"
+)
+
+// connTimeout bounds how long a single client connection may stay open
+const connTimeout = time.Minute
+
+// Signer signs messages using a key that is never exposed to agent clients
+type Signer interface {
+	PublicKey() common.PublicKey
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+	// Close wipes or releases the key
+	Close() error
+}
+
+// Server serves sign requests using a signer
+type Server struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	signer    Signer
+	publicKey common.PublicKey
+	ttl       time.Duration
+	timer     *time.Timer
+	listener  net.Listener
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewServer creates a server that takes ownership of signer. Signer is closed
+// once ttl elapses without any request, and a ttl of zero keeps it until the
+// server is stopped.
+func NewServer(signer Signer, ttl time.Duration) *Server {
+	return &Server{
+		ctx:       context.Background(),
+		signer:    signer,
+		publicKey: signer.PublicKey(),
+		ttl:       ttl,
+		done:      make(chan struct{}),
+	}
+}
+
+// Listen creates a Unix socket at path that is accessible only to the current
+// user. Socket dir must be owned by the current user and closed to everyone
+// else. A stale socket left behind by an agent that is no longer running is
+// replaced, however, a running agent is never displaced.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		err := fmt.Errorf("could not create socket dir: %w", err)
+		return nil, err
+	}
+
+	if err := checkSocketDir(dir); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			err := fmt.Errorf("%s exists and is not a socket", path)
+			return nil, err
+		}
+
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			err := fmt.Errorf("agent is already running at %s", path)
+			return nil, err
+		}
+
+		if err := os.Remove(path); err != nil {
+			err := fmt.Errorf("could not remove stale socket: %w", err)
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		err := fmt.Errorf("could not listen on socket: %w", err)
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = listener.Close()
+		err := fmt.Errorf("could not restrict socket permissions: %w", err)
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// PublicKey returns public key of the key held by the server
+func (s *Server) PublicKey() string {
+	return s.publicKey.ToBase58()
+}
+
+// Serve accepts connections on listener until the server is stopped by a
+// client, its idle ttl elapses or ctx is done. Signer is closed and listener
+// closed when Serve returns.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.listener = listener
+	if s.ttl > 0 {
+		s.timer = time.AfterFunc(s.ttl, s.Stop)
+	}
+	s.mu.Unlock()
+	defer s.Stop()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-s.done:
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				err := fmt.Errorf("could not accept connection: %w", err)
+				return err
+			}
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// Done returns a channel that is closed once the server is stopped
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Stop closes the signer, wiping its key, and closes the listener. It is safe
+// to call more than once.
+func (s *Server) Stop() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		close(s.done)
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		_ = s.signer.Close()
+		s.signer = nil
+		if s.listener != nil {
+			_ = s.listener.Close()
+		}
+	})
+}
+
+// handle serves requests on a single connection, one JSON line per request
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxRequestSize)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		request := &Request{}
+		response := &Response{}
+		if err := json.Unmarshal(scanner.Bytes(), request); err != nil {
+			response.Error = fmt.Sprintf("invalid request: %v", err)
+		} else if err := s.process(request, response); err != nil {
+			response.Error = err.Error()
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+
+		if request.Op == OpStop && len(response.Error) == 0 {
+			s.Stop()
+			return
+		}
+	}
+}
+
+// process executes request and fills in response
+func (s *Server) process(request *Request, response *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signer == nil {
+		return errors.New("agent key has expired")
+	}
+
+	if s.timer != nil {
+		s.timer.Reset(s.ttl)
+	}
+
+	response.PublicKey = s.publicKey.ToBase58()
+	switch request.Op {
+	case OpPublicKey, OpStop:
+		return nil
+	case OpSignMessage:
+		// a transaction must not be approved under the guise of a message
+		if IsTransactionSigner(request.Data, s.publicKey) {
+			return fmt.Errorf("refusing to sign transaction as a message, use %s", OpSignTransaction)
+		}
+	case OpSignTransaction:
+		message, err := transaction.ParseMessage(request.Data)
+		if err != nil {
+			return fmt.Errorf("invalid transaction message: %w", err)
+		}
+
+		if message.SignerIndex(s.publicKey) < 0 {
+			return fmt.Errorf("%s is not a required signer of the transaction", s.publicKey.ToBase58())
+		}
+	default:
+		return fmt.Errorf("unsupported operation %q", request.Op)
+	}
+
+	signature, err := s.signer.Sign(s.ctx, request.Data)
+	if err != nil {
+		return err
+	}
+
+	response.Signature = signature
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkSocketDir ensures that only the current user can reach sockets in dir,
+// so that a socket is never exposed before its permissions are restricted
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		err := fmt.Errorf("could not stat socket dir: %w", err)
+		return err
+	}
+
+	if !info.IsDir() {
+		err := fmt.Errorf("socket dir %s is not a directory", dir)
+		return err
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Getuid() {
+		err := fmt.Errorf("socket dir %s is not owned by current user", dir)
+		return err
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		err := fmt.Errorf("socket dir %s must not be accessible by group or others, run chmod 700 %s", dir, dir)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package agent
+
+// checkSocketDir is a no-op since file modes do not restrict access to
+// sockets on this platform
+func checkSocketDir(string) error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// awsKms implements KeyEncrypter using AWS KMS
+type awsKms struct {
+	client *kms.Client
+	keyArn string
+}
+
+// NewAwsKms creates an AWS KMS backed KeyEncrypter for the key referenced by keyArn.
+// Region and profile are optional and default to AWS SDK defaults when empty.
+// Endpoint is optional and overrides the AWS KMS service endpoint, which is useful
+// when working against a local KMS stand-in.
+func NewAwsKms(ctx context.Context, keyArn, region, profile, endpoint string) (KeyEncrypter, error) {
+	if len(keyArn) == 0 {
+		err := fmt.Errorf("aws kms key arn is required")
+		return nil, err
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if len(region) > 0 {
+		opts = append(opts, config.WithRegion(region))
+	}
+	if len(profile) > 0 {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		err := fmt.Errorf("failed to load aws config: %w", err)
+		return nil, err
+	}
+
+	var kmsOpts []func(*kms.Options)
+	if len(endpoint) > 0 {
+		kmsOpts = append(kmsOpts, func(o *kms.Options) {
+			o.EndpointResolver = kms.EndpointResolverFromURL(endpoint)
+		})
+	}
+
+	return &awsKms{
+		client: kms.NewFromConfig(cfg, kmsOpts...),
+		keyArn: keyArn,
+	}, nil
+}
+
+func (a *awsKms) Encrypt(ctx context.Context, plaintext, aad []byte) (*Ciphertext, error) {
+	encryptOutput, err := a.client.Encrypt(
+		ctx,
+		&kms.EncryptInput{
+			KeyId:             aws.String(a.keyArn),
+			Plaintext:         plaintext,
+			EncryptionContext: awsEncryptionContext(aad),
+		},
+	)
+	if err != nil {
+		err := fmt.Errorf("kms encrypt request failed: %w", err)
+		return nil, err
+	}
+
+	keyVersion := a.keyArn
+	if encryptOutput.KeyId != nil {
+		keyVersion = *encryptOutput.KeyId
+	}
+
+	return &Ciphertext{
+		Data:       encryptOutput.CiphertextBlob,
+		Backend:    Aws,
+		KeyVersion: keyVersion,
+	}, nil
+}
+
+func (a *awsKms) Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	decryptOutput, err := a.client.Decrypt(
+		ctx,
+		&kms.DecryptInput{
+			KeyId:             aws.String(a.keyArn),
+			CiphertextBlob:    ciphertext,
+			EncryptionContext: awsEncryptionContext(aad),
+		},
+	)
+	if err != nil {
+		err := fmt.Errorf("kms decrypt request failed: %w", err)
+		return nil, err
+	}
+
+	return decryptOutput.Plaintext, nil
+}
+
+func (a *awsKms) Type() string {
+	return Aws
+}
+
+func (a *awsKms) Describe() string {
+	return fmt.Sprintf("%s:%s", Aws, a.keyArn)
+}
+
+func (a *awsKms) Close() error {
+	return nil
+}
+
+// awsEncryptionContext maps additional authenticated data to AWS KMS
+// encryption context, which is a set of key-value pairs
+func awsEncryptionContext(aad []byte) map[string]string {
+	if len(aad) == 0 {
+		return nil
+	}
+
+	return map[string]string{
+		"aad": base64.StdEncoding.EncodeToString(aad),
+	}
+}
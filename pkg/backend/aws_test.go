@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newAwsKmsStandIn starts a minimal stand-in for AWS KMS JSON protocol that
+// "encrypts" by prefixing plaintext with the key ARN.
+func newAwsKmsStandIn(t *testing.T, keyArn string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			KeyId          string `json:"KeyId"`
+			Plaintext      []byte `json:"Plaintext"`
+			CiphertextBlob []byte `json:"CiphertextBlob"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.KeyId != keyArn {
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"NotFoundException","message":"key not found"}`))
+			return
+		}
+
+		var resp interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			resp = map[string]interface{}{
+				"KeyId":          keyArn,
+				"CiphertextBlob": append([]byte(keyArn), req.Plaintext...),
+			}
+		case "TrentService.Decrypt":
+			if !bytes.HasPrefix(req.CiphertextBlob, []byte(keyArn)) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"bad ciphertext"}`))
+				return
+			}
+			resp = map[string]interface{}{
+				"KeyId":     keyArn,
+				"Plaintext": bytes.TrimPrefix(req.CiphertextBlob, []byte(keyArn)),
+			}
+		default:
+			http.Error(w, "unsupported operation", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestAwsKmsRoundTrip(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+
+	keyArn := "arn:aws:kms:us-east-1:111122223333:key/test"
+	server := newAwsKmsStandIn(t, keyArn)
+	defer server.Close()
+
+	ctx := context.Background()
+	keyEncrypter, err := NewAwsKms(ctx, keyArn, "us-east-1", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keyEncrypter.Close()
+
+	plaintext := []byte("this is a seed")
+	ciphertext, err := keyEncrypter.Encrypt(ctx, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Fatal("decrypted data does not match plaintext")
+	}
+
+	if _, err := keyEncrypter.Decrypt(ctx, []byte("garbage"), nil); err == nil {
+		t.Fatal("expected error decrypting garbage")
+	}
+}
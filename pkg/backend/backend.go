@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"crypto/ed25519"
+	"hash/crc32"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	Google = "gcp"   // Google Cloud KMS
+	Aws    = "aws"   // AWS KMS
+	Vault  = "vault" // HashiCorp Vault Transit secrets engine
+	Local  = "local" // Passphrase based local encryption
+)
+
+// Ciphertext is the result of an encryption operation
+type Ciphertext struct {
+	// Data is the encrypted payload
+	Data []byte
+	// Backend is the type of the backend that produced the ciphertext
+	Backend string
+	// KeyVersion identifies the key version that was used for encryption,
+	// for instance, full CryptoKeyVersion name for Google Cloud KMS
+	KeyVersion string
+}
+
+// KeyEncrypter wraps a key management service that is able to encrypt
+// and decrypt small payloads such as private keys and seeds.
+type KeyEncrypter interface {
+	// Encrypt encrypts plaintext and returns ciphertext. Additional authenticated
+	// data, aad, is optional and if provided, must be presented again at decryption.
+	Encrypt(ctx context.Context, plaintext, aad []byte) (*Ciphertext, error)
+	// Decrypt decrypts ciphertext and returns plaintext. Additional authenticated
+	// data, aad, must match the one used during encryption.
+	Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error)
+	// Type returns backend type such as gcp, aws, vault or local
+	Type() string
+	// Describe returns a human readable description of the backend and the key in use
+	Describe() string
+	// Close releases any resources held by the backend
+	Close() error
+}
+
+// Signer wraps an Ed25519 signing key held by a key management service.
+// Private key never leaves the service, all signing happens remotely.
+type Signer interface {
+	// PublicKey returns ed25519 public key of the signing key
+	PublicKey(ctx context.Context) (ed25519.PublicKey, error)
+	// Sign signs message and returns ed25519 signature over it
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+	// KeyVersion identifies the signing key version, for instance, full
+	// CryptoKeyVersion name for Google Cloud KMS
+	KeyVersion() string
+	// Close releases any resources held by the backend
+	Close() error
+}
+
+// crc32Value returns crc32 sum of data as a proto wrapper value or
+// nil when data is empty
+func crc32Value(data []byte) *wrapperspb.Int64Value {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return wrapperspb.Int64(int64(crc32Sum(data)))
+}
+
+// crc32Matches reports whether checksum returned by KMS matches data. Missing
+// checksum is treated as a mismatch.
+func crc32Matches(data []byte, checksum *wrapperspb.Int64Value) bool {
+	return checksum != nil && checksum.GetValue() == int64(crc32Sum(data))
+}
+
+// crc32Sum produces crc32 sum
+func crc32Sum(data []byte) uint32 {
+	t := crc32.MakeTable(crc32.Castagnoli)
+	return crc32.Checksum(data, t)
+}
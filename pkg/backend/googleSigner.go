@@ -0,0 +1,237 @@
+package backend
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"google.golang.org/api/option"
+	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// googleEd25519Algorithm is EC_SIGN_ED25519, which is not yet part of the
+// KMS protos this module depends on
+const googleEd25519Algorithm = kms2.CryptoKeyVersion_CryptoKeyVersionAlgorithm(40)
+
+// googleKeyVersionPollInterval is how often state of a key version pending
+// generation is checked. It is a variable so that tests can shorten it.
+var googleKeyVersionPollInterval = time.Second
+
+// googleKmsSigner implements Signer using an asymmetric Google Cloud KMS key
+type googleKmsSigner struct {
+	client    *kms.KeyManagementClient
+	name      string
+	mu        sync.Mutex
+	publicKey ed25519.PublicKey
+}
+
+// NewGoogleKmsSigner creates a Google Cloud KMS backed Signer for the existing
+// crypto key version referenced by name, which is of the form
+// projects/<project>/locations/<location>/keyRings/<keyring>/cryptoKeys/<key>/cryptoKeyVersions/<version>.
+func NewGoogleKmsSigner(ctx context.Context, name string, opts ...option.ClientOption) (Signer, error) {
+	kmsClient, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		err := fmt.Errorf("failed to create kms client: %w", err)
+		return nil, err
+	}
+
+	return &googleKmsSigner{
+		client: kmsClient,
+		name:   name,
+	}, nil
+}
+
+// CreateGoogleKmsSigner creates a new EC_SIGN_ED25519 key version under crypto
+// key referenced by name, creating the crypto key itself if it does not exist,
+// and returns a Signer for it once the key version is generated.
+func CreateGoogleKmsSigner(ctx context.Context, name string, opts ...option.ClientOption) (Signer, error) {
+	kmsClient, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		err := fmt.Errorf("failed to create kms client: %w", err)
+		return nil, err
+	}
+
+	version, err := createGoogleSigningKeyVersion(ctx, kmsClient, name)
+	if err != nil {
+		_ = kmsClient.Close()
+		return nil, err
+	}
+
+	return &googleKmsSigner{
+		client: kmsClient,
+		name:   version,
+	}, nil
+}
+
+// createGoogleSigningKeyVersion creates a new signing key version and waits
+// for it to be enabled
+func createGoogleSigningKeyVersion(ctx context.Context, client *kms.KeyManagementClient, name string) (string, error) {
+	cryptoKey, err := client.GetCryptoKey(ctx, &kms2.GetCryptoKeyRequest{Name: name})
+	switch {
+	case status.Code(err) == codes.NotFound:
+		parent, id := name[:strings.LastIndex(name, "/cryptoKeys/")], name[strings.LastIndex(name, "/")+1:]
+		if _, err := client.CreateCryptoKey(
+			ctx,
+			&kms2.CreateCryptoKeyRequest{
+				Parent:      parent,
+				CryptoKeyId: id,
+				CryptoKey: &kms2.CryptoKey{
+					Purpose: kms2.CryptoKey_ASYMMETRIC_SIGN,
+					VersionTemplate: &kms2.CryptoKeyVersionTemplate{
+						Algorithm: googleEd25519Algorithm,
+					},
+				},
+				SkipInitialVersionCreation: true,
+			},
+		); err != nil {
+			err := fmt.Errorf("kms create crypto key request failed: %w", err)
+			return "", err
+		}
+	case err != nil:
+		err := fmt.Errorf("kms get crypto key request failed: %w", err)
+		return "", err
+	case cryptoKey.Purpose != kms2.CryptoKey_ASYMMETRIC_SIGN ||
+		cryptoKey.GetVersionTemplate().GetAlgorithm() != googleEd25519Algorithm:
+		err := fmt.Errorf("kms key %s is not an EC_SIGN_ED25519 asymmetric signing key", name)
+		return "", err
+	}
+
+	version, err := client.CreateCryptoKeyVersion(
+		ctx,
+		&kms2.CreateCryptoKeyVersionRequest{
+			Parent:           name,
+			CryptoKeyVersion: &kms2.CryptoKeyVersion{},
+		},
+	)
+	if err != nil {
+		err := fmt.Errorf("kms create crypto key version request failed: %w", err)
+		return "", err
+	}
+
+	// asymmetric key versions are generated asynchronously
+	for version.State == kms2.CryptoKeyVersion_PENDING_GENERATION {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(googleKeyVersionPollInterval):
+		}
+
+		version, err = client.GetCryptoKeyVersion(ctx, &kms2.GetCryptoKeyVersionRequest{Name: version.Name})
+		if err != nil {
+			err := fmt.Errorf("kms get crypto key version request failed: %w", err)
+			return "", err
+		}
+	}
+
+	if version.State != kms2.CryptoKeyVersion_ENABLED {
+		err := fmt.Errorf("kms key version %s is in state %s", version.Name, version.State)
+		return "", err
+	}
+
+	return version.Name, nil
+}
+
+func (g *googleKmsSigner) PublicKey(ctx context.Context) (ed25519.PublicKey, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.publicKey != nil {
+		return g.publicKey, nil
+	}
+
+	response, err := g.client.GetPublicKey(ctx, &kms2.GetPublicKeyRequest{Name: g.name})
+	if err != nil {
+		err := fmt.Errorf("kms get public key request failed: %w", err)
+		return nil, err
+	}
+
+	if !crc32Matches([]byte(response.Pem), response.PemCrc32C) {
+		err := fmt.Errorf("kms get public key response corrupted in transit: pem crc32c mismatch")
+		return nil, err
+	}
+
+	if response.Algorithm != googleEd25519Algorithm {
+		err := fmt.Errorf("kms key version %s is not an EC_SIGN_ED25519 key, found %s", g.name, response.Algorithm)
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(response.Pem))
+	if block == nil {
+		err := fmt.Errorf("kms public key is not PEM encoded")
+		return nil, err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		err := fmt.Errorf("could not parse kms public key: %w", err)
+		return nil, err
+	}
+
+	ed25519PublicKey, ok := publicKey.(ed25519.PublicKey)
+	if !ok {
+		err := fmt.Errorf("kms public key is of type %T, expected ed25519", publicKey)
+		return nil, err
+	}
+
+	g.publicKey = ed25519PublicKey
+	return g.publicKey, nil
+}
+
+func (g *googleKmsSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	publicKey, err := g.PublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	signResponse, err := g.client.AsymmetricSign(
+		ctx,
+		&kms2.AsymmetricSignRequest{
+			Name:       g.name,
+			Data:       message,
+			DataCrc32C: wrapperspb.Int64(int64(crc32Sum(message))),
+		},
+	)
+	if err != nil {
+		err := fmt.Errorf("kms asymmetric sign request failed: %w", err)
+		return nil, err
+	}
+
+	if !signResponse.VerifiedDataCrc32C {
+		err := fmt.Errorf("kms asymmetric sign request corrupted in transit: data crc32c not verified")
+		return nil, err
+	}
+
+	if signResponse.Name != g.name {
+		err := fmt.Errorf("kms asymmetric sign response is for key version %s, expected %s", signResponse.Name, g.name)
+		return nil, err
+	}
+
+	if !crc32Matches(signResponse.Signature, signResponse.SignatureCrc32C) {
+		err := fmt.Errorf("kms asymmetric sign response corrupted in transit: signature crc32c mismatch")
+		return nil, err
+	}
+
+	if !ed25519.Verify(publicKey, message, signResponse.Signature) {
+		err := fmt.Errorf("kms signature does not verify against public key")
+		return nil, err
+	}
+
+	return signResponse.Signature, nil
+}
+
+func (g *googleKmsSigner) KeyVersion() string {
+	return g.name
+}
+
+func (g *googleKmsSigner) Close() error {
+	return g.client.Close()
+}
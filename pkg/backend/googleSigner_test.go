@@ -0,0 +1,238 @@
+package backend
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeGoogleKmsSigner is a stand-in for Google Cloud KMS asymmetric signing keys.
+// Key versions are reported as pending generation once before being enabled.
+type fakeGoogleKmsSigner struct {
+	kms2.UnimplementedKeyManagementServiceServer
+	mu         sync.Mutex
+	cryptoKeys map[string]*kms2.CryptoKey
+	versions   map[string]ed25519.PrivateKey
+	pending    map[string]bool
+}
+
+func newFakeGoogleKmsSigner() *fakeGoogleKmsSigner {
+	return &fakeGoogleKmsSigner{
+		cryptoKeys: make(map[string]*kms2.CryptoKey),
+		versions:   make(map[string]ed25519.PrivateKey),
+		pending:    make(map[string]bool),
+	}
+}
+
+func (f *fakeGoogleKmsSigner) GetCryptoKey(_ context.Context, req *kms2.GetCryptoKeyRequest) (*kms2.CryptoKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cryptoKey, ok := f.cryptoKeys[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+
+	return cryptoKey, nil
+}
+
+func (f *fakeGoogleKmsSigner) CreateCryptoKey(_ context.Context, req *kms2.CreateCryptoKeyRequest) (*kms2.CryptoKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cryptoKey := req.CryptoKey
+	cryptoKey.Name = req.Parent + "/cryptoKeys/" + req.CryptoKeyId
+	f.cryptoKeys[cryptoKey.Name] = cryptoKey
+	return cryptoKey, nil
+}
+
+func (f *fakeGoogleKmsSigner) CreateCryptoKeyVersion(_ context.Context, req *kms2.CreateCryptoKeyVersionRequest) (*kms2.CryptoKeyVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cryptoKeys[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", req.Parent)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s/cryptoKeyVersions/%d", req.Parent, len(f.versions)+1)
+	f.versions[name] = privateKey
+	f.pending[name] = true
+
+	return &kms2.CryptoKeyVersion{
+		Name:  name,
+		State: kms2.CryptoKeyVersion_PENDING_GENERATION,
+	}, nil
+}
+
+func (f *fakeGoogleKmsSigner) GetCryptoKeyVersion(_ context.Context, req *kms2.GetCryptoKeyVersionRequest) (*kms2.CryptoKeyVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.versions[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+
+	state := kms2.CryptoKeyVersion_ENABLED
+	if f.pending[req.Name] {
+		state = kms2.CryptoKeyVersion_PENDING_GENERATION
+		f.pending[req.Name] = false
+	}
+
+	return &kms2.CryptoKeyVersion{
+		Name:      req.Name,
+		State:     state,
+		Algorithm: googleEd25519Algorithm,
+	}, nil
+}
+
+func (f *fakeGoogleKmsSigner) GetPublicKey(_ context.Context, req *kms2.GetPublicKeyRequest) (*kms2.PublicKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	privateKey, ok := f.versions[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	pemData := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	return &kms2.PublicKey{
+		Pem:       pemData,
+		PemCrc32C: wrapperspb.Int64(int64(crc32Sum([]byte(pemData)))),
+		Algorithm: googleEd25519Algorithm,
+	}, nil
+}
+
+func (f *fakeGoogleKmsSigner) AsymmetricSign(_ context.Context, req *kms2.AsymmetricSignRequest) (*kms2.AsymmetricSignResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	privateKey, ok := f.versions[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+
+	signature := ed25519.Sign(privateKey, req.Data)
+	return &kms2.AsymmetricSignResponse{
+		Name:               req.Name,
+		Signature:          signature,
+		SignatureCrc32C:    wrapperspb.Int64(int64(crc32Sum(signature))),
+		VerifiedDataCrc32C: req.DataCrc32C.GetValue() == int64(crc32Sum(req.Data)),
+	}, nil
+}
+
+func newFakeGoogleKmsSignerOptions(t *testing.T, fake *fakeGoogleKmsSigner) []option.ClientOption {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	kms2.RegisterKeyManagementServiceServer(server, fake)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	orig := googleKeyVersionPollInterval
+	googleKeyVersionPollInterval = time.Millisecond
+	t.Cleanup(func() { googleKeyVersionPollInterval = orig })
+
+	return []option.ClientOption{
+		option.WithEndpoint(listener.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+	}
+}
+
+func TestGoogleKmsSigner(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleKmsSigner()
+	opts := newFakeGoogleKmsSignerOptions(t, fake)
+
+	signer, err := CreateGoogleKmsSigner(ctx, testGoogleKmsName, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	if signer.KeyVersion() != testGoogleKmsName+"/cryptoKeyVersions/1" {
+		t.Fatalf("unexpected key version %q", signer.KeyVersion())
+	}
+
+	publicKey, err := signer.PublicKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("hello")
+	signature, err := signer.Sign(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		t.Fatal("signature does not verify")
+	}
+
+	// existing key gets a new version
+	second, err := CreateGoogleKmsSigner(ctx, testGoogleKmsName, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if second.KeyVersion() != testGoogleKmsName+"/cryptoKeyVersions/2" {
+		t.Fatalf("unexpected key version %q", second.KeyVersion())
+	}
+
+	// existing version is used as is
+	existing, err := NewGoogleKmsSigner(ctx, signer.KeyVersion(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer existing.Close()
+
+	existingPublicKey, err := existing.PublicKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existingPublicKey.Equal(publicKey) {
+		t.Fatal("public key of existing key version does not match")
+	}
+}
+
+func TestGoogleKmsSignerRejectsOtherKeys(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGoogleKmsSigner()
+	opts := newFakeGoogleKmsSignerOptions(t, fake)
+
+	fake.cryptoKeys[testGoogleKmsName] = &kms2.CryptoKey{
+		Name:    testGoogleKmsName,
+		Purpose: kms2.CryptoKey_ENCRYPT_DECRYPT,
+	}
+
+	if _, err := CreateGoogleKmsSigner(ctx, testGoogleKmsName, opts...); err == nil {
+		t.Fatal("expected symmetric key to be rejected")
+	}
+}
@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/api/option"
+	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testGoogleKmsName = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+
+// fakeGoogleKms is a stand-in for Google Cloud KMS that "encrypts" by
+// flipping bits and can be told to corrupt checksums
+type fakeGoogleKms struct {
+	kms2.UnimplementedKeyManagementServiceServer
+	corruptResponse   bool
+	unverifiedRequest bool
+}
+
+func flipBits(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = ^b
+	}
+	return out
+}
+
+func (f *fakeGoogleKms) Encrypt(_ context.Context, req *kms2.EncryptRequest) (*kms2.EncryptResponse, error) {
+	ciphertext := append(flipBits(req.Plaintext), req.AdditionalAuthenticatedData...)
+	checksum := int64(crc32Sum(ciphertext))
+	if f.corruptResponse {
+		checksum++
+	}
+
+	return &kms2.EncryptResponse{
+		Name:             req.Name + "/cryptoKeyVersions/1",
+		Ciphertext:       ciphertext,
+		CiphertextCrc32C: wrapperspb.Int64(checksum),
+		VerifiedPlaintextCrc32C: !f.unverifiedRequest &&
+			req.PlaintextCrc32C.GetValue() == int64(crc32Sum(req.Plaintext)),
+		VerifiedAdditionalAuthenticatedDataCrc32C: !f.unverifiedRequest &&
+			req.AdditionalAuthenticatedDataCrc32C.GetValue() == int64(crc32Sum(req.AdditionalAuthenticatedData)),
+	}, nil
+}
+
+func (f *fakeGoogleKms) Decrypt(_ context.Context, req *kms2.DecryptRequest) (*kms2.DecryptResponse, error) {
+	plaintext := flipBits(bytes.TrimSuffix(req.Ciphertext, req.AdditionalAuthenticatedData))
+	checksum := int64(crc32Sum(plaintext))
+	if f.corruptResponse {
+		checksum++
+	}
+
+	return &kms2.DecryptResponse{
+		Plaintext:       plaintext,
+		PlaintextCrc32C: wrapperspb.Int64(checksum),
+	}, nil
+}
+
+func newFakeGoogleKms(t *testing.T, fake *fakeGoogleKms) KeyEncrypter {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	kms2.RegisterKeyManagementServiceServer(server, fake)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	keyEncrypter, err := NewGoogleKms(
+		context.Background(),
+		testGoogleKmsName,
+		option.WithEndpoint(listener.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = keyEncrypter.Close() })
+
+	return keyEncrypter
+}
+
+func TestGoogleKmsChecksums(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGoogleKms{}
+	keyEncrypter := newFakeGoogleKms(t, fake)
+
+	plaintext, aad := []byte("this is a seed"), []byte("aad")
+	ciphertext, err := keyEncrypter.Encrypt(ctx, plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ciphertext.KeyVersion != testGoogleKmsName+"/cryptoKeyVersions/1" {
+		t.Fatalf("unexpected key version %q", ciphertext.KeyVersion)
+	}
+
+	decrypted, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatal("decrypted data does not match plaintext")
+	}
+
+	fake.corruptResponse = true
+	if _, err := keyEncrypter.Encrypt(ctx, plaintext, aad); err == nil {
+		t.Fatal("expected error on corrupted ciphertext checksum")
+	}
+	if _, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, aad); err == nil {
+		t.Fatal("expected error on corrupted plaintext checksum")
+	}
+
+	fake.corruptResponse, fake.unverifiedRequest = false, true
+	if _, err := keyEncrypter.Encrypt(ctx, plaintext, aad); err == nil {
+		t.Fatal("expected error when request checksums are not verified")
+	}
+}
@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	localMagic   = "slk1"  // local backend ciphertext header magic and version
+	localLogN    = 15      // scrypt cost parameter as a power of 2
+	localR       = 8       // scrypt block size parameter
+	localP       = 1       // scrypt parallelization parameter
+	localMaxLogN = 22      // upper bound on cost parameter accepted when decrypting
+	localMaxMem  = 1 << 30 // upper bound on scrypt memory 128·r·2^logN accepted when decrypting
+	localMaxRP   = 16      // upper bound on r·p accepted when decrypting
+	localSaltLen = 16
+	localKeyLen  = 32
+)
+
+// PassphraseFunc returns passphrase to be used for key derivation. The confirm
+// argument is true when the passphrase is going to be used for encryption and
+// the user should be asked to enter it twice.
+type PassphraseFunc func(confirm bool) ([]byte, error)
+
+// localPassphrase implements KeyEncrypter without any remote key management service
+// by deriving an AES-256-GCM key from a passphrase using scrypt
+type localPassphrase struct {
+	passphraseFunc PassphraseFunc
+	passphrase     []byte
+}
+
+// NewLocalPassphrase creates a KeyEncrypter that works fully offline. The passphrase
+// is obtained lazily on first use via passphraseFunc and reused thereafter.
+//
+// Ciphertext layout is magic | logN | r | p | salt | nonce | sealed data, where
+// the header preceding the nonce followed by aad is authenticated as additional data.
+func NewLocalPassphrase(passphraseFunc PassphraseFunc) (KeyEncrypter, error) {
+	if passphraseFunc == nil {
+		err := fmt.Errorf("passphrase func cannot be nil")
+		return nil, err
+	}
+
+	return &localPassphrase{
+		passphraseFunc: passphraseFunc,
+	}, nil
+}
+
+func (l *localPassphrase) Encrypt(_ context.Context, plaintext, aad []byte) (*Ciphertext, error) {
+	if err := l.getPassphrase(true); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(localMagic)+3+localSaltLen)
+	header = append(header, localMagic...)
+	header = append(header, localLogN, localR, localP)
+
+	salt := make([]byte, localSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		err := fmt.Errorf("could not generate salt: %w", err)
+		return nil, err
+	}
+	header = append(header, salt...)
+
+	aead, err := l.newAead(salt, localLogN, localR, localP)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		err := fmt.Errorf("could not generate nonce: %w", err)
+		return nil, err
+	}
+
+	additionalData := append(append([]byte{}, header...), aad...)
+	ciphertext := append(append([]byte{}, header...), nonce...)
+	return &Ciphertext{
+		Data:       aead.Seal(ciphertext, nonce, plaintext, additionalData),
+		Backend:    Local,
+		KeyVersion: "scrypt-aes256gcm",
+	}, nil
+}
+
+func (l *localPassphrase) Decrypt(_ context.Context, ciphertext, aad []byte) ([]byte, error) {
+	headerLen := len(localMagic) + 3 + localSaltLen
+	if len(ciphertext) < headerLen || !bytes.HasPrefix(ciphertext, []byte(localMagic)) {
+		err := fmt.Errorf("input is not a passphrase encrypted ciphertext")
+		return nil, err
+	}
+
+	header := ciphertext[:headerLen]
+	logN, r, p := header[len(localMagic)], header[len(localMagic)+1], header[len(localMagic)+2]
+	if logN > localMaxLogN || r == 0 || p == 0 {
+		err := fmt.Errorf("invalid key derivation parameters in ciphertext header")
+		return nil, err
+	}
+
+	// header is untrusted, so bound memory and time spent deriving the key
+	if 128*uint64(r)<<logN > localMaxMem || int(r)*int(p) > localMaxRP {
+		err := fmt.Errorf("key derivation parameters in ciphertext header exceed limits")
+		return nil, err
+	}
+	salt := header[len(localMagic)+3:]
+
+	if err := l.getPassphrase(false); err != nil {
+		return nil, err
+	}
+
+	aead, err := l.newAead(salt, logN, r, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < headerLen+aead.NonceSize() {
+		err := fmt.Errorf("ciphertext too short")
+		return nil, err
+	}
+
+	nonce := ciphertext[headerLen : headerLen+aead.NonceSize()]
+	additionalData := append(append([]byte{}, header...), aad...)
+	plaintext, err := aead.Open(nil, nonce, ciphertext[headerLen+aead.NonceSize():], additionalData)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt, possibly incorrect passphrase: %w", err)
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+func (l *localPassphrase) Type() string {
+	return Local
+}
+
+func (l *localPassphrase) Describe() string {
+	return fmt.Sprintf("%s:scrypt-aes256gcm", Local)
+}
+
+func (l *localPassphrase) Close() error {
+	for i := range l.passphrase {
+		l.passphrase[i] = 0
+	}
+	l.passphrase = nil
+	return nil
+}
+
+// getPassphrase fetches passphrase unless it is already known
+func (l *localPassphrase) getPassphrase(confirm bool) error {
+	if l.passphrase != nil {
+		return nil
+	}
+
+	passphrase, err := l.passphraseFunc(confirm)
+	if err != nil {
+		err := fmt.Errorf("could not read passphrase: %w", err)
+		return err
+	}
+
+	if len(passphrase) == 0 {
+		err := fmt.Errorf("passphrase cannot be empty")
+		return err
+	}
+
+	l.passphrase = passphrase
+	return nil
+}
+
+// newAead derives key from passphrase and returns AES-GCM AEAD
+func (l *localPassphrase) newAead(salt []byte, logN, r, p byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(l.passphrase, salt, 1<<logN, int(r), int(p), localKeyLen)
+	if err != nil {
+		err := fmt.Errorf("could not derive key from passphrase: %w", err)
+		return nil, err
+	}
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		err := fmt.Errorf("could not create cipher: %w", err)
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
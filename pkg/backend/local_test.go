@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func staticPassphrase(passphrase string) PassphraseFunc {
+	return func(bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+}
+
+func TestLocalPassphraseRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keyEncrypter, err := NewLocalPassphrase(staticPassphrase("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("this is a seed")
+	ciphertext, err := keyEncrypter.Encrypt(ctx, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(ciphertext.Data, plaintext) {
+		t.Fatal("ciphertext contains plaintext")
+	}
+
+	decrypted, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Fatal("decrypted data does not match plaintext")
+	}
+
+	wrongKeyEncrypter, err := NewLocalPassphrase(staticPassphrase("battery staple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrongKeyEncrypter.Decrypt(ctx, ciphertext.Data, nil); err == nil {
+		t.Fatal("expected error decrypting with wrong passphrase")
+	}
+
+	if _, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, []byte("aad")); err == nil {
+		t.Fatal("expected error decrypting with mismatched additional data")
+	}
+
+	ciphertext.Data[len(localMagic)+3] ^= 0xff // tamper with salt
+	if _, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, nil); err == nil {
+		t.Fatal("expected error decrypting tampered ciphertext")
+	}
+}
+
+func TestLocalPassphraseRejectsExpensiveParameters(t *testing.T) {
+	ctx := context.Background()
+	keyEncrypter, err := NewLocalPassphrase(func(bool) ([]byte, error) {
+		t.Fatal("expected header to be rejected before reading passphrase")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range [][3]byte{
+		{localLogN, 255, 1},
+		{localLogN, localR, 255},
+		{localMaxLogN, localR, localP},
+	} {
+		ciphertext := append([]byte(localMagic), params[:]...)
+		ciphertext = append(ciphertext, make([]byte, localSaltLen+64)...)
+		if _, err := keyEncrypter.Decrypt(ctx, ciphertext, nil); err == nil {
+			t.Fatalf("expected key derivation parameters %v to be rejected", params)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxVaultErrorBody limits how much of an error response is included in errors
+const maxVaultErrorBody = 1024
+
+// vaultTransit implements KeyEncrypter using HashiCorp Vault Transit secrets engine
+type vaultTransit struct {
+	client  *http.Client
+	address string
+	token   string
+	mount   string
+	keyName string
+}
+
+// vaultResponse is a generic Vault API response
+type vaultResponse struct {
+	Data struct {
+		Ciphertext string `json:"ciphertext,omitempty"`
+		Plaintext  string `json:"plaintext,omitempty"`
+	} `json:"data"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// NewVaultTransit creates a Vault Transit backed KeyEncrypter for the transit
+// key keyName mounted at mount on the Vault server at address.
+func NewVaultTransit(address, token, mount, keyName string) (KeyEncrypter, error) {
+	if len(address) == 0 {
+		err := fmt.Errorf("vault address is required")
+		return nil, err
+	}
+
+	if len(keyName) == 0 {
+		err := fmt.Errorf("vault transit key name is required")
+		return nil, err
+	}
+
+	if len(mount) == 0 {
+		mount = "transit"
+	}
+
+	return &vaultTransit{
+		client:  &http.Client{},
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		keyName: keyName,
+	}, nil
+}
+
+func (v *vaultTransit) Encrypt(ctx context.Context, plaintext, aad []byte) (*Ciphertext, error) {
+	body := map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}
+	if len(aad) > 0 {
+		body["associated_data"] = base64.StdEncoding.EncodeToString(aad)
+	}
+
+	response, err := v.do(ctx, "encrypt", body)
+	if err != nil {
+		err := fmt.Errorf("vault encrypt request failed: %w", err)
+		return nil, err
+	}
+
+	if len(response.Data.Ciphertext) == 0 {
+		err := fmt.Errorf("vault encrypt response did not contain ciphertext")
+		return nil, err
+	}
+
+	// transit ciphertext is of the form vault:v<version>:<base64 data>
+	keyVersion := fmt.Sprintf("%s/keys/%s", v.mount, v.keyName)
+	if parts := strings.SplitN(response.Data.Ciphertext, ":", 3); len(parts) == 3 {
+		keyVersion = fmt.Sprintf("%s:%s", keyVersion, parts[1])
+	}
+
+	return &Ciphertext{
+		Data:       []byte(response.Data.Ciphertext),
+		Backend:    Vault,
+		KeyVersion: keyVersion,
+	}, nil
+}
+
+func (v *vaultTransit) Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte("vault:")) {
+		err := fmt.Errorf("input is not a vault transit ciphertext")
+		return nil, err
+	}
+
+	body := map[string]string{
+		"ciphertext": string(ciphertext),
+	}
+	if len(aad) > 0 {
+		body["associated_data"] = base64.StdEncoding.EncodeToString(aad)
+	}
+
+	response, err := v.do(ctx, "decrypt", body)
+	if err != nil {
+		err := fmt.Errorf("vault decrypt request failed: %w", err)
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(response.Data.Plaintext)
+	if err != nil {
+		err := fmt.Errorf("could not decode vault plaintext: %w", err)
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+func (v *vaultTransit) Type() string {
+	return Vault
+}
+
+func (v *vaultTransit) Describe() string {
+	return fmt.Sprintf("%s:%s/v1/%s/keys/%s", Vault, v.address, v.mount, v.keyName)
+}
+
+func (v *vaultTransit) Close() error {
+	v.client.CloseIdleConnections()
+	return nil
+}
+
+// do sends a transit request for given operation
+func (v *vaultTransit) do(ctx context.Context, operation string, body interface{}) (*vaultResponse, error) {
+	jb, err := json.Marshal(body)
+	if err != nil {
+		err := fmt.Errorf("could not serialize request: %w", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/v1/%s/%s/%s", v.address, v.mount, operation, v.keyName),
+		bytes.NewReader(jb),
+	)
+	if err != nil {
+		err := fmt.Errorf("could not create request: %w", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(v.token) > 0 {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// error responses may come from a proxy in front of Vault rather than
+	// Vault itself, so they are not assumed to be JSON
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxVaultErrorBody))
+
+		message := strings.TrimSpace(string(body))
+		errResponse := &vaultResponse{}
+		if err := json.Unmarshal(body, errResponse); err == nil && len(errResponse.Errors) > 0 {
+			message = strings.Join(errResponse.Errors, "; ")
+		}
+
+		if len(message) == 0 {
+			err := fmt.Errorf("status %s", resp.Status)
+			return nil, err
+		}
+
+		err := fmt.Errorf("status %s: %s", resp.Status, message)
+		return nil, err
+	}
+
+	response := &vaultResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		err := fmt.Errorf("could not decode response with status %s: %w", resp.Status, err)
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newVaultTransitStandIn starts a minimal stand-in for Vault Transit API that
+// "encrypts" by base64 encoding plaintext under a vault:v1: prefix.
+func newVaultTransitStandIn(t *testing.T, token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/solana":
+			_, _ = w.Write([]byte(`{"data":{"ciphertext":"vault:v1:` + req["plaintext"] + `"}}`))
+		case "/v1/transit/decrypt/solana":
+			_, _ = w.Write([]byte(`{"data":{"plaintext":"` + strings.TrimPrefix(req["ciphertext"], "vault:v1:") + `"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":["no handler for route"]}`))
+		}
+	}))
+}
+
+func TestVaultTransitRoundTrip(t *testing.T) {
+	server := newVaultTransitStandIn(t, "s.token")
+	defer server.Close()
+
+	ctx := context.Background()
+	keyEncrypter, err := NewVaultTransit(server.URL, "s.token", "", "solana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keyEncrypter.Close()
+
+	plaintext := []byte("this is a seed")
+	ciphertext, err := keyEncrypter.Encrypt(ctx, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(ciphertext.Data) != "vault:v1:"+base64.StdEncoding.EncodeToString(plaintext) {
+		t.Fatalf("unexpected ciphertext: %s", ciphertext.Data)
+	}
+
+	decrypted, err := keyEncrypter.Decrypt(ctx, ciphertext.Data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Fatal("decrypted data does not match plaintext")
+	}
+}
+
+func TestVaultTransitPermissionDenied(t *testing.T) {
+	server := newVaultTransitStandIn(t, "s.token")
+	defer server.Close()
+
+	keyEncrypter, err := NewVaultTransit(server.URL, "s.wrong", "transit", "solana")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = keyEncrypter.Encrypt(context.Background(), []byte("data"), nil)
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission denied error, got %v", err)
+	}
+}
+
+func TestVaultTransitNonJsonError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+	}))
+	defer server.Close()
+
+	keyEncrypter, err := NewVaultTransit(server.URL, "s.token", "transit", "solana")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = keyEncrypter.Encrypt(context.Background(), []byte("data"), nil)
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "Bad Gateway</body>") {
+		t.Fatalf("expected status and body of proxy error, got %v", err)
+	}
+}
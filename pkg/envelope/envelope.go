@@ -0,0 +1,164 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+const (
+	// Magic identifies key files written in envelope format
+	Magic = "SOLKMS"
+	// Version is the current envelope format version
+	Version = 1
+	// AadVersion is the current format version of additional authenticated data
+	AadVersion = 1
+)
+
+const (
+	RoleKeypair = "keypair" // file holds private keypair
+	RoleSeed    = "seed"    // file holds seed of private keypair
+	RoleShare   = "share"   // file holds Shamir share of seed
+	// RoleSigningKey marks files that reference a signing key held by KMS
+	// and therefore carry no ciphertext
+	RoleSigningKey = "signing-key"
+)
+
+// ErrNotEnvelope is returned when input does not start with envelope magic
+// header, which is the case for legacy files holding raw ciphertext
+var ErrNotEnvelope = errors.New("input is not in envelope format")
+
+// Header is self describing metadata stored in plaintext in front of the ciphertext
+type Header struct {
+	Backend    string    `json:"backend,omitempty"`
+	KeyVersion string    `json:"keyVersion,omitempty"`
+	Role       string    `json:"role,omitempty"`
+	PublicKey  string    `json:"publicKey,omitempty"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	// AadVersion is the format version of additional authenticated data bound
+	// to the ciphertext. Zero value implies ciphertext was produced without it.
+	AadVersion int `json:"aadVersion,omitempty"`
+}
+
+// AdditionalData returns additional authenticated data binding ciphertext to
+// the file role and the public key
+func AdditionalData(role, publicKey string) []byte {
+	return []byte(fmt.Sprintf("solana-kms:aad:v%d:%s:%s", AadVersion, role, publicKey))
+}
+
+// AdditionalData returns additional authenticated data that was bound to the
+// ciphertext in the envelope or nil if none was used. Role is the role the
+// caller expects the file to have, which prevents a seed ciphertext from being
+// accepted in place of a keypair ciphertext and vice versa.
+func (h Header) AdditionalData(role string) ([]byte, error) {
+	switch h.AadVersion {
+	case 0:
+		return nil, nil
+	case AadVersion:
+		return AdditionalData(role, h.PublicKey), nil
+	default:
+		err := fmt.Errorf("unsupported additional authenticated data version %d", h.AadVersion)
+		return nil, err
+	}
+}
+
+// Envelope is a versioned container for ciphertext of key material.
+//
+// Serialized layout is as follows, with all integers in big endian:
+//
+//	magic (6 bytes) | version (1 byte) | header length (4 bytes) | JSON header |
+//	ciphertext | CRC32C of all preceding bytes (4 bytes)
+type Envelope struct {
+	Version    uint8
+	Header     Header
+	Ciphertext []byte
+}
+
+// New creates a new envelope at current format version
+func New(header Header, ciphertext []byte) *Envelope {
+	return &Envelope{
+		Version:    Version,
+		Header:     header,
+		Ciphertext: ciphertext,
+	}
+}
+
+// IsEnvelope reports whether data starts with envelope magic header
+func IsEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Marshal serializes envelope
+func (e *Envelope) Marshal() ([]byte, error) {
+	header, err := json.Marshal(e.Header)
+	if err != nil {
+		err := fmt.Errorf("could not serialize envelope header: %w", err)
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(Magic)
+	buf.WriteByte(e.Version)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(header)))
+	buf.Write(header)
+	buf.Write(e.Ciphertext)
+	_ = binary.Write(buf, binary.BigEndian, crc32Sum(buf.Bytes()))
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses envelope from data. ErrNotEnvelope is returned if data
+// does not carry the envelope magic header.
+func Unmarshal(data []byte) (*Envelope, error) {
+	if !IsEnvelope(data) {
+		return nil, ErrNotEnvelope
+	}
+
+	minLen := len(Magic) + 1 + 4 + 4
+	if len(data) < minLen {
+		err := fmt.Errorf("envelope is truncated")
+		return nil, err
+	}
+
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32Sum(body) != sum {
+		err := fmt.Errorf("envelope checksum mismatch, file is corrupt")
+		return nil, err
+	}
+
+	e := &Envelope{Version: body[len(Magic)]}
+	if e.Version == 0 || e.Version > Version {
+		err := fmt.Errorf("unsupported envelope version %d", e.Version)
+		return nil, err
+	}
+
+	headerLen := binary.BigEndian.Uint32(body[len(Magic)+1:])
+	rest := body[len(Magic)+1+4:]
+	if uint64(headerLen) > uint64(len(rest)) {
+		err := fmt.Errorf("envelope header length exceeds data")
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rest[:headerLen], &e.Header); err != nil {
+		err := fmt.Errorf("could not parse envelope header: %w", err)
+		return nil, err
+	}
+
+	e.Ciphertext = rest[headerLen:]
+	if len(e.Ciphertext) == 0 && e.Header.Role != RoleSigningKey {
+		err := fmt.Errorf("envelope does not contain ciphertext")
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// crc32Sum produces crc32 sum
+func crc32Sum(data []byte) uint32 {
+	t := crc32.MakeTable(crc32.Castagnoli)
+	return crc32.Checksum(data, t)
+}
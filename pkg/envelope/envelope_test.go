@@ -0,0 +1,64 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	header := Header{
+		Backend:    "gcp",
+		KeyVersion: "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+		PublicKey:  "5YcDXyNdRuKVZ8aoPjy2uCGyHPJUKfxmWHXM4JneuCsc",
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+
+	data, err := New(header, []byte("ciphertext")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Version != Version || e.Header != header || !bytes.Equal(e.Ciphertext, []byte("ciphertext")) {
+		t.Fatalf("unexpected envelope: %+v", e)
+	}
+
+	data[len(data)-5] ^= 0xff
+	if _, err := Unmarshal(data); err == nil {
+		t.Fatal("expected checksum error for corrupt envelope")
+	}
+}
+
+func TestEnvelopeLegacy(t *testing.T) {
+	if _, err := Unmarshal([]byte("raw kms ciphertext")); !errors.Is(err, ErrNotEnvelope) {
+		t.Fatalf("expected ErrNotEnvelope, got %v", err)
+	}
+}
+
+func TestEnvelopeSigningKey(t *testing.T) {
+	header := Header{Backend: "gcp", Role: RoleSigningKey}
+	data, err := New(header, nil).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	header.Role = RoleKeypair
+	data, err = New(header, nil).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Unmarshal(data); err == nil {
+		t.Fatal("expected error for keypair envelope without ciphertext")
+	}
+}
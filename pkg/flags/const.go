@@ -11,4 +11,73 @@ const (
 	SeedFile                     = "seedfile"                       // Seedfile associated with private keypair
 	PubKey                       = "pubkey"                         // Public key aka Solana address
 	Url                          = "url"                            // Solana validator endpoint
+	Backend                      = "backend"                        // Key encryption backend
+	Info                         = "info"                           // Display key file metadata
+	Mnemonic                     = "mnemonic"                       // Generate key from a new BIP39 mnemonic
+	MnemonicWords                = "mnemonic-words"                 // Number of words in BIP39 mnemonic
+	MnemonicPassphrase           = "mnemonic-passphrase"            // Prompt for BIP39 passphrase
+	DerivationPath               = "derivation-path"                // BIP44 derivation path
+	Index                        = "index"                          // Account index in derivation path
+	Count                        = "count"                          // Number of items to process
+	OutDir                       = "outdir"                         // Output directory
+	Input                        = "input"                          // Input file
+	Shred                        = "shred"                          // Overwrite and remove plaintext input
+	TargetKey                    = "target-key"                     // Target KMS key name
+	DryRun                       = "dry-run"                        // Report actions without performing them
+	InDir                        = "indir"                          // Input directory
+	DestBackend                  = "dest-backend"                   // Destination key encryption backend
+	DestGoogleProjectID          = "dest-google-project-id"         // Destination Google KMS project ID
+	DestKmsLocation              = "dest-kms-location"              // Destination KMS location
+	DestKmsKeyring               = "dest-kms-keyring"               // Destination KMS keyring name
+	DestKmsKey                   = "dest-kms-key"                   // Destination KMS key name
+	DestGoogleAppCredentials     = "dest-application-credentials"   // Destination Google service account
+	DestAwsKmsKeyArn             = "dest-aws-kms-key-arn"           // Destination AWS KMS key ARN
+	DestAwsRegion                = "dest-aws-region"                // Destination AWS region
+	DestAwsProfile               = "dest-aws-profile"               // Destination AWS shared config profile
+	DestAwsKmsEndpoint           = "dest-aws-kms-endpoint"          // Destination AWS KMS endpoint override
+	DestVaultAddr                = "dest-vault-addr"                // Destination Vault server address
+	DestVaultToken               = "dest-vault-token"               // Destination Vault token
+	DestVaultTransitMount        = "dest-vault-transit-mount"       // Destination Vault transit mount path
+	DestVaultTransitKey          = "dest-vault-transit-key"         // Destination Vault transit key name
+	Prefix                       = "prefix"                         // Vanity address prefix
+	Suffix                       = "suffix"                         // Vanity address suffix
+	IgnoreCase                   = "ignore-case"                    // Case insensitive matching
+	Threads                      = "threads"                        // Number of worker threads
+	KeyName                      = "key-name"                       // Name of key in keystore
+	Keystore                     = "keystore"                       // Keystore directory
+	Label                        = "label"                          // Key label as key=value
+	Shares                       = "shares"                         // Number of Shamir shares
+	Threshold                    = "threshold"                      // Number of shares required to combine
+	Share                        = "share"                          // Shamir share file
+	ShareKeys                    = "share-keys"                     // KMS key names to encrypt shares with
+	Force                        = "force"                          // Overwrite existing files
+	Backup                       = "backup"                         // Keep backup of overwritten files
+	Socket                       = "socket"                         // Agent Unix socket path
+	Ttl                          = "ttl"                            // Idle time after which key is wiped
+	Transaction                  = "transaction"                    // Input is a transaction message
+	KmsNative                    = "kms-native"                     // Use KMS native signing key
+	Offchain                     = "offchain"                       // Sign as Solana off-chain message
+	Signature                    = "signature"                      // Signature to verify
+	Encoding                     = "encoding"                       // Signature encoding
+	FeePayer                     = "fee-payer"                      // Fee payer keypair file
+	AllowUnfundedRecipient       = "allow-unfunded-recipient"       // Allow transfer to unfunded account
+	Memo                         = "memo"                           // Memo to attach to transaction
+	Decimals                     = "decimals"                       // Number of token decimal places
+	EnableFreeze                 = "enable-freeze"                  // Set key as token freeze authority
+	Owner                        = "owner"                          // Owner of token account
+	FundRecipient                = "fund-recipient"                 // Create recipient token account
+	Output                       = "output"                         // Output format
+	Seed                         = "seed"                           // Seed to derive account address from key
+	StakeAuthority               = "stake-authority"                // Stake authority keypair file or address
+	WithdrawAuthority            = "withdraw-authority"             // Withdraw authority keypair file or address
+	NewStakeAuthority            = "new-stake-authority"            // New stake authority address or keypair file
+	NewWithdrawAuthority         = "new-withdraw-authority"         // New withdraw authority address or keypair file
+	AwsKmsKeyArn                 = "aws-kms-key-arn"                // AWS KMS key ARN
+	AwsRegion                    = "aws-region"                     // AWS region of the KMS key
+	AwsProfile                   = "aws-profile"                    // AWS shared config profile
+	AwsKmsEndpoint               = "aws-kms-endpoint"               // AWS KMS endpoint override
+	VaultAddr                    = "vault-addr"                     // Vault server address
+	VaultToken                   = "vault-token"                    // Vault token
+	VaultTransitMount            = "vault-transit-mount"            // Vault transit secrets engine mount path
+	VaultTransitKey              = "vault-transit-key"              // Vault transit key name
 )
@@ -0,0 +1,144 @@
+// Package offchain implements serialization of Solana off-chain messages, which
+// prefix the message with a signing domain that can never start a valid
+// transaction, so that a signature over one cannot be replayed on chain.
+//
+// Version 0 layout matches that of solana sign-offchain-message:
+//
+//	signing domain "\xffsolana offchain" (16 bytes)
+//	header version (1 byte)
+//	message format (1 byte)
+//	message length (2 bytes, little endian)
+//	message
+package offchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// SigningDomain prefixes every serialized off-chain message
+const SigningDomain = "\xffsolana offchain"
+
+// Version is the only supported header version
+const Version = 0
+
+// Format describes allowed character set and length of the message
+type Format uint8
+
+const (
+	RestrictedAscii Format = iota // printable ASCII, fits a ledger packet
+	LimitedUtf8                   // UTF-8, fits a ledger packet
+	ExtendedUtf8                  // UTF-8, up to MaxLen bytes
+)
+
+const (
+	headerLen = 4 // version, format and length
+
+	// MaxLenLedger is max message length that hardware wallets can display
+	// within a single packet
+	MaxLenLedger = 1232 - len(SigningDomain) - headerLen
+
+	// MaxLen is max message length allowed by the two byte length field
+	MaxLen = 0xffff - len(SigningDomain) - headerLen
+)
+
+func (f Format) String() string {
+	switch f {
+	case RestrictedAscii:
+		return "restricted-ascii"
+	case LimitedUtf8:
+		return "limited-utf8"
+	case ExtendedUtf8:
+		return "extended-utf8"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(f))
+	}
+}
+
+// FormatOf returns the most restrictive format that message fits
+func FormatOf(message []byte) (Format, error) {
+	switch {
+	case len(message) == 0:
+		return 0, errors.New("message is empty")
+	case len(message) <= MaxLenLedger && isPrintableAscii(message):
+		return RestrictedAscii, nil
+	case len(message) <= MaxLenLedger && utf8.Valid(message):
+		return LimitedUtf8, nil
+	case len(message) <= MaxLen && utf8.Valid(message):
+		return ExtendedUtf8, nil
+	case !utf8.Valid(message):
+		return 0, errors.New("message is not valid UTF-8")
+	default:
+		return 0, fmt.Errorf("message is longer than %d bytes", MaxLen)
+	}
+}
+
+// Encode serializes message as version 0 off-chain message, which is what
+// gets signed
+func Encode(message []byte) ([]byte, error) {
+	format, err := FormatOf(message)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(SigningDomain)+headerLen+len(message))
+	data = append(data, SigningDomain...)
+	data = append(data, Version, byte(format), 0, 0)
+	binary.LittleEndian.PutUint16(data[len(data)-2:], uint16(len(message)))
+	data = append(data, message...)
+
+	return data, nil
+}
+
+// Decode parses serialized off-chain message and returns its format and message
+func Decode(data []byte) (Format, []byte, error) {
+	if !IsOffchainMessage(data) {
+		return 0, nil, errors.New("missing off-chain message signing domain")
+	}
+	data = data[len(SigningDomain):]
+
+	if len(data) < headerLen {
+		return 0, nil, errors.New("off-chain message header is truncated")
+	}
+
+	if data[0] != Version {
+		return 0, nil, fmt.Errorf("unsupported off-chain message version %d", data[0])
+	}
+
+	format := Format(data[1])
+	message := data[headerLen:]
+	if n := int(binary.LittleEndian.Uint16(data[2:headerLen])); n != len(message) {
+		return 0, nil, fmt.Errorf("off-chain message length %d does not match header length %d", len(message), n)
+	}
+
+	expected, err := FormatOf(message)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// a more permissive format than necessary is allowed
+	if format > ExtendedUtf8 || format < expected {
+		return 0, nil, fmt.Errorf("off-chain message does not fit %s format", format)
+	}
+
+	return format, message, nil
+}
+
+// IsOffchainMessage reports whether data starts with off-chain signing domain
+func IsOffchainMessage(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(SigningDomain))
+}
+
+// isPrintableAscii reports whether message consists of printable ASCII only
+func isPrintableAscii(message []byte) bool {
+	for _, c := range message {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
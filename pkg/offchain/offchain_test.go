@@ -0,0 +1,91 @@
+package offchain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	data, err := Encode([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := append([]byte("\xffsolana offchain"), 0, 0, 5, 0, 'h', 'e', 'l', 'l', 'o')
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("expected %x, got %x", expected, data)
+	}
+
+	format, message, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != RestrictedAscii || string(message) != "hello" {
+		t.Fatalf("unexpected decoded message %s %q", format, message)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		message string
+		format  Format
+		valid   bool
+	}{
+		{message: "hello", format: RestrictedAscii, valid: true},
+		{message: "hello\n", format: LimitedUtf8, valid: true},
+		{message: "héllo", format: LimitedUtf8, valid: true},
+		{message: strings.Repeat("a", MaxLenLedger), format: RestrictedAscii, valid: true},
+		{message: strings.Repeat("a", MaxLenLedger+1), format: ExtendedUtf8, valid: true},
+		{message: strings.Repeat("a", MaxLen+1)},
+		{message: "\xff\xfe"},
+		{message: ""},
+	}
+
+	for _, test := range tests {
+		format, err := FormatOf([]byte(test.message))
+		if !test.valid {
+			if err == nil {
+				t.Fatalf("expected message of length %d to be invalid", len(test.message))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != test.format {
+			t.Fatalf("expected format %s for message of length %d, got %s", test.format, len(test.message), format)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid, err := Encode([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsupportedVersion := append([]byte{}, valid...)
+	unsupportedVersion[len(SigningDomain)] = 1
+
+	wrongLength := append([]byte{}, valid...)
+	wrongLength[len(SigningDomain)+2] = 6
+
+	restrictedUtf8, err := Encode([]byte("héllo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restrictedUtf8[len(SigningDomain)+1] = byte(RestrictedAscii)
+
+	for _, data := range [][]byte{
+		[]byte("hello"),
+		valid[:len(SigningDomain)+2],
+		unsupportedVersion,
+		wrongLength,
+		restrictedUtf8,
+	} {
+		if _, _, err := Decode(data); err == nil {
+			t.Fatalf("expected decoding %x to fail", data)
+		}
+	}
+}
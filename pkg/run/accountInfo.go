@@ -3,17 +3,12 @@ package run
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 
-	kms "cloud.google.com/go/kms/apiv1"
 	"github.com/kubetrail/solana-kms/pkg/flags"
 	"github.com/portto/solana-go-sdk/client"
-	"github.com/portto/solana-go-sdk/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
-	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // AccountInfo retrieves account info
@@ -29,6 +24,14 @@ func AccountInfo(cmd *cobra.Command, _ []string) error {
 	pubKey := viper.GetString(flags.PubKey)
 	url := viper.GetString(flags.Url)
 
+	if len(pubKey) == 0 && len(persistentFlags.KeyName) > 0 {
+		var err error
+		keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+	}
+
 	var endpoint string
 	var configValues *config
 
@@ -52,11 +55,6 @@ func AccountInfo(cmd *cobra.Command, _ []string) error {
 	endpoint = getEndpointFromUrlOrMoniker(url, configValues)
 
 	if len(pubKey) == 0 {
-		if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
-			err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
-			return err
-		}
-
 		if len(keyFile) == 0 {
 			if configValues == nil || len(configValues.KeypairPath) == 0 {
 				err := fmt.Errorf("could not find a valid keypair path from config file")
@@ -67,53 +65,11 @@ func AccountInfo(cmd *cobra.Command, _ []string) error {
 
 		keyFile = removeSchemeFromPath(keyFile)
 
-		kmsClient, err := kms.NewKeyManagementClient(ctx)
-		if err != nil {
-			err := fmt.Errorf("failed to create kms client: %w", err)
-			return err
-		}
-		defer kmsClient.Close()
-
-		ciphertext, err := os.ReadFile(keyFile)
-		if err != nil {
-			err := fmt.Errorf("error reading input keypair file: %w", err)
-			return err
-		}
-
-		var key []byte
-		// try json parsing first and if it fails assume input to be
-		// encrypted
-		if err := json.Unmarshal(ciphertext, &key); err != nil {
-			decryptResponse, err := kmsClient.Decrypt(
-				ctx,
-				&kms2.DecryptRequest{
-					Name: getKmsName(
-						persistentFlags.Project,
-						persistentFlags.Location,
-						persistentFlags.Keyring,
-						persistentFlags.Key,
-					),
-					Ciphertext:                        ciphertext,
-					AdditionalAuthenticatedData:       nil,
-					CiphertextCrc32C:                  wrapperspb.Int64(int64(crc32Sum(ciphertext))),
-					AdditionalAuthenticatedDataCrc32C: nil,
-				},
-			)
-			if err != nil {
-				err := fmt.Errorf("could not decrypt private key: %w", err)
-				return err
-			}
-
-			key = decryptResponse.Plaintext
-		}
-
-		account, err := types.AccountFromBytes(key)
+		var err error
+		pubKey, err = readPublicKey(ctx, persistentFlags, keyFile)
 		if err != nil {
-			err := fmt.Errorf("could not create account from data: %w", err)
 			return err
 		}
-
-		pubKey = account.PublicKey.ToBase58()
 	}
 
 	// create a RPC client
@@ -0,0 +1,196 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/mr-tron/base58"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AccountTransfer transfers SOL from the account of the key to recipient. Amount
+// is in SOL or ALL for entire balance. Transaction is sent to the validator and
+// confirmed at commitment level of Solana config.
+func AccountTransfer(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.AllowUnfundedRecipient, cmd.Flags().Lookup(filepath.Base(flags.AllowUnfundedRecipient)))
+	_ = viper.BindPFlag(flags.Memo, cmd.Flags().Lookup(filepath.Base(flags.Memo)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	allowUnfundedRecipient := viper.GetBool(flags.AllowUnfundedRecipient)
+	memo := viper.GetString(flags.Memo)
+
+	if len(args) != 2 {
+		err := fmt.Errorf("expected recipient and amount as arguments")
+		return err
+	}
+
+	recipient, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	var lamports uint64
+	all := args[1] == amountAll
+	if !all {
+		lamports, err = parseAmount(args[1], solDecimals)
+		if err != nil {
+			return err
+		}
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	from := session.key.PublicKey()
+	feePayer := session.feePayer.PublicKey()
+
+	if recipient == from {
+		err := fmt.Errorf("recipient %s is the sender", recipient.ToBase58())
+		return err
+	}
+
+	if !allowUnfundedRecipient {
+		balance, err := session.getBalance(ctx, recipient)
+		if err != nil {
+			return err
+		}
+
+		if balance == 0 {
+			err := fmt.Errorf(
+				"recipient %s is not funded, use --%s to transfer anyway",
+				recipient.ToBase58(),
+				flags.AllowUnfundedRecipient,
+			)
+			return err
+		}
+	}
+
+	blockhash, lastValidBlockHeight, err := getLatestBlockhash(ctx, session.client, session.commitment)
+	if err != nil {
+		return err
+	}
+
+	newTransferMessage := func(lamports uint64) types.Message {
+		instructions := []types.Instruction{
+			sysprog.Transfer(sysprog.TransferParam{From: from, To: recipient, Amount: lamports}),
+		}
+
+		if len(memo) > 0 {
+			instructions = append(instructions, types.Instruction{
+				ProgramID: transaction.MemoProgramID,
+				Accounts:  []types.AccountMeta{{PubKey: from, IsSigner: true}},
+				Data:      []byte(memo),
+			})
+		}
+
+		return types.NewMessage(types.NewMessageParam{
+			FeePayer:        feePayer,
+			Instructions:    instructions,
+			RecentBlockhash: blockhash,
+		})
+	}
+
+	// fee does not depend on amount, so it is computed for the message as is
+	fee, err := getFeeForMessage(ctx, session.client, newTransferMessage(lamports), session.commitment)
+	if err != nil {
+		return err
+	}
+
+	balance, err := session.getBalance(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	var senderFee uint64
+	if feePayer == from {
+		senderFee = fee
+	}
+
+	if all {
+		if balance <= senderFee {
+			err := fmt.Errorf(
+				"balance of %s SOL does not cover fee of %s SOL",
+				formatAmount(balance, solDecimals),
+				formatAmount(senderFee, solDecimals),
+			)
+			return err
+		}
+		lamports = balance - senderFee
+	} else {
+		required, err := checkedAdd(lamports, senderFee)
+		if err != nil {
+			return err
+		}
+
+		if balance < required {
+			err := fmt.Errorf(
+				"insufficient balance of %s SOL, %s SOL required including fee",
+				formatAmount(balance, solDecimals),
+				formatAmount(required, solDecimals),
+			)
+			return err
+		}
+	}
+
+	if feePayer != from {
+		feePayerBalance, err := session.getBalance(ctx, feePayer)
+		if err != nil {
+			return err
+		}
+
+		if feePayerBalance < fee {
+			err := fmt.Errorf(
+				"fee payer balance of %s SOL does not cover fee of %s SOL",
+				formatAmount(feePayerBalance, solDecimals),
+				formatAmount(fee, solDecimals),
+			)
+			return err
+		}
+	}
+
+	tx, err := signMessage(ctx, newTransferMessage(lamports), session.key, session.feePayer)
+	if err != nil {
+		return err
+	}
+
+	signature, err := sendAndConfirm(ctx, session.client, tx, lastValidBlockHeight, session.commitment)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// parsePublicKey decodes base58 address, which unlike common.PublicKeyFromString
+// rejects input that is not a valid public key
+func parsePublicKey(address string) (common.PublicKey, error) {
+	b, err := base58.Decode(address)
+	if err != nil || len(b) != common.PublicKeyLength {
+		err := fmt.Errorf("invalid address %s", address)
+		return common.PublicKey{}, err
+	}
+
+	return common.PublicKeyFromBytes(b), nil
+}
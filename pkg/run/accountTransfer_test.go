@@ -0,0 +1,169 @@
+package run
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/mr-tron/base58"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func accountTransferFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.Url, "", "")
+	f.String(flags.FeePayer, "", "")
+	f.Bool(flags.AllowUnfundedRecipient, false, "")
+	f.String(flags.Memo, "", "")
+}
+
+// transferredLamports returns lamports moved by transfer instruction of tx
+func transferredLamports(t *testing.T, tx *transaction.Transaction) uint64 {
+	t.Helper()
+
+	info := tx.Message.DescribeInstructions()[0]
+	if info.Type != "transfer" {
+		t.Fatalf("expected transfer instruction, got %+v", info)
+	}
+
+	return info.Parsed["lamports"].(uint64)
+}
+
+func TestAccountTransfer(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, from := newAgentKeyFile(t)
+	recipient := types.NewAccount().PublicKey.ToBase58()
+
+	rpc := newFakeRpc(t)
+	rpc.PendingPolls = 2
+	rpc.Balances[from] = 2000000000
+	rpc.Balances[recipient] = 1
+	config := newRpcConfig(t, rpc.URL, "finalized")
+
+	out, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, "--memo", "invoice 42", recipient, "1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rpc.Sent) != 1 {
+		t.Fatalf("expected one transaction to be sent, got %d", len(rpc.Sent))
+	}
+	tx := rpc.Sent[0]
+	if signature := strings.TrimSpace(out); signature != base58.Encode(tx.Signatures[0]) {
+		t.Fatalf("expected signature of sent transaction to be printed, got %s", signature)
+	}
+	if fromKey := tx.Message.AccountKeys[0].ToBase58(); fromKey != from {
+		t.Fatalf("expected sender %s to pay fee, got %s", from, fromKey)
+	}
+	if lamports := transferredLamports(t, tx); lamports != 1500000000 {
+		t.Fatalf("expected 1.5 SOL to be transferred, got %d lamports", lamports)
+	}
+	if memo := tx.Message.DescribeInstructions()[1]; memo.Type != "memo" || memo.Parsed["memo"] != "invoice 42" {
+		t.Fatalf("expected memo instruction, got %+v", memo)
+	}
+
+	// ALL leaves nothing but the fee
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, recipient, "ALL"); err != nil {
+		t.Fatal(err)
+	}
+	if lamports := transferredLamports(t, rpc.Sent[1]); lamports != 2000000000-rpc.Fee {
+		t.Fatalf("expected balance less fee to be transferred, got %d lamports", lamports)
+	}
+
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, recipient, "2"); err == nil ||
+		!strings.Contains(err.Error(), "insufficient balance") {
+		t.Fatalf("expected transfer not covering fee to fail, got %v", err)
+	}
+}
+
+func TestAccountTransferFeePayer(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, from := newAgentKeyFile(t)
+	feePayerFile, feePayer := newAgentKeyFile(t)
+	recipient := types.NewAccount().PublicKey.ToBase58()
+
+	// relative path starting with letters of stdin: scheme is used as is
+	feePayerFile = renameKeyFile(t, feePayerFile, "signer.json")
+	chdir(t, filepath.Dir(feePayerFile))
+	feePayerFile = "signer.json"
+
+	rpc := newFakeRpc(t)
+	rpc.Balances[from] = 1000000000
+	rpc.Balances[feePayer] = rpc.Fee
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, "--fee-payer", feePayerFile,
+		"--allow-unfunded-recipient", recipient, "ALL"); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := rpc.Sent[0]
+	if signers := tx.Message.Signers(); len(signers) != 2 || signers[0].ToBase58() != feePayer {
+		t.Fatalf("expected fee payer and sender to sign, got %v", signers)
+	}
+	if lamports := transferredLamports(t, tx); lamports != 1000000000 {
+		t.Fatalf("expected entire balance to be transferred, got %d lamports", lamports)
+	}
+}
+
+func TestAccountTransferRefusesUnfundedRecipient(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, from := newAgentKeyFile(t)
+	recipient := types.NewAccount().PublicKey.ToBase58()
+
+	rpc := newFakeRpc(t)
+	rpc.Balances[from] = 1000000000
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, recipient, "1"); err == nil ||
+		!strings.Contains(err.Error(), "not funded") {
+		t.Fatalf("expected transfer to unfunded recipient to fail, got %v", err)
+	}
+
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, "not-an-address", "1"); err == nil {
+		t.Fatal("expected invalid recipient to fail")
+	}
+
+	if len(rpc.Sent) != 0 {
+		t.Fatalf("expected no transaction to be sent, got %d", len(rpc.Sent))
+	}
+}
+
+func TestAccountTransferFailed(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, from := newAgentKeyFile(t)
+	recipient := types.NewAccount().PublicKey.ToBase58()
+
+	rpc := newFakeRpc(t)
+	rpc.Balances[from] = 1000000000
+	rpc.TxErr = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, "--allow-unfunded-recipient", recipient, "0.5"); err == nil ||
+		!strings.Contains(err.Error(), "failed") {
+		t.Fatalf("expected failed transaction to be reported, got %v", err)
+	}
+
+	// transaction never lands before its blockhash expires
+	rpc.TxErr = nil
+	rpc.PendingPolls = 1000
+	rpc.Handlers["getBlockHeight"] = func([]json.RawMessage) (interface{}, error) {
+		return rpc.BlockHeight + 1000, nil
+	}
+	if _, err := execute(t, AccountTransfer, accountTransferFlags,
+		"--config", config, "--keyfile", keyFile, "--allow-unfunded-recipient", recipient, "0.5"); err == nil ||
+		!strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected expired transaction to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package run
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/kubetrail/solana-kms/pkg/agent"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// agentSocketEnv names env variable that points clients to a running agent
+const agentSocketEnv = "SOLANA_KMS_AGENT_SOCK"
+
+// getDefaultAgentSocket retrieves default agent socket path, preferring per user
+// runtime dir when available
+func getDefaultAgentSocket() (string, error) {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); len(runtimeDir) > 0 {
+		return filepath.Join(runtimeDir, "solana-kms", "agent.sock"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		err := fmt.Errorf("could not get user home dir: %w", err)
+		return "", err
+	}
+
+	return filepath.Join(homeDir, ".config", "solana-kms", "agent.sock"), nil
+}
+
+// getAgentSocket returns agent socket path from --socket flag, env or default
+func getAgentSocket(cmd *cobra.Command) (string, error) {
+	_ = viper.BindPFlag(flags.Socket, cmd.Flags().Lookup(filepath.Base(flags.Socket)))
+	_ = viper.BindEnv(flags.Socket, agentSocketEnv)
+
+	if socket := viper.GetString(flags.Socket); len(socket) > 0 {
+		return socket, nil
+	}
+
+	return getDefaultAgentSocket()
+}
+
+// dialAgent connects to the agent selected by command flags
+func dialAgent(cmd *cobra.Command) (*agent.Client, error) {
+	socket, err := getAgentSocket(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent.Dial(socket)
+}
+
+// AgentStart decrypts keypair once and serves sign-only requests over a Unix
+// socket until stopped or idle for longer than --ttl. Key is held in locked
+// memory and wiped on exit, it is never sent over the socket. KMS native
+// signing keys are served as well, in which case signing happens in KMS.
+func AgentStart(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Ttl, cmd.Flags().Lookup(filepath.Base(flags.Ttl)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	ttl := viper.GetDuration(flags.Ttl)
+
+	if ttl < 0 {
+		err := fmt.Errorf("--%s cannot be negative", flags.Ttl)
+		return err
+	}
+
+	socket, err := getAgentSocket(cmd)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	// key is decrypted once, or for KMS native keys, connected to once
+	keySigner, err := newSigner(ctx, persistentFlags, keyFile)
+	if err != nil {
+		return err
+	}
+
+	server := agent.NewServer(keySigner, ttl)
+	defer server.Stop()
+
+	listener, err := agent.Listen(socket)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := fmt.Fprintf(
+		cmd.ErrOrStderr(),
+		"agent for %s listening on %s\n%s=%s; export %s\n",
+		server.PublicKey(),
+		socket,
+		agentSocketEnv,
+		socket,
+		agentSocketEnv,
+	); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return server.Serve(ctx, listener)
+}
+
+// AgentPubkey prints public key of the key held by a running agent
+func AgentPubkey(cmd *cobra.Command, _ []string) error {
+	client, err := dialAgent(cmd)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	publicKey, err := client.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), publicKey); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// AgentSign asks a running agent to sign input and prints base58 encoded
+// signature. Input is either an arbitrary message or, with --transaction, a
+// base64 encoded serialized transaction message.
+func AgentSign(cmd *cobra.Command, _ []string) error {
+	_ = viper.BindPFlag(flags.Input, cmd.Flags().Lookup(filepath.Base(flags.Input)))
+	_ = viper.BindPFlag(flags.Transaction, cmd.Flags().Lookup(filepath.Base(flags.Transaction)))
+
+	input := viper.GetString(flags.Input)
+	transaction := viper.GetBool(flags.Transaction)
+
+	var data []byte
+	var err error
+	if len(input) == 0 || input == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read input: %w", err)
+		return err
+	}
+
+	if transaction {
+		data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			err := fmt.Errorf("could not decode base64 transaction message: %w", err)
+			return err
+		}
+	}
+
+	client, err := dialAgent(cmd)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var signature []byte
+	if transaction {
+		signature, err = client.SignTransaction(data)
+	} else {
+		signature, err = client.SignMessage(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), base58.Encode(signature)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// AgentStop asks a running agent to wipe its key and exit
+func AgentStop(cmd *cobra.Command, _ []string) error {
+	client, err := dialAgent(cmd)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Stop()
+}
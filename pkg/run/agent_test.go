@@ -0,0 +1,172 @@
+package run
+
+import (
+	"context"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/agent"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/pflag"
+)
+
+// newAgentKeyFile writes a new key file and returns its path and public key
+func newAgentKeyFile(t *testing.T) (string, string) {
+	t.Helper()
+	keyFile := filepath.Join(t.TempDir(), "id")
+
+	keyNewFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	keyEncrypter, _ := newKeyEncrypter(context.Background(), persistentFlagValues{})
+	account, keyBuffer, err := readAccountFromKeyFile(context.Background(), keyEncrypter, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBuffer.Destroy()
+
+	return keyFile, account.PublicKey.ToBase58()
+}
+
+// dialAgentSocket waits for agent to listen on socket and connects to it
+func dialAgentSocket(t *testing.T, socket string) *agent.Client {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client, err := agent.Dial(socket)
+		if err == nil {
+			return client
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAgentStart(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	socket := filepath.Join(t.TempDir(), "agent", "agent.sock")
+
+	agentStartFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.Socket, "", "")
+		f.Duration(flags.Ttl, 0, "")
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := execute(t, AgentStart, agentStartFlags, "--keyfile", keyFile, "--socket", socket, "--ttl", "1m")
+		errs <- err
+	}()
+
+	client := dialAgentSocket(t, socket)
+	defer client.Close()
+
+	got, err := client.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != publicKey {
+		t.Fatalf("expected public key %s, got %s", publicKey, got)
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+}
+
+func TestAgentClientCommands(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	socket := filepath.Join(t.TempDir(), "agent", "agent.sock")
+
+	keySigner, err := newSigner(context.Background(), persistentFlagValues{}, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := agent.NewServer(keySigner, 0)
+	listener, err := agent.Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(context.Background(), listener) }()
+	t.Cleanup(server.Stop)
+
+	socketFlags := func(f *pflag.FlagSet) {
+		f.String(flags.Socket, "", "")
+	}
+	agentSignFlags := func(f *pflag.FlagSet) {
+		f.String(flags.Socket, "", "")
+		f.String(flags.Input, "", "")
+		f.Bool(flags.Transaction, false, "")
+	}
+
+	out, err := execute(t, AgentPubkey, socketFlags, "--socket", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != publicKey {
+		t.Fatalf("expected public key %s, got %s", publicKey, out)
+	}
+
+	message := []byte("hello")
+	input := filepath.Join(t.TempDir(), "message")
+	if err := os.WriteFile(input, message, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = execute(t, AgentSign, agentSignFlags, "--socket", socket, "--input", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := base58.Decode(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := base58.Decode(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, message, signature) {
+		t.Fatal("signature does not verify")
+	}
+
+	if _, err := execute(t, AgentSign, agentSignFlags, "--socket", socket, "--input", input, "--transaction"); err == nil {
+		t.Fatal("expected signing a message as transaction to fail")
+	}
+
+	if _, err := execute(t, AgentStop, socketFlags, "--socket", socket); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-server.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+}
@@ -0,0 +1,88 @@
+package run
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// solDecimals is the number of decimal places of SOL amounts
+const solDecimals = 9
+
+// amountAll is the amount argument that selects entire available balance
+const amountAll = "ALL"
+
+// parseAmount converts decimal amount such as 1.5 to integer base units of a
+// currency with given decimals. Amounts that cannot be represented exactly are
+// rejected rather than rounded.
+func parseAmount(amount string, decimals int) (uint64, error) {
+	whole, fraction := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, fraction = amount[:i], amount[i+1:]
+	}
+
+	if len(whole) == 0 && len(fraction) == 0 {
+		err := fmt.Errorf("invalid amount %q", amount)
+		return 0, err
+	}
+
+	if len(fraction) > decimals {
+		if strings.TrimRight(fraction[decimals:], "0") != "" {
+			err := fmt.Errorf("amount %s has more than %d decimal places", amount, decimals)
+			return 0, err
+		}
+		fraction = fraction[:decimals]
+	}
+
+	digits := whole + fraction + strings.Repeat("0", decimals-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			err := fmt.Errorf("invalid amount %q", amount)
+			return 0, err
+		}
+	}
+
+	value, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		err := fmt.Errorf("invalid amount %q: %w", amount, err)
+		return 0, err
+	}
+
+	if value == 0 {
+		err := fmt.Errorf("amount must be greater than zero")
+		return 0, err
+	}
+
+	return value, nil
+}
+
+// formatAmount converts integer base units to decimal amount without trailing
+// zeros
+func formatAmount(value uint64, decimals int) string {
+	s := strconv.FormatUint(value, 10)
+	if decimals == 0 {
+		return s
+	}
+
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+
+	whole, fraction := s[:len(s)-decimals], strings.TrimRight(s[len(s)-decimals:], "0")
+	if len(fraction) == 0 {
+		return whole
+	}
+
+	return whole + "." + fraction
+}
+
+// checkedAdd adds amounts and reports an error on overflow
+func checkedAdd(a, b uint64) (uint64, error) {
+	if a > math.MaxUint64-b {
+		err := fmt.Errorf("amount overflows")
+		return 0, err
+	}
+
+	return a + b, nil
+}
@@ -0,0 +1,43 @@
+package run
+
+import "testing"
+
+func TestParseAndFormatAmount(t *testing.T) {
+	for input, expected := range map[string]uint64{
+		"1":            1000000000,
+		"1.5":          1500000000,
+		".5":           500000000,
+		"0.000000001":  1,
+		"2.10":         2100000000,
+		"3.0000000000": 3000000000,
+	} {
+		value, err := parseAmount(input, solDecimals)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if value != expected {
+			t.Fatalf("expected %s to parse as %d, got %d", input, expected, value)
+		}
+	}
+
+	for _, input := range []string{"", ".", "0", "-1", "1e9", "0.0000000001", "1.2.3", "18446744073.709551616"} {
+		if _, err := parseAmount(input, solDecimals); err == nil {
+			t.Fatalf("expected %q to fail", input)
+		}
+	}
+
+	for value, expected := range map[uint64]string{
+		0:          "0",
+		1:          "0.000000001",
+		1500000000: "1.5",
+		2000000000: "2",
+	} {
+		if s := formatAmount(value, solDecimals); s != expected {
+			t.Fatalf("expected %d to format as %s, got %s", value, expected, s)
+		}
+	}
+
+	if s := formatAmount(42, 0); s != "42" {
+		t.Fatalf("expected amount without decimals to format as 42, got %s", s)
+	}
+}
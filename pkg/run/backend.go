@@ -0,0 +1,308 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+// newKeyEncrypter creates a key encrypter for the backend selected via persistent flags.
+// It is declared as a variable so that tests can swap it for an in-memory fake.
+var newKeyEncrypter = func(ctx context.Context, persistentFlags persistentFlagValues) (backend.KeyEncrypter, error) {
+	switch persistentFlags.Backend {
+	case backend.Google:
+		if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+			err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+			return nil, err
+		}
+
+		return backend.NewGoogleKms(
+			ctx,
+			getKmsName(
+				persistentFlags.Project,
+				persistentFlags.Location,
+				persistentFlags.Keyring,
+				persistentFlags.Key,
+			),
+		)
+	case backend.Aws:
+		return backend.NewAwsKms(
+			ctx,
+			persistentFlags.AwsKmsKeyArn,
+			persistentFlags.AwsRegion,
+			persistentFlags.AwsProfile,
+			persistentFlags.AwsKmsEndpoint,
+		)
+	case backend.Vault:
+		return backend.NewVaultTransit(
+			persistentFlags.VaultAddr,
+			persistentFlags.VaultToken,
+			persistentFlags.VaultTransitMount,
+			persistentFlags.VaultTransitKey,
+		)
+	case backend.Local:
+		return backend.NewLocalPassphrase(readPassphrase)
+	default:
+		err := fmt.Errorf("unsupported backend: %q", persistentFlags.Backend)
+		return nil, err
+	}
+}
+
+// encryptKeyData encrypts key material and returns serialized envelope
+// that carries ciphertext along with metadata describing how to open it.
+// File role and public key are bound to the ciphertext as additional authenticated data.
+func encryptKeyData(ctx context.Context, keyEncrypter backend.KeyEncrypter, plaintext []byte, role, publicKey string) ([]byte, error) {
+	ciphertext, err := keyEncrypter.Encrypt(ctx, plaintext, envelope.AdditionalData(role, publicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.New(
+		envelope.Header{
+			Backend:    ciphertext.Backend,
+			KeyVersion: ciphertext.KeyVersion,
+			Role:       role,
+			PublicKey:  publicKey,
+			CreatedAt:  time.Now().UTC(),
+			AadVersion: envelope.AadVersion,
+		},
+		ciphertext.Data,
+	).Marshal()
+}
+
+// decryptKeyData decrypts file contents that are either in envelope format
+// or legacy raw ciphertext format. Role is the expected role of the file and is
+// verified as part of additional authenticated data. Plaintext is returned in a
+// secure buffer that caller must destroy. Envelope is returned when available.
+func decryptKeyData(ctx context.Context, keyEncrypter backend.KeyEncrypter, data []byte, role string) (*secure.Buffer, *envelope.Envelope, error) {
+	e, err := envelope.Unmarshal(data)
+	if err != nil {
+		if !errors.Is(err, envelope.ErrNotEnvelope) {
+			err := fmt.Errorf("invalid key file: %w", err)
+			return nil, nil, err
+		}
+
+		// legacy files hold raw ciphertext encrypted without additional data
+		plaintext, err := keyEncrypter.Decrypt(ctx, data, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		buffer, err := secure.Copy(plaintext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return buffer, nil, nil
+	}
+
+	if e.Header.Role == envelope.RoleSigningKey {
+		err := fmt.Errorf("file references KMS native signing key %s, private key never leaves KMS", e.Header.KeyVersion)
+		return nil, nil, err
+	}
+
+	if len(e.Header.Backend) > 0 && e.Header.Backend != keyEncrypter.Type() {
+		err := fmt.Errorf(
+			"file is encrypted using %s backend (key version %s), however, %s backend is in use",
+			e.Header.Backend,
+			e.Header.KeyVersion,
+			keyEncrypter.Type(),
+		)
+		return nil, nil, err
+	}
+
+	if len(e.Header.Role) > 0 && e.Header.Role != role {
+		err := fmt.Errorf("file holds %s data, expected %s", e.Header.Role, role)
+		return nil, nil, err
+	}
+
+	aad, err := e.Header.AdditionalData(role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := keyEncrypter.Decrypt(ctx, e.Ciphertext, aad)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt using key version %s: %w", e.Header.KeyVersion, err)
+		return nil, nil, err
+	}
+
+	buffer, err := secure.Copy(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buffer, e, nil
+}
+
+// newLockedAccount moves private key into a secure buffer, wiping key, and returns
+// account referencing it. Caller must destroy the buffer once done with the account.
+func newLockedAccount(key []byte) (types.Account, *secure.Buffer, error) {
+	buffer, err := secure.Copy(key)
+	if err != nil {
+		return types.Account{}, nil, err
+	}
+
+	account, err := types.AccountFromBytes(buffer.Bytes())
+	if err != nil {
+		buffer.Destroy()
+		err := fmt.Errorf("could not create key pair from private key: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	return account, buffer, nil
+}
+
+// readAccountFromKeyFile reads keypair file and returns account after
+// decrypting file contents as necessary. Plaintext JSON formatted keypair
+// files are accepted as is. Private key of the account is held in the returned
+// secure buffer, which caller must destroy.
+func readAccountFromKeyFile(ctx context.Context, keyEncrypter backend.KeyEncrypter, keyFile string) (types.Account, *secure.Buffer, error) {
+	ciphertext, err := os.ReadFile(keyFile)
+	if err != nil {
+		err := fmt.Errorf("error reading input keypair file: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	var key []byte
+	// try json parsing first and if it fails assume input to be
+	// encrypted
+	if err := json.Unmarshal(ciphertext, &key); err == nil {
+		secure.Wipe(ciphertext)
+		return newLockedAccount(key)
+	}
+
+	buffer, e, err := decryptKeyData(ctx, keyEncrypter, ciphertext, envelope.RoleKeypair)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt private key: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	account, err := types.AccountFromBytes(buffer.Bytes())
+	if err != nil {
+		buffer.Destroy()
+		err := fmt.Errorf("could not create key pair from decrypted data: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	if e != nil && len(e.Header.PublicKey) > 0 && e.Header.PublicKey != account.PublicKey.ToBase58() {
+		buffer.Destroy()
+		err := fmt.Errorf("decrypted key does not match public key %s recorded in key file", e.Header.PublicKey)
+		return types.Account{}, nil, err
+	}
+
+	return account, buffer, nil
+}
+
+// reencryptedKeyFiles holds new ciphertext of keypair and seed files
+type reencryptedKeyFiles struct {
+	PublicKey string
+	Keypair   []byte
+	Seed      []byte
+}
+
+// reencryptKeyFiles decrypts keypair file and its seed file in memory using source
+// key encrypter and re-encrypts both using target key encrypter. Seed is verified to
+// produce the keypair and new ciphertext is verified to round trip before returning.
+func reencryptKeyFiles(
+	ctx context.Context,
+	sourceKeyEncrypter, targetKeyEncrypter backend.KeyEncrypter,
+	keyFile string,
+) (*reencryptedKeyFiles, error) {
+	keyCiphertext, err := os.ReadFile(keyFile)
+	if err != nil {
+		err := fmt.Errorf("error reading input keypair file: %w", err)
+		return nil, err
+	}
+
+	var plaintextKey []byte
+	if err := json.Unmarshal(keyCiphertext, &plaintextKey); err == nil {
+		err := fmt.Errorf("keypair file is not encrypted, please use key import instead")
+		return nil, err
+	}
+
+	seedCiphertext, err := os.ReadFile(fmt.Sprintf("%s.%s", keyFile, "seed"))
+	if err != nil {
+		err := fmt.Errorf("could not read seed file: %w", err)
+		return nil, err
+	}
+
+	account, keyBuffer, err := readAccountFromKeyFile(ctx, sourceKeyEncrypter, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer keyBuffer.Destroy()
+
+	seedBuffer, _, err := decryptKeyData(ctx, sourceKeyEncrypter, seedCiphertext, envelope.RoleSeed)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt seed: %w", err)
+		return nil, err
+	}
+	defer seedBuffer.Destroy()
+	seed := seedBuffer.Bytes()
+
+	seedAccount, seedKeyBuffer, err := accountFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	defer seedKeyBuffer.Destroy()
+
+	if !bytes.Equal(seedAccount.PrivateKey, account.PrivateKey) {
+		err := fmt.Errorf("seed file does not match keypair file")
+		return nil, err
+	}
+
+	publicKey := account.PublicKey.ToBase58()
+
+	newKeyCiphertext, err := encryptKeyData(ctx, targetKeyEncrypter, account.PrivateKey, envelope.RoleKeypair, publicKey)
+	if err != nil {
+		err := fmt.Errorf("could not encrypt private key: %w", err)
+		return nil, err
+	}
+
+	newSeedCiphertext, err := encryptKeyData(ctx, targetKeyEncrypter, seed, envelope.RoleSeed, publicKey)
+	if err != nil {
+		err := fmt.Errorf("could not encrypt private key seed: %w", err)
+		return nil, err
+	}
+
+	// verify new ciphertext round trips to the same key material
+	verifiedKey, _, err := decryptKeyData(ctx, targetKeyEncrypter, newKeyCiphertext, envelope.RoleKeypair)
+	if err != nil {
+		err := fmt.Errorf("could not verify re-encrypted private key: %w", err)
+		return nil, err
+	}
+	defer verifiedKey.Destroy()
+
+	if !bytes.Equal(verifiedKey.Bytes(), account.PrivateKey) {
+		err := fmt.Errorf("re-encrypted private key does not match original")
+		return nil, err
+	}
+
+	verifiedSeed, _, err := decryptKeyData(ctx, targetKeyEncrypter, newSeedCiphertext, envelope.RoleSeed)
+	if err != nil {
+		err := fmt.Errorf("could not verify re-encrypted seed: %w", err)
+		return nil, err
+	}
+	defer verifiedSeed.Destroy()
+
+	if !bytes.Equal(verifiedSeed.Bytes(), seed) {
+		err := fmt.Errorf("re-encrypted seed does not match original")
+		return nil, err
+	}
+
+	return &reencryptedKeyFiles{
+		PublicKey: publicKey,
+		Keypair:   newKeyCiphertext,
+		Seed:      newSeedCiphertext,
+	}, nil
+}
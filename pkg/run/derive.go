@@ -0,0 +1,76 @@
+package run
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+)
+
+const (
+	// hardenedOffset is added to index of hardened derivation path segments
+	hardenedOffset = uint32(0x80000000)
+	// solanaDerivationPathFormat is BIP44 path used by Phantom and solana-keygen
+	// where account index is substituted
+	solanaDerivationPathFormat = "m/44'/501'/%d'/0'"
+)
+
+// parseDerivationPath parses path such as m/44'/501'/0'/0' into indices.
+// Ed25519 supports only hardened derivation, therefore all segments are treated
+// as hardened regardless of the ' or h suffix.
+func parseDerivationPath(path string) ([]uint32, error) {
+	segments := strings.Split(strings.TrimSpace(path), "/")
+	if len(segments) == 0 || segments[0] != "m" {
+		err := fmt.Errorf("derivation path must start with m/")
+		return nil, err
+	}
+
+	indices := make([]uint32, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		segment = strings.TrimRight(segment, "'hH")
+		index, err := strconv.ParseUint(segment, 10, 31)
+		if err != nil {
+			err := fmt.Errorf("invalid derivation path segment %q: %w", segment, err)
+			return nil, err
+		}
+		indices = append(indices, uint32(index)+hardenedOffset)
+	}
+
+	return indices, nil
+}
+
+// deriveKey derives ed25519 private key seed and chain code for the path from
+// master seed as per SLIP-0010
+func deriveKey(seed []byte, path string) ([]byte, []byte, error) {
+	indices, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := hmac.New(sha512.New, []byte("ed25519 seed"))
+	_, _ = h.Write(seed)
+	sum := h.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range indices {
+		data := make([]byte, 1+32+4)
+		copy(data[1:], key)
+		binary.BigEndian.PutUint32(data[33:], index)
+
+		h := hmac.New(sha512.New, chainCode)
+		_, _ = h.Write(data)
+
+		// parent key and chain code are no longer needed
+		secure.Wipe(data)
+		secure.Wipe(sum)
+
+		sum = h.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+
+	return key, chainCode, nil
+}
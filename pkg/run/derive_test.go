@@ -0,0 +1,121 @@
+package run
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+// TestDeriveKey checks against SLIP-0010 ed25519 test vector 1
+func TestDeriveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	tests := []struct {
+		path      string
+		key       string
+		chainCode string
+	}{
+		{
+			path:      "m",
+			key:       "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+			chainCode: "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+		},
+		{
+			path:      "m/0'",
+			key:       "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			chainCode: "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69",
+		},
+		{
+			path:      "m/0H/1H",
+			key:       "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+			chainCode: "a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14",
+		},
+	}
+
+	for _, test := range tests {
+		key, chainCode, err := deriveKey(seed, test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(key) != test.key || hex.EncodeToString(chainCode) != test.chainCode {
+			t.Fatalf("unexpected derivation for %s: key %x chain code %x", test.path, key, chainCode)
+		}
+	}
+
+	if _, _, err := deriveKey(seed, "44'/501'"); err == nil {
+		t.Fatal("expected error for path without m/ prefix")
+	}
+}
+
+func TestKeyDerive(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	dir := t.TempDir()
+
+	seed, err := seedFromMnemonic([]byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedCiphertext, err := encryptKeyData(context.Background(), keyEncrypter, seed, envelope.RoleSeed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedFile := filepath.Join(dir, "id.seed")
+	if err := os.WriteFile(seedFile, seedCiphertext, 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	keyDeriveFlags := func(f *pflag.FlagSet) {
+		f.String(flags.SeedFile, "", "")
+		f.String(flags.DerivationPath, "", "")
+		f.Uint32(flags.Index, 0, "")
+		f.Uint32(flags.Count, 1, "")
+		f.String(flags.OutDir, "", "")
+	}
+
+	out, err := execute(t, KeyDerive, keyDeriveFlags, "--seedfile", seedFile, "--count", "3", "--outdir", filepath.Join(dir, "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var derivedKeys []derivedKey
+	if err := json.Unmarshal([]byte(out), &derivedKeys); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(derivedKeys) != 3 || derivedKeys[1].Path != "m/44'/501'/1'/0'" {
+		t.Fatalf("unexpected derived keys: %s", out)
+	}
+
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, derivedKeys[2].KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.PublicKey.ToBase58() != derivedKeys[2].PublicKey {
+		t.Fatal("derived keypair file does not match public key")
+	}
+
+	out, err = execute(t, KeyDerive, keyDeriveFlags, "--seedfile", seedFile, "--derivation-path", "m/44'/501'/1'/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var single []derivedKey
+	if err := json.Unmarshal([]byte(out), &single); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(single) != 1 || single[0].PublicKey != derivedKeys[1].PublicKey {
+		t.Fatalf("explicit path derivation does not match indexed derivation: %s", out)
+	}
+}
@@ -0,0 +1,194 @@
+package run
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/mr-tron/base58"
+)
+
+// base58Alphabet is the bitcoin alphabet used for Solana addresses
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// grindProgressInterval is how often progress is reported while grinding
+var grindProgressInterval = 5 * time.Second
+
+const (
+	// maxAddressLength is the length of the longest base58 encoded public key
+	maxAddressLength = 44
+	// maxGrindAttempts bounds expected search effort, which is about seven
+	// case sensitive characters and would take weeks on a typical machine
+	maxGrindAttempts = 1e13
+)
+
+// vanityMatcher matches base58 encoded public keys against prefix and suffix
+type vanityMatcher struct {
+	prefix     string
+	suffix     string
+	ignoreCase bool
+}
+
+// newVanityMatcher validates prefix and suffix to only contain characters that
+// can occur in a base58 encoded public key and to fit in one, otherwise search
+// would never end. Patterns that would take too long to find are also rejected.
+func newVanityMatcher(prefix, suffix string, ignoreCase bool) (*vanityMatcher, error) {
+	if len(prefix) == 0 && len(suffix) == 0 {
+		err := fmt.Errorf("please provide a prefix or suffix to search for")
+		return nil, err
+	}
+
+	if len(prefix)+len(suffix) > maxAddressLength {
+		err := fmt.Errorf("prefix and suffix cannot be longer than %d characters of an address", maxAddressLength)
+		return nil, err
+	}
+
+	for _, c := range prefix + suffix {
+		if strings.ContainsRune(base58Alphabet, c) {
+			continue
+		}
+
+		if ignoreCase &&
+			(strings.ContainsAny(base58Alphabet, strings.ToUpper(string(c))) ||
+				strings.ContainsAny(base58Alphabet, strings.ToLower(string(c)))) {
+			continue
+		}
+
+		err := fmt.Errorf("character %q is not valid in a base58 address", c)
+		return nil, err
+	}
+
+	matcher := &vanityMatcher{
+		prefix:     prefix,
+		suffix:     suffix,
+		ignoreCase: ignoreCase,
+	}
+
+	if attempts := matcher.expectedAttempts(); attempts > maxGrindAttempts {
+		err := fmt.Errorf("prefix and suffix are too long, expecting about %.0f attempts", attempts)
+		return nil, err
+	}
+
+	return matcher, nil
+}
+
+// match reports whether base58 encoded public key matches
+func (m *vanityMatcher) match(publicKey string) bool {
+	if len(publicKey) < len(m.prefix)+len(m.suffix) {
+		return false
+	}
+
+	head := publicKey[:len(m.prefix)]
+	tail := publicKey[len(publicKey)-len(m.suffix):]
+	if m.ignoreCase {
+		return strings.EqualFold(head, m.prefix) && strings.EqualFold(tail, m.suffix)
+	}
+
+	return head == m.prefix && tail == m.suffix
+}
+
+// expectedAttempts estimates number of keys to generate before a match is found.
+// Each character is treated as uniformly distributed over the base58 alphabet,
+// which slightly underestimates the effort for leading characters.
+func (m *vanityMatcher) expectedAttempts() float64 {
+	attempts := 1.0
+	for _, c := range m.prefix + m.suffix {
+		matches := 0
+		for _, a := range base58Alphabet {
+			if a == c || (m.ignoreCase && strings.EqualFold(string(a), string(c))) {
+				matches++
+			}
+		}
+		attempts *= float64(len(base58Alphabet)) / float64(matches)
+	}
+
+	return math.Round(attempts)
+}
+
+// grind generates random seeds on given number of workers until public key of
+// derived keypair matches. Seed and the total number of attempts are returned.
+// Progress is called periodically with the number of attempts so far.
+func grind(ctx context.Context, matcher *vanityMatcher, workers int, progress func(attempts uint64)) ([]byte, uint64, error) {
+	if workers < 1 {
+		err := fmt.Errorf("number of threads must be at least 1")
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var attempts uint64
+	found := make(chan []byte, workers)
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seed := make([]byte, ed25519.SeedSize)
+			for ctx.Err() == nil {
+				if _, err := rand.Read(seed); err != nil {
+					secure.Wipe(seed)
+					errs <- fmt.Errorf("could not read random seed: %w", err)
+					return
+				}
+
+				privateKey := ed25519.NewKeyFromSeed(seed)
+				publicKey := privateKey.Public().(ed25519.PublicKey)
+				secure.Wipe(privateKey)
+
+				atomic.AddUint64(&attempts, 1)
+				if matcher.match(base58.Encode(publicKey)) {
+					// seed is wiped by the receiver
+					found <- seed
+					return
+				}
+			}
+			secure.Wipe(seed)
+		}()
+	}
+
+	// stop waits for workers and wipes seeds of matches that were found by
+	// other workers in the same round and are not returned
+	stop := func() {
+		cancel()
+		wg.Wait()
+		for {
+			select {
+			case seed := <-found:
+				secure.Wipe(seed)
+			default:
+				return
+			}
+		}
+	}
+
+	ticker := time.NewTicker(grindProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case seed := <-found:
+			stop()
+			return seed, atomic.LoadUint64(&attempts), nil
+		case err := <-errs:
+			stop()
+			return nil, atomic.LoadUint64(&attempts), err
+		case <-ctx.Done():
+			stop()
+			return nil, atomic.LoadUint64(&attempts), ctx.Err()
+		case <-ticker.C:
+			if progress != nil {
+				progress(atomic.LoadUint64(&attempts))
+			}
+		}
+	}
+}
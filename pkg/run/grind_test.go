@@ -0,0 +1,90 @@
+package run
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+func TestVanityMatcher(t *testing.T) {
+	if _, err := newVanityMatcher("0", "", false); err == nil {
+		t.Fatal("expected error for character outside base58 alphabet")
+	}
+
+	if _, err := newVanityMatcher("l", "", false); err == nil {
+		t.Fatal("expected error for lowercase l")
+	}
+
+	if _, err := newVanityMatcher(strings.Repeat("a", 30), strings.Repeat("b", 15), false); err == nil {
+		t.Fatal("expected error for pattern longer than an address")
+	}
+
+	if _, err := newVanityMatcher("abcd", "efgh", false); err == nil {
+		t.Fatal("expected error for pattern taking too long to find")
+	}
+
+	// uppercase L is valid, so lowercase l matches when ignoring case
+	matcher, err := newVanityMatcher("l", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !matcher.match("Lxyz") || matcher.match("xyzL") {
+		t.Fatal("unexpected case insensitive prefix match")
+	}
+	if matcher.expectedAttempts() != 58 {
+		t.Fatalf("unexpected expected attempts %v", matcher.expectedAttempts())
+	}
+
+	matcher, err = newVanityMatcher("a", "Z", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !matcher.match("abcZ") || matcher.match("Abcz") {
+		t.Fatal("unexpected case sensitive match")
+	}
+	if matcher.expectedAttempts() != 58*58 {
+		t.Fatalf("unexpected expected attempts %v", matcher.expectedAttempts())
+	}
+
+	matcher, err = newVanityMatcher("a", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matcher.expectedAttempts() != 29 {
+		t.Fatalf("unexpected expected attempts %v", matcher.expectedAttempts())
+	}
+}
+
+func TestKeyGrind(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	keyFile := filepath.Join(t.TempDir(), "vanity")
+
+	out, err := execute(t, KeyGrind, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.Prefix, "", "")
+		f.String(flags.Suffix, "", "")
+		f.Bool(flags.IgnoreCase, false, "")
+		f.Int(flags.Threads, 2, "")
+	}, "--keyfile", keyFile, "--prefix", "s", "--ignore-case")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := strings.TrimSpace(out)
+	if !strings.HasPrefix(strings.ToLower(publicKey), "s") {
+		t.Fatalf("public key %q does not match prefix", publicKey)
+	}
+
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.PublicKey.ToBase58() != publicKey {
+		t.Fatalf("encrypted key %s does not match %s", account.PublicKey.ToBase58(), publicKey)
+	}
+}
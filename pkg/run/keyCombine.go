@@ -0,0 +1,178 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/kubetrail/solana-kms/pkg/shamir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeyCombine reconstructs seed from Shamir shares produced by KeySplit and writes
+// freshly encrypted keypair and seed files the same way as KeyNew. Shares are read
+// either from encrypted share files or as words typed on the terminal.
+func KeyCombine(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Share, cmd.Flags().Lookup(filepath.Base(flags.Share)))
+	_ = viper.BindPFlag(flags.ShareKeys, cmd.Flags().Lookup(filepath.Base(flags.ShareKeys)))
+	_ = viper.BindPFlag(flags.Mnemonic, cmd.Flags().Lookup(filepath.Base(flags.Mnemonic)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	shareFiles := viper.GetStringSlice(flags.Share)
+	shareKeys := viper.GetStringSlice(flags.ShareKeys)
+	mnemonic := viper.GetBool(flags.Mnemonic)
+
+	if (len(shareFiles) > 0) == mnemonic {
+		err := fmt.Errorf("please provide either --%s or --%s", flags.Share, flags.Mnemonic)
+		return err
+	}
+
+	if len(shareKeys) > 0 && len(shareKeys) != len(shareFiles) {
+		err := fmt.Errorf("number of --%s must match number of --%s", flags.ShareKeys, flags.Share)
+		return err
+	}
+
+	keyFile, err := resolveNewKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	var encodedShares [][]byte
+	var publicKey string
+
+	if mnemonic {
+		// threshold is encoded in each share, so keep asking until enough are provided
+		for i := 0; i == 0 || i < int(encodedShares[0][1]); i++ {
+			words, err := readSecret(fmt.Sprintf("Enter share %d: ", i+1))
+			if err != nil {
+				err := fmt.Errorf("could not read share: %w", err)
+				return err
+			}
+
+			data, err := shareFromWords(words)
+			secure.Wipe(words)
+			if err != nil {
+				return err
+			}
+
+			if _, _, err := decodeShare(data); err != nil {
+				secure.Wipe(data)
+				return err
+			}
+
+			buffer, err := secure.Copy(data)
+			if err != nil {
+				return err
+			}
+			defer buffer.Destroy()
+
+			encodedShares = append(encodedShares, buffer.Bytes())
+		}
+	}
+
+	for i, shareFile := range shareFiles {
+		shareKeyEncrypter := keyEncrypter
+		if len(shareKeys) > 0 {
+			shareKeyEncrypter, err = newKeyEncrypter(ctx, withTargetKey(persistentFlags, shareKeys[i]))
+			if err != nil {
+				err := fmt.Errorf("could not create key encrypter for share %s: %w", shareFile, err)
+				return err
+			}
+		}
+
+		ciphertext, err := os.ReadFile(shareFile)
+		if err != nil {
+			err := fmt.Errorf("could not read share file: %w", err)
+			return err
+		}
+
+		data, e, err := decryptKeyData(ctx, shareKeyEncrypter, ciphertext, envelope.RoleShare)
+		if shareKeyEncrypter != keyEncrypter {
+			_ = shareKeyEncrypter.Close()
+		}
+		if err != nil {
+			err := fmt.Errorf("could not decrypt share %s: %w", shareFile, err)
+			return err
+		}
+		defer data.Destroy()
+
+		if e != nil && len(e.Header.PublicKey) > 0 {
+			if len(publicKey) > 0 && publicKey != e.Header.PublicKey {
+				err := fmt.Errorf("share %s belongs to %s, other shares belong to %s", shareFile, e.Header.PublicKey, publicKey)
+				return err
+			}
+			publicKey = e.Header.PublicKey
+		}
+
+		encodedShares = append(encodedShares, data.Bytes())
+	}
+
+	shares := make([][]byte, 0, len(encodedShares))
+	var threshold int
+	for i, data := range encodedShares {
+		shareThreshold, share, err := decodeShare(data)
+		if err != nil {
+			err := fmt.Errorf("could not decode share %d: %w", i+1, err)
+			return err
+		}
+
+		if threshold > 0 && shareThreshold != threshold {
+			err := fmt.Errorf("shares were not produced by the same split")
+			return err
+		}
+		threshold = shareThreshold
+
+		shares = append(shares, share)
+	}
+
+	if len(shares) < threshold {
+		err := fmt.Errorf("at least %d shares are required, got %d", threshold, len(shares))
+		return err
+	}
+
+	seed, err := shamir.Combine(shares)
+	if err != nil {
+		err := fmt.Errorf("could not combine shares: %w", err)
+		return err
+	}
+	defer secure.Wipe(seed)
+
+	account, keyBuffer, err := accountFromSeed(seed)
+	if err != nil {
+		return err
+	}
+	defer keyBuffer.Destroy()
+
+	if len(publicKey) > 0 && publicKey != account.PublicKey.ToBase58() {
+		err := fmt.Errorf("combined seed does not match public key %s recorded in shares", publicKey)
+		return err
+	}
+
+	if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, seed, keyFile); err != nil {
+		return err
+	}
+
+	if keyFile != "-" {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), account.PublicKey.ToBase58()); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// derivedKey describes a key derived from the seed
+type derivedKey struct {
+	Path      string `json:"path,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+	KeyFile   string `json:"keyFile,omitempty"`
+}
+
+// KeyDerive derives keypairs from encrypted seed as per SLIP-0010 using either
+// an explicit derivation path or a range of account indices substituted in
+// BIP44 path m/44'/501'/n'/0'. Public keys are printed and derived keypairs are
+// optionally written in encrypted form to an output directory.
+func KeyDerive(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.SeedFile, cmd.Flags().Lookup(filepath.Base(flags.SeedFile)))
+	_ = viper.BindPFlag(flags.DerivationPath, cmd.Flags().Lookup(filepath.Base(flags.DerivationPath)))
+	_ = viper.BindPFlag(flags.Index, cmd.Flags().Lookup(filepath.Base(flags.Index)))
+	_ = viper.BindPFlag(flags.Count, cmd.Flags().Lookup(filepath.Base(flags.Count)))
+	_ = viper.BindPFlag(flags.OutDir, cmd.Flags().Lookup(filepath.Base(flags.OutDir)))
+
+	seedFile := viper.GetString(flags.SeedFile)
+	derivationPath := viper.GetString(flags.DerivationPath)
+	index := viper.GetUint32(flags.Index)
+	count := viper.GetUint32(flags.Count)
+	outDir := viper.GetString(flags.OutDir)
+
+	if len(seedFile) == 0 {
+		keyFile, err := resolveKeyFile("", persistentFlags)
+		if err != nil {
+			return err
+		}
+		seedFile = fmt.Sprintf("%s.%s", keyFile, "seed")
+	}
+
+	var paths []string
+	if len(derivationPath) > 0 {
+		paths = append(paths, derivationPath)
+	} else {
+		if count == 0 {
+			err := fmt.Errorf("--%s must be greater than zero", flags.Count)
+			return err
+		}
+		if uint64(index)+uint64(count) > uint64(hardenedOffset) {
+			err := fmt.Errorf("index range exceeds maximum derivation index")
+			return err
+		}
+		for i := index; i < index+count; i++ {
+			paths = append(paths, fmt.Sprintf(solanaDerivationPathFormat, i))
+		}
+	}
+
+	if len(outDir) > 0 {
+		if err := os.MkdirAll(outDir, 0700); err != nil {
+			err := fmt.Errorf("could not create output dir: %w", err)
+			return err
+		}
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	ciphertext, err := os.ReadFile(seedFile)
+	if err != nil {
+		err := fmt.Errorf("could not read seed file: %w", err)
+		return err
+	}
+
+	seed, _, err := decryptKeyData(ctx, keyEncrypter, ciphertext, envelope.RoleSeed)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt seed: %w", err)
+		return err
+	}
+	defer seed.Destroy()
+
+	derivedKeys := make([]derivedKey, 0, len(paths))
+	for _, path := range paths {
+		key, _, err := deriveKey(seed.Bytes(), path)
+		if err != nil {
+			err := fmt.Errorf("could not derive key for path %s: %w", path, err)
+			return err
+		}
+
+		derived, err := newDerivedKey(ctx, cmd, keyEncrypter, path, key, outDir)
+		secure.Wipe(key)
+		if err != nil {
+			return err
+		}
+
+		derivedKeys = append(derivedKeys, *derived)
+	}
+
+	jb, err := json.MarshalIndent(derivedKeys, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize derived keys: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// newDerivedKey creates account from key derived for path and writes its key
+// files to outDir when provided
+func newDerivedKey(
+	ctx context.Context,
+	cmd *cobra.Command,
+	keyEncrypter backend.KeyEncrypter,
+	path string,
+	key []byte,
+	outDir string,
+) (*derivedKey, error) {
+	account, keyBuffer, err := accountFromSeed(key)
+	if err != nil {
+		return nil, err
+	}
+	defer keyBuffer.Destroy()
+
+	derived := &derivedKey{
+		Path:      path,
+		PublicKey: account.PublicKey.ToBase58(),
+	}
+
+	if len(outDir) > 0 {
+		derived.KeyFile = filepath.Join(outDir, derived.PublicKey)
+		if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, key, derived.KeyFile); err != nil {
+			err := fmt.Errorf("could not write key files for path %s: %w", path, err)
+			return nil, err
+		}
+	}
+
+	return derived, nil
+}
@@ -0,0 +1,129 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeyGrind searches for a keypair whose public key matches a vanity prefix
+// and/or suffix using multiple threads. Winning keypair and seed are encrypted
+// and written the same way as KeyNew so that plaintext never touches the disk.
+func KeyGrind(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Prefix, cmd.Flags().Lookup(filepath.Base(flags.Prefix)))
+	_ = viper.BindPFlag(flags.Suffix, cmd.Flags().Lookup(filepath.Base(flags.Suffix)))
+	_ = viper.BindPFlag(flags.IgnoreCase, cmd.Flags().Lookup(filepath.Base(flags.IgnoreCase)))
+	_ = viper.BindPFlag(flags.Threads, cmd.Flags().Lookup(filepath.Base(flags.Threads)))
+	_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	prefix := viper.GetString(flags.Prefix)
+	suffix := viper.GetString(flags.Suffix)
+	ignoreCase := viper.GetBool(flags.IgnoreCase)
+	threads := viper.GetInt(flags.Threads)
+	force := viper.GetBool(flags.Force)
+
+	matcher, err := newVanityMatcher(prefix, suffix, ignoreCase)
+	if err != nil {
+		return err
+	}
+
+	// resolve named key before the search so that errors are not reported
+	// only after it has completed
+	if len(persistentFlags.KeyName) > 0 {
+		keyFile, err = resolveNewKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+	} else {
+		keyFile = removeSchemeFromPath(keyFile)
+	}
+
+	if len(keyFile) > 0 && keyFile != "-" {
+		if err := checkFilesAbsent(force, keyFile, fmt.Sprintf("%s.%s", keyFile, seedFileExt)); err != nil {
+			return err
+		}
+	}
+
+	// create key encrypter upfront so that misconfiguration is reported
+	// before spending time on the search
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	expected := matcher.expectedAttempts()
+	if _, err := fmt.Fprintf(
+		cmd.ErrOrStderr(),
+		"searching with %d threads, expecting about %.0f attempts\n",
+		threads,
+		expected,
+	); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	start := time.Now()
+	seed, attempts, err := grind(ctx, matcher, threads, func(attempts uint64) {
+		elapsed := time.Since(start).Seconds()
+		rate := float64(attempts) / elapsed
+		_, _ = fmt.Fprintf(
+			cmd.ErrOrStderr(),
+			"%d attempts in %.0fs (%.0f/s, %.1f%% of expected)\n",
+			attempts,
+			elapsed,
+			rate,
+			100*float64(attempts)/expected,
+		)
+	})
+	if err != nil {
+		err := fmt.Errorf("could not find matching keypair after %d attempts: %w", attempts, err)
+		return err
+	}
+	defer secure.Wipe(seed)
+
+	account, keyBuffer, err := accountFromSeed(seed)
+	if err != nil {
+		return err
+	}
+	defer keyBuffer.Destroy()
+
+	// key file is named after the public key unless provided
+	if len(keyFile) == 0 {
+		keyFile = account.PublicKey.ToBase58()
+	}
+
+	if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, seed, keyFile); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(
+		cmd.ErrOrStderr(),
+		"found after %d attempts in %s\n",
+		attempts,
+		time.Since(start).Round(time.Second),
+	); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if keyFile != "-" {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), account.PublicKey.ToBase58()); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package run
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeyImport reads an existing plaintext keypair from a file, stdin or a hidden
+// terminal prompt and writes it in encrypted form. Keypair can be formatted as
+// solana-keygen JSON array, base58 (Phantom export) or hex string.
+func KeyImport(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Input, cmd.Flags().Lookup(filepath.Base(flags.Input)))
+	_ = viper.BindPFlag(flags.Shred, cmd.Flags().Lookup(filepath.Base(flags.Shred)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	input := viper.GetString(flags.Input)
+	shred := viper.GetBool(flags.Shred)
+
+	if shred && (len(input) == 0 || input == "-") {
+		err := fmt.Errorf("--%s requires --%s to be a file", flags.Shred, flags.Input)
+		return err
+	}
+
+	keyFile, err := resolveNewKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	// check before reading input so that a secret is not typed in or read
+	// for a key that is then not written
+	if keyFile != "-" {
+		_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+		if err := checkFilesAbsent(
+			viper.GetBool(flags.Force),
+			keyFile,
+			fmt.Sprintf("%s.%s", keyFile, seedFileExt),
+		); err != nil {
+			return err
+		}
+	}
+
+	var data []byte
+	switch input {
+	case "":
+		data, err = readSecret("Enter private key: ")
+		if err != nil {
+			err := fmt.Errorf("could not read private key: %w", err)
+			return err
+		}
+	case "-":
+		data, err = io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			err := fmt.Errorf("could not read private key from stdin: %w", err)
+			return err
+		}
+	default:
+		data, err = os.ReadFile(input)
+		if err != nil {
+			err := fmt.Errorf("could not read private key file: %w", err)
+			return err
+		}
+	}
+
+	defer secure.Wipe(data)
+
+	account, keyBuffer, err := parseKeypair(data)
+	if err != nil {
+		err := fmt.Errorf("could not parse private key: %w", err)
+		return err
+	}
+	defer keyBuffer.Destroy()
+
+	seed := account.PrivateKey.Seed()
+	defer secure.Wipe(seed)
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, seed, keyFile); err != nil {
+		return err
+	}
+
+	if shred {
+		if err := shredFile(input); err != nil {
+			err := fmt.Errorf("could not shred plaintext input file: %w", err)
+			return err
+		}
+	}
+
+	if keyFile != "-" {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), account.PublicKey.ToBase58()); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseKeypair parses 64 byte keypair formatted as JSON array, hex or base58
+// string and validates that public key half matches the private key. Private key
+// is held in the returned secure buffer, which caller must destroy.
+func parseKeypair(data []byte) (types.Account, *secure.Buffer, error) {
+	data = bytes.TrimSpace(data)
+
+	// secret input is never converted to string, which would leave a copy
+	// on the heap that cannot be wiped
+	var key []byte
+	defer func() { secure.Wipe(key) }()
+	switch {
+	case len(data) == 0:
+		err := fmt.Errorf("input is empty")
+		return types.Account{}, nil, err
+	case data[0] == '[':
+		if err := json.Unmarshal(data, &key); err != nil {
+			err := fmt.Errorf("could not parse JSON array: %w", err)
+			return types.Account{}, nil, err
+		}
+	case len(data) == 2*ed25519.PrivateKeySize && isHex(data):
+		key = make([]byte, hex.DecodedLen(len(data)))
+		if _, err := hex.Decode(key, data); err != nil {
+			err := fmt.Errorf("could not decode hex: %w", err)
+			return types.Account{}, nil, err
+		}
+	default:
+		var err error
+		key, err = decodeBase58(data)
+		if err != nil {
+			err := fmt.Errorf("input is neither JSON array, hex nor base58: %w", err)
+			return types.Account{}, nil, err
+		}
+	}
+
+	account, keyBuffer, err := newLockedAccount(key)
+	if err != nil {
+		return types.Account{}, nil, err
+	}
+
+	seed := account.PrivateKey.Seed()
+	defer secure.Wipe(seed)
+
+	expected := ed25519.NewKeyFromSeed(seed)
+	defer secure.Wipe(expected)
+
+	if !bytes.Equal(expected, account.PrivateKey) {
+		keyBuffer.Destroy()
+		err := fmt.Errorf("public key does not match private key")
+		return types.Account{}, nil, err
+	}
+
+	return account, keyBuffer, nil
+}
+
+// isHex reports whether data consists only of hex digits
+func isHex(data []byte) bool {
+	for _, c := range data {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeBase58 decodes base58 input held in a byte slice, so that unlike
+// base58.Decode secret input need not be converted to string. Intermediate
+// results are wiped.
+func decodeBase58(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		err := fmt.Errorf("base58 input is empty")
+		return nil, err
+	}
+
+	// each leading 1 encodes a leading zero byte
+	zeros := 0
+	for zeros < len(input) && input[zeros] == '1' {
+		zeros++
+	}
+
+	// log(58) / log(256) is about 0.733
+	buf := make([]byte, len(input)*733/1000+1)
+	defer secure.Wipe(buf)
+
+	for _, c := range input {
+		carry := strings.IndexByte(base58Alphabet, c)
+		if carry < 0 {
+			err := fmt.Errorf("invalid base58 character")
+			return nil, err
+		}
+
+		for i := len(buf) - 1; i >= 0; i-- {
+			carry += 58 * int(buf[i])
+			buf[i] = byte(carry)
+			carry >>= 8
+		}
+	}
+
+	start := 0
+	for start < len(buf) && buf[start] == 0 {
+		start++
+	}
+
+	out := make([]byte, zeros+len(buf)-start)
+	copy(out[zeros:], buf[start:])
+	return out, nil
+}
+
+// shredFile overwrites file contents with random data before removing it
+func shredFile(name string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
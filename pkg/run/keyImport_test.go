@@ -0,0 +1,136 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/pflag"
+)
+
+func TestParseKeypair(t *testing.T) {
+	privateKey := "39fVpgen8BDGfryixELCVEV51D2CNUJG6DREeRAQ7Qn564rzarkBMeQb6HxdLyZw1xKqhNEqwNMAxuFr24xiX6yG"
+	publicKey := "5YcDXyNdRuKVZ8aoPjy2uCGyHPJUKfxmWHXM4JneuCsc"
+
+	key, err := base58.Decode(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyValues := make([]int, len(key))
+	for i, value := range key {
+		keyValues[i] = int(value)
+	}
+	jb, err := json.Marshal(keyValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{
+		privateKey + "\n",
+		hex.EncodeToString(key),
+		strings.ToUpper(hex.EncodeToString(key)),
+		string(jb),
+	} {
+		account, _, err := parseKeypair([]byte(input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", input, err)
+		}
+
+		if account.PublicKey.ToBase58() != publicKey {
+			t.Fatalf("unexpected public key for input %q", input)
+		}
+	}
+
+	key[63] ^= 0xff
+	if _, _, err := parseKeypair([]byte(base58.Encode(key))); err == nil {
+		t.Fatal("expected error for mismatched public key")
+	}
+}
+
+func TestKeyImportShred(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "id.json")
+	if err := os.WriteFile(input, []byte("39fVpgen8BDGfryixELCVEV51D2CNUJG6DREeRAQ7Qn564rzarkBMeQb6HxdLyZw1xKqhNEqwNMAxuFr24xiX6yG"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(dir, "id")
+	out, err := execute(t, KeyImport, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.Input, "", "")
+		f.Bool(flags.Shred, false, "")
+	}, "--keyfile", keyFile, "--input", input, "--shred")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(out) != "5YcDXyNdRuKVZ8aoPjy2uCGyHPJUKfxmWHXM4JneuCsc" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Fatal("plaintext input file was not removed")
+	}
+
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.PublicKey.ToBase58() != strings.TrimSpace(out) {
+		t.Fatal("imported keypair does not match input")
+	}
+}
+
+func TestDecodeBase58(t *testing.T) {
+	for _, data := range [][]byte{
+		{0},
+		{0, 0, 1, 2, 3},
+		{255, 254, 253},
+		[]byte("hello world"),
+	} {
+		decoded, err := decodeBase58([]byte(base58.Encode(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Fatalf("expected %x, got %x", data, decoded)
+		}
+	}
+
+	if _, err := decodeBase58([]byte("0OIl")); err == nil {
+		t.Fatal("expected error for characters outside base58 alphabet")
+	}
+}
+
+func TestKeyImportRefusesExistingFile(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, _ := newAgentKeyFile(t)
+
+	origReadSecret := readSecret
+	t.Cleanup(func() { readSecret = origReadSecret })
+	readSecret = func(prompt string) ([]byte, error) {
+		t.Fatalf("expected no prompt, got %q", prompt)
+		return nil, nil
+	}
+
+	if _, err := execute(t, KeyImport, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.Input, "", "")
+		f.Bool(flags.Shred, false, "")
+		f.Bool(flags.Force, false, "")
+	}, "--keyfile", keyFile); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("expected existing key file to be refused, got %v", err)
+	}
+}
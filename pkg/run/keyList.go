@@ -0,0 +1,40 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// KeyList prints metadata of all named keys in keystore without decrypting them
+func KeyList(cmd *cobra.Command, _ []string) error {
+	persistentFlags := getPersistentFlags(cmd)
+
+	dir, err := getKeystoreDir(persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	entries, err := listKeystore(dir)
+	if err != nil {
+		return err
+	}
+
+	if entries == nil {
+		entries = []*keystoreEntry{}
+	}
+
+	jb, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize keystore entries: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -2,23 +2,25 @@ package run
 
 import (
 	"bytes"
+	"context"
 	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
-	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
 	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
 	"github.com/portto/solana-go-sdk/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
-	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-// KeyNew generates a new private keypair data either from random seed or a seedfile
-// provided as input. The seedfile needs to be in encrypted format.
+// KeyNew generates a new private keypair data either from random seed, a new BIP39
+// mnemonic or a seedfile provided as input. The seedfile needs to be in encrypted format.
 // When seedfile is not provided, a seedfile is generated along with the private keypair
 // data with .seed extension
 func KeyNew(cmd *cobra.Command, _ []string) error {
@@ -27,133 +29,276 @@ func KeyNew(cmd *cobra.Command, _ []string) error {
 
 	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
 	_ = viper.BindPFlag(flags.SeedFile, cmd.Flags().Lookup(filepath.Base(flags.SeedFile)))
+	_ = viper.BindPFlag(flags.Mnemonic, cmd.Flags().Lookup(filepath.Base(flags.Mnemonic)))
+	_ = viper.BindPFlag(flags.MnemonicWords, cmd.Flags().Lookup(filepath.Base(flags.MnemonicWords)))
+	_ = viper.BindPFlag(flags.MnemonicPassphrase, cmd.Flags().Lookup(filepath.Base(flags.MnemonicPassphrase)))
+	_ = viper.BindPFlag(flags.Label, cmd.Flags().Lookup(filepath.Base(flags.Label)))
+	_ = viper.BindPFlag(flags.KmsNative, cmd.Flags().Lookup(filepath.Base(flags.KmsNative)))
 
 	keyFile := viper.GetString(flags.KeyFile)
 	seedFile := viper.GetString(flags.SeedFile)
+	mnemonic := viper.GetBool(flags.Mnemonic)
+	mnemonicWords := viper.GetInt(flags.MnemonicWords)
+	mnemonicPassphrase := viper.GetBool(flags.MnemonicPassphrase)
+	labelValues := viper.GetStringSlice(flags.Label)
+	kmsNative := viper.GetBool(flags.KmsNative)
 
-	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
-		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+	keyFile, err := resolveNewKeyFile(keyFile, persistentFlags)
+	if err != nil {
 		return err
 	}
 
-	if len(keyFile) == 0 {
-		if len(persistentFlags.ConfigFile) == 0 {
-			var err error
-			persistentFlags.ConfigFile, err = getDefaultConfigFilename()
-			if err != nil {
-				err := fmt.Errorf("could not get default config filename: %w", err)
-				return err
-			}
-		}
+	labels, err := parseLabels(labelValues)
+	if err != nil {
+		return err
+	}
 
-		configValues, err := getConfigValues(persistentFlags.ConfigFile)
-		if err != nil {
-			err := fmt.Errorf("could not get config values: %w", err)
-			return err
-		}
+	if len(labels) > 0 && keyFile == "-" {
+		err := fmt.Errorf("--%s cannot be used when writing key to stdout", flags.Label)
+		return err
+	}
 
-		if configValues == nil || len(configValues.KeypairPath) == 0 {
-			err := fmt.Errorf("could not find a valid keypair path from config file")
+	if kmsNative {
+		if len(seedFile) > 0 || mnemonic {
+			err := fmt.Errorf("--%s cannot be used with --%s or --%s", flags.KmsNative, flags.SeedFile, flags.Mnemonic)
 			return err
 		}
 
-		keyFile = configValues.KeypairPath
+		return newKmsNativeKey(ctx, cmd, persistentFlags, keyFile, labels)
 	}
 
-	keyFile = removeSchemeFromPath(keyFile)
-
-	kmsClient, err := kms.NewKeyManagementClient(ctx)
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
 	if err != nil {
-		err := fmt.Errorf("failed to create kms client: %w", err)
+		err := fmt.Errorf("could not create key encrypter: %w", err)
 		return err
 	}
-	defer kmsClient.Close()
+	defer keyEncrypter.Close()
 
-	var account types.Account
+	if len(seedFile) > 0 && mnemonic {
+		err := fmt.Errorf("--%s and --%s cannot be used together", flags.SeedFile, flags.Mnemonic)
+		return err
+	}
+
+	// check before generating the key so that a mnemonic is not shown for a
+	// key that is then not written
+	if keyFile != "-" {
+		_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+		if err := checkFilesAbsent(
+			viper.GetBool(flags.Force),
+			keyFile,
+			fmt.Sprintf("%s.%s", keyFile, seedFileExt),
+		); err != nil {
+			return err
+		}
+	}
+
+	// seed is held in locked memory irrespective of its source
+	var seed *secure.Buffer
+	defer func() { seed.Destroy() }()
 
-	// if seed file is provided, use that to generate new account
+	// if seed file is provided, use that to generate new account,
+	// if mnemonic is requested, derive seed from a new mnemonic,
 	// otherwise generate new account using default random seed
-	if len(seedFile) > 0 {
+	switch {
+	case len(seedFile) > 0:
 		ciphertext, err := os.ReadFile(seedFile)
 		if err != nil {
 			err := fmt.Errorf("could not read seed file: %w", err)
 			return err
 		}
 
-		decryptResponse, err := kmsClient.Decrypt(
-			ctx,
-			&kms2.DecryptRequest{
-				Name: getKmsName(
-					persistentFlags.Project,
-					persistentFlags.Location,
-					persistentFlags.Keyring,
-					persistentFlags.Key,
-				),
-				Ciphertext:                        ciphertext,
-				AdditionalAuthenticatedData:       nil,
-				CiphertextCrc32C:                  wrapperspb.Int64(int64(crc32Sum(ciphertext))),
-				AdditionalAuthenticatedDataCrc32C: nil,
-			},
-		)
+		seed, _, err = decryptKeyData(ctx, keyEncrypter, ciphertext, envelope.RoleSeed)
 		if err != nil {
 			err := fmt.Errorf("could not decrypt seed: %w", err)
 			return err
 		}
+	case mnemonic:
+		phrase, err := newMnemonic(mnemonicWords)
+		if err != nil {
+			err := fmt.Errorf("could not generate mnemonic: %w", err)
+			return err
+		}
+		defer phrase.Destroy()
 
-		_, X, err := ed25519.GenerateKey(bytes.NewReader(decryptResponse.Plaintext))
+		var passphrase []byte
+		if mnemonicPassphrase {
+			passphrase, err = readMnemonicPassphrase(true)
+			if err != nil {
+				return err
+			}
+			defer secure.Wipe(passphrase)
+		}
+
+		data, err := seedFromMnemonic(phrase.Bytes(), passphrase)
+		if err != nil {
+			err := fmt.Errorf("could not generate seed from mnemonic: %w", err)
+			return err
+		}
+
+		seed, err = secure.Copy(data)
+		if err != nil {
+			return err
+		}
+
+		account, keyBuffer, err := accountFromSeed(seed.Bytes())
+		if err != nil {
+			return err
+		}
+		keyBuffer.Destroy()
+
+		message, err := joinSecret(
+			[]byte(fmt.Sprintf(
+				"pubkey: %s\nSave this seed phrase to recover your new keypair, it will not be shown again:\n",
+				account.PublicKey.ToBase58(),
+			)),
+			phrase.Bytes(),
+			[]byte("\n"),
+		)
 		if err != nil {
-			err := fmt.Errorf("could not generate ed25519 key: %w", err)
 			return err
 		}
-		account, err = types.AccountFromBytes(X)
+		defer message.Destroy()
+
+		if err := writeToTerminal(message.Bytes()); err != nil {
+			err := fmt.Errorf("could not display mnemonic: %w", err)
+			return err
+		}
+	default:
+		seed, err = secure.NewBuffer(ed25519.SeedSize)
 		if err != nil {
-			err := fmt.Errorf("could not generate new account: %w", err)
-			return err
-		}
-	} else {
-		account = types.NewAccount()
-	}
-
-	encryptResponseKey, err := kmsClient.Encrypt(
-		ctx,
-		&kms2.EncryptRequest{
-			Name: getKmsName(
-				persistentFlags.Project,
-				persistentFlags.Location,
-				persistentFlags.Keyring,
-				persistentFlags.Key,
-			),
-			Plaintext:                         account.PrivateKey,
-			AdditionalAuthenticatedData:       nil,
-			PlaintextCrc32C:                   wrapperspb.Int64(int64(crc32Sum(account.PrivateKey))),
-			AdditionalAuthenticatedDataCrc32C: nil,
-		},
-	)
+			return err
+		}
+
+		if _, err := rand.Read(seed.Bytes()); err != nil {
+			err := fmt.Errorf("could not read random seed: %w", err)
+			return err
+		}
+	}
+
+	account, keyBuffer, err := accountFromSeed(seed.Bytes())
 	if err != nil {
-		err := fmt.Errorf("could not encrypt private key: %w", err)
 		return err
 	}
+	defer keyBuffer.Destroy()
 
-	encryptResponseSeed, err := kmsClient.Encrypt(
-		ctx,
-		&kms2.EncryptRequest{
-			Name: getKmsName(
-				persistentFlags.Project,
-				persistentFlags.Location,
-				persistentFlags.Keyring,
-				persistentFlags.Key,
-			),
-			Plaintext:                         account.PrivateKey.Seed(),
-			AdditionalAuthenticatedData:       nil,
-			PlaintextCrc32C:                   wrapperspb.Int64(int64(crc32Sum(account.PrivateKey.Seed()))),
-			AdditionalAuthenticatedDataCrc32C: nil,
-		},
-	)
+	if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, seed.Bytes(), keyFile); err != nil {
+		return err
+	}
+
+	if len(labels) > 0 {
+		if err := writeKeyMetadata(keyFile, &keyMetadata{Labels: labels}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newKmsNativeKey creates a new KMS native signing key version and writes key
+// file referencing it. Key file carries no key material, private key never leaves KMS.
+func newKmsNativeKey(
+	ctx context.Context,
+	cmd *cobra.Command,
+	persistentFlags persistentFlagValues,
+	keyFile string,
+	labels map[string]string,
+) error {
+	if keyFile == "-" {
+		err := fmt.Errorf("--%s requires a key file", flags.KmsNative)
+		return err
+	}
+
+	_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+	_ = viper.BindPFlag(flags.Backup, cmd.Flags().Lookup(filepath.Base(flags.Backup)))
+
+	force := viper.GetBool(flags.Force)
+	backup := viper.GetBool(flags.Backup)
+
+	// check early so that a KMS key version is not created in vain
+	if err := checkFilesAbsent(force, keyFile); err != nil {
+		return err
+	}
+
+	kmsKeySigner, err := newKmsSigner(ctx, persistentFlags, "")
+	if err != nil {
+		err := fmt.Errorf("could not create kms signing key: %w", err)
+		return err
+	}
+	defer kmsKeySigner.Close()
+
+	publicKey, err := kmsKeySigner.PublicKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := signingKeyFileData(persistentFlags.Backend, kmsKeySigner.KeyVersion(), publicKey)
+	if err != nil {
+		return err
+	}
+
+	pending := &pendingFile{Name: keyFile, Data: data}
+	if backup {
+		pending.Backup = fmt.Sprintf("%s.%s", keyFile, keyFileBackupSuffix())
+	}
+
+	if err := writeFilesAtomically([]*pendingFile{pending}, 0400, force); err != nil {
+		err := fmt.Errorf("could not write key file: %w", err)
+		return err
+	}
+
+	if len(labels) > 0 {
+		if err := writeKeyMetadata(keyFile, &keyMetadata{Labels: labels}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// accountFromSeed generates ed25519 keypair from seed. Only the first 32 bytes of the
+// seed are consumed, which for a 64 byte BIP39 seed matches solana-keygen derivation
+// when no derivation path is used. Private key is held in the returned secure buffer,
+// which caller must destroy.
+func accountFromSeed(seed []byte) (types.Account, *secure.Buffer, error) {
+	_, X, err := ed25519.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		err := fmt.Errorf("could not generate ed25519 key: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	account, buffer, err := newLockedAccount(X)
+	if err != nil {
+		err := fmt.Errorf("could not generate new account: %w", err)
+		return types.Account{}, nil, err
+	}
+
+	return account, buffer, nil
+}
+
+// writeKeyFiles encrypts private key and seed and writes them to keyFile and
+// keyFile.seed respectively as a single transaction. When keyFile is "-" the
+// ciphertext is written to command output instead. Existing files are only
+// overwritten when command has --force set, optionally keeping a --backup.
+func writeKeyFiles(
+	ctx context.Context,
+	cmd *cobra.Command,
+	keyEncrypter backend.KeyEncrypter,
+	account types.Account,
+	seed []byte,
+	keyFile string,
+) error {
+	keyCiphertext, err := encryptKeyData(ctx, keyEncrypter, account.PrivateKey, envelope.RoleKeypair, account.PublicKey.ToBase58())
 	if err != nil {
 		err := fmt.Errorf("could not encrypt private key: %w", err)
 		return err
 	}
 
+	seedCiphertext, err := encryptKeyData(ctx, keyEncrypter, seed, envelope.RoleSeed, account.PublicKey.ToBase58())
+	if err != nil {
+		err := fmt.Errorf("could not encrypt private key seed: %w", err)
+		return err
+	}
+
 	if keyFile == "-" {
 		type keyInfo struct {
 			PrivateKeyCipherText []byte `json:"privateKeyCipherText,omitempty"`
@@ -161,8 +306,8 @@ func KeyNew(cmd *cobra.Command, _ []string) error {
 		}
 
 		info := &keyInfo{
-			PrivateKeyCipherText: encryptResponseKey.Ciphertext,
-			SeedCipherText:       encryptResponseSeed.Ciphertext,
+			PrivateKeyCipherText: keyCiphertext,
+			SeedCipherText:       seedCiphertext,
 		}
 
 		jb, err := json.MarshalIndent(info, "", "  ")
@@ -182,14 +327,14 @@ func KeyNew(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	if err := os.WriteFile(keyFile, encryptResponseKey.Ciphertext, 0400); err != nil {
-		err := fmt.Errorf("could not write encrypted private key to outfile: %w", err)
-		return err
-	}
+	_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+	_ = viper.BindPFlag(flags.Backup, cmd.Flags().Lookup(filepath.Base(flags.Backup)))
+
+	force := viper.GetBool(flags.Force)
+	backup := viper.GetBool(flags.Backup)
 
-	seedFile = fmt.Sprintf("%s.%s", keyFile, "seed")
-	if err := os.WriteFile(seedFile, encryptResponseSeed.Ciphertext, 0400); err != nil {
-		err := fmt.Errorf("could not write encrypted private key seed to outfile: %w", err)
+	if err := writeKeyFilePair(keyFile, keyCiphertext, seedCiphertext, force, backup); err != nil {
+		err := fmt.Errorf("could not write encrypted key files: %w", err)
 		return err
 	}
 
@@ -0,0 +1,91 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeyRecover rebuilds keypair from a BIP39 mnemonic typed on the terminal and
+// writes encrypted keypair and seed files the same way as KeyNew
+func KeyRecover(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.MnemonicPassphrase, cmd.Flags().Lookup(filepath.Base(flags.MnemonicPassphrase)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	mnemonicPassphrase := viper.GetBool(flags.MnemonicPassphrase)
+
+	keyFile, err := resolveNewKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	// check before prompting so that a secret is not typed in for a key
+	// that is then not written
+	if keyFile != "-" {
+		_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+		if err := checkFilesAbsent(
+			viper.GetBool(flags.Force),
+			keyFile,
+			fmt.Sprintf("%s.%s", keyFile, seedFileExt),
+		); err != nil {
+			return err
+		}
+	}
+
+	mnemonic, err := readSecret("Enter seed phrase: ")
+	if err != nil {
+		err := fmt.Errorf("could not read mnemonic: %w", err)
+		return err
+	}
+	defer secure.Wipe(mnemonic)
+
+	var passphrase []byte
+	if mnemonicPassphrase {
+		passphrase, err = readMnemonicPassphrase(false)
+		if err != nil {
+			return err
+		}
+		defer secure.Wipe(passphrase)
+	}
+
+	seed, err := seedFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		err := fmt.Errorf("could not recover seed from mnemonic: %w", err)
+		return err
+	}
+	defer secure.Wipe(seed)
+
+	account, keyBuffer, err := accountFromSeed(seed)
+	if err != nil {
+		return err
+	}
+	defer keyBuffer.Destroy()
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	if err := writeKeyFiles(ctx, cmd, keyEncrypter, account, seed, keyFile); err != nil {
+		return err
+	}
+
+	if keyFile != "-" {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), account.PublicKey.ToBase58()); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+	}
+
+	return nil
+}
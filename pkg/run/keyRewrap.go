@@ -0,0 +1,210 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// rewrappedKey reports outcome of rewrapping a single keypair file
+type rewrappedKey struct {
+	KeyFile    string `json:"keyFile,omitempty"`
+	OutFile    string `json:"outFile,omitempty"`
+	PublicKey  string `json:"publicKey,omitempty"`
+	KeyVersion string `json:"keyVersion,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// KeyRewrap migrates keypair and seed files from the source KMS key, selected via
+// persistent flags, to a destination KMS key, which may live in a different keyring,
+// project or backend. Key material is only ever held in memory. New files are written
+// to output dir and source files are left untouched.
+func KeyRewrap(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+	destFlags := getDestinationFlags(cmd, persistentFlags)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.InDir, cmd.Flags().Lookup(filepath.Base(flags.InDir)))
+	_ = viper.BindPFlag(flags.OutDir, cmd.Flags().Lookup(filepath.Base(flags.OutDir)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	inDir := viper.GetString(flags.InDir)
+	outDir := viper.GetString(flags.OutDir)
+
+	if len(outDir) == 0 {
+		err := fmt.Errorf("please provide output dir using --%s", flags.OutDir)
+		return err
+	}
+
+	if len(keyFile) > 0 && len(inDir) > 0 {
+		err := fmt.Errorf("--%s and --%s cannot be used together", flags.KeyFile, flags.InDir)
+		return err
+	}
+
+	var keyFiles []string
+	if len(inDir) > 0 {
+		var err error
+		keyFiles, err = listKeyFiles(inDir)
+		if err != nil {
+			return err
+		}
+
+		if len(keyFiles) == 0 {
+			err := fmt.Errorf("no keypair files with matching seed files found in %s", inDir)
+			return err
+		}
+	} else {
+		var err error
+		keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+		keyFiles = []string{keyFile}
+	}
+
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		err := fmt.Errorf("could not create output dir: %w", err)
+		return err
+	}
+
+	sourceKeyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create source key encrypter: %w", err)
+		return err
+	}
+	defer sourceKeyEncrypter.Close()
+
+	destKeyEncrypter, err := newKeyEncrypter(ctx, destFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create destination key encrypter: %w", err)
+		return err
+	}
+	defer destKeyEncrypter.Close()
+
+	// process all files so that a single failure in batch mode does not
+	// hide the state of the rest, and report failures at the end
+	var failed int
+	results := make([]*rewrappedKey, 0, len(keyFiles))
+	for _, keyFile := range keyFiles {
+		result := &rewrappedKey{
+			KeyFile: keyFile,
+			OutFile: filepath.Join(outDir, filepath.Base(keyFile)),
+		}
+		results = append(results, result)
+
+		if err := rewrapKeyFile(ctx, sourceKeyEncrypter, destKeyEncrypter, result); err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+	}
+
+	jb, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize rewrap results: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("could not rewrap %d of %d key files", failed, len(results))
+		return err
+	}
+
+	return nil
+}
+
+// rewrapKeyFile re-encrypts result.KeyFile and its seed file and writes them
+// to result.OutFile, which must not already exist
+func rewrapKeyFile(
+	ctx context.Context,
+	sourceKeyEncrypter, destKeyEncrypter backend.KeyEncrypter,
+	result *rewrappedKey,
+) error {
+	reencrypted, err := reencryptKeyFiles(ctx, sourceKeyEncrypter, destKeyEncrypter, result.KeyFile)
+	if err != nil {
+		err := fmt.Errorf("could not re-encrypt key files: %w", err)
+		return err
+	}
+	result.PublicKey = reencrypted.PublicKey
+
+	if err := writeKeyFilePair(result.OutFile, reencrypted.Keypair, reencrypted.Seed, false, false); err != nil {
+		err := fmt.Errorf("could not write key files: %w", err)
+		return err
+	}
+
+	info, err := getKeyFileInfo(result.OutFile)
+	if err != nil {
+		err := fmt.Errorf("could not get key file info: %w", err)
+		return err
+	}
+	result.KeyVersion = info.KeyVersion
+
+	return nil
+}
+
+// listKeyFiles returns keypair files in dir that have a matching seed file
+func listKeyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		err := fmt.Errorf("could not read input dir: %w", err)
+		return nil, err
+	}
+
+	var keyFiles []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// skip seed, metadata and backup files the same way keystore listings do
+		if !entry.Type().IsRegular() || validateKeyName(name) != nil {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("%s.%s", name, seedFileExt))); err != nil {
+			continue
+		}
+
+		keyFiles = append(keyFiles, filepath.Join(dir, name))
+	}
+
+	return keyFiles, nil
+}
+
+// getDestinationFlags returns a copy of source persistent flag values with
+// destination backend and key settings applied where provided
+func getDestinationFlags(cmd *cobra.Command, source persistentFlagValues) persistentFlagValues {
+	dest := source
+	for flag, value := range map[string]*string{
+		flags.DestBackend:              &dest.Backend,
+		flags.DestGoogleProjectID:      &dest.Project,
+		flags.DestKmsLocation:          &dest.Location,
+		flags.DestKmsKeyring:           &dest.Keyring,
+		flags.DestKmsKey:               &dest.Key,
+		flags.DestGoogleAppCredentials: &dest.ApplicationCredentials,
+		flags.DestAwsKmsKeyArn:         &dest.AwsKmsKeyArn,
+		flags.DestAwsRegion:            &dest.AwsRegion,
+		flags.DestAwsProfile:           &dest.AwsProfile,
+		flags.DestAwsKmsEndpoint:       &dest.AwsKmsEndpoint,
+		flags.DestVaultAddr:            &dest.VaultAddr,
+		flags.DestVaultToken:           &dest.VaultToken,
+		flags.DestVaultTransitMount:    &dest.VaultTransitMount,
+		flags.DestVaultTransitKey:      &dest.VaultTransitKey,
+	} {
+		_ = viper.BindPFlag(flag, cmd.Flags().Lookup(filepath.Base(flag)))
+		if v := viper.GetString(flag); len(v) > 0 {
+			*value = v
+		}
+	}
+
+	return dest
+}
@@ -0,0 +1,94 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+func TestKeyRewrap(t *testing.T) {
+	source := newMemoryKeyEncrypter(t)
+	dest := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, source)
+
+	inDir := t.TempDir()
+	keyNewFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", filepath.Join(inDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// backups kept on overwrite are not rewrapped
+	backupFile := filepath.Join(inDir, "a."+keyFileBackupSuffix())
+	for _, ext := range []string{"", ".seed"} {
+		data, err := os.ReadFile(filepath.Join(inDir, "a"+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(backupFile+ext, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// select fake by backend so that source and destination use different keys
+	newKeyEncrypter = func(_ context.Context, persistentFlags persistentFlagValues) (backend.KeyEncrypter, error) {
+		if persistentFlags.Backend == backend.Aws {
+			return dest, nil
+		}
+		return source, nil
+	}
+
+	keyRewrapFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.InDir, "", "")
+		f.String(flags.OutDir, "", "")
+		f.String(flags.DestBackend, "", "")
+	}
+
+	outDir := filepath.Join(t.TempDir(), "out")
+	out, err := execute(t, KeyRewrap, keyRewrapFlags, "--indir", inDir, "--outdir", outDir, "--dest-backend", backend.Aws)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []rewrappedKey
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("unexpected rewrap output: %s", out)
+	}
+
+	for _, result := range results {
+		account, _, err := readAccountFromKeyFile(context.Background(), dest, result.OutFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.PublicKey.ToBase58() != result.PublicKey {
+			t.Fatalf("rewrapped key %s does not match %s", account.PublicKey.ToBase58(), result.PublicKey)
+		}
+
+		if _, _, err := readAccountFromKeyFile(context.Background(), source, result.OutFile); err == nil {
+			t.Fatal("rewrapped key can still be decrypted using source key")
+		}
+
+		if _, err := os.Stat(result.OutFile + ".seed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// existing output files are never overwritten
+	if _, err := execute(t, KeyRewrap, keyRewrapFlags, "--keyfile", filepath.Join(inDir, "a"), "--outdir", outDir, "--dest-backend", backend.Aws); err == nil {
+		t.Fatal("expected error rewrapping to existing output file")
+	}
+}
@@ -0,0 +1,107 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeyRotate re-encrypts keypair and seed files in place using the current primary
+// version of the KMS key or a named target key. Public key is verified to remain
+// unchanged before files are replaced. In dry run mode it only reports which key
+// version protects each file. Previous files can optionally be kept as a backup.
+func KeyRotate(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.TargetKey, cmd.Flags().Lookup(filepath.Base(flags.TargetKey)))
+	_ = viper.BindPFlag(flags.DryRun, cmd.Flags().Lookup(filepath.Base(flags.DryRun)))
+	_ = viper.BindPFlag(flags.Backup, cmd.Flags().Lookup(filepath.Base(flags.Backup)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	targetKey := viper.GetString(flags.TargetKey)
+	dryRun := viper.GetBool(flags.DryRun)
+	backup := viper.GetBool(flags.Backup)
+
+	keyFile, err := resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+	seedFile := fmt.Sprintf("%s.%s", keyFile, "seed")
+
+	if dryRun {
+		var infos []*keyFileInfo
+		for _, name := range []string{keyFile, seedFile} {
+			info, err := getKeyFileInfo(name)
+			if err != nil {
+				err := fmt.Errorf("could not get key file info: %w", err)
+				return err
+			}
+			infos = append(infos, info)
+		}
+
+		jb, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			err := fmt.Errorf("could not serialize key file info: %w", err)
+			return err
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+
+		return nil
+	}
+
+	sourceKeyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer sourceKeyEncrypter.Close()
+
+	targetKeyEncrypter := sourceKeyEncrypter
+	if len(targetKey) > 0 {
+		targetKeyEncrypter, err = newKeyEncrypter(ctx, withTargetKey(persistentFlags, targetKey))
+		if err != nil {
+			err := fmt.Errorf("could not create target key encrypter: %w", err)
+			return err
+		}
+		defer targetKeyEncrypter.Close()
+	}
+
+	reencrypted, err := reencryptKeyFiles(ctx, sourceKeyEncrypter, targetKeyEncrypter, keyFile)
+	if err != nil {
+		err := fmt.Errorf("could not re-encrypt key files, refusing to rotate: %w", err)
+		return err
+	}
+
+	if err := writeKeyFilePair(keyFile, reencrypted.Keypair, reencrypted.Seed, true, backup); err != nil {
+		err := fmt.Errorf("could not replace key files: %w", err)
+		return err
+	}
+
+	info, err := getKeyFileInfo(keyFile)
+	if err != nil {
+		err := fmt.Errorf("could not get key file info: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(
+		cmd.OutOrStdout(),
+		"rotated %s to key version %s\n",
+		reencrypted.PublicKey,
+		info.KeyVersion,
+	); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
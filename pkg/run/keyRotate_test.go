@@ -0,0 +1,71 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+func TestKeyRotate(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	keyFile := filepath.Join(t.TempDir(), "id")
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}, "--keyfile", keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyRotateFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.TargetKey, "", "")
+		f.Bool(flags.DryRun, false, "")
+	}
+
+	out, err := execute(t, KeyRotate, keyRotateFlags, "--keyfile", keyFile, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []keyFileInfo
+	if err := json.Unmarshal([]byte(out), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].KeyVersion != "memory/1" || infos[1].Role != "seed" {
+		t.Fatalf("unexpected dry run output: %s", out)
+	}
+
+	if _, err := execute(t, KeyRotate, keyRotateFlags, "--keyfile", keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before, after) {
+		t.Fatal("keypair file was not re-encrypted")
+	}
+
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(after), account.PublicKey.ToBase58()) {
+		t.Fatal("rotated envelope does not record public key")
+	}
+}
@@ -2,17 +2,18 @@ package run
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
-	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
 	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
 	"github.com/portto/solana-go-sdk/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	kms2 "google.golang.org/genproto/googleapis/cloud/kms/v1"
-	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // KeyShow decrypts KMS encrypted private keypair file and prints on screen the values
@@ -23,111 +24,146 @@ func KeyShow(cmd *cobra.Command, _ []string) error {
 
 	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
 	_ = viper.BindPFlag(flags.PubKey, cmd.Flags().Lookup(filepath.Base(flags.PubKey)))
+	_ = viper.BindPFlag(flags.Info, cmd.Flags().Lookup(filepath.Base(flags.Info)))
 
 	keyFile := viper.GetString(flags.KeyFile)
 	pubKey := viper.GetBool(flags.PubKey)
+	info := viper.GetBool(flags.Info)
 
-	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
-		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+	keyFile, err := resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
 		return err
 	}
 
-	if len(keyFile) == 0 {
-		if len(persistentFlags.ConfigFile) == 0 {
-			var err error
-			persistentFlags.ConfigFile, err = getDefaultConfigFilename()
-			if err != nil {
-				err := fmt.Errorf("could not get default config filename: %w", err)
-				return err
-			}
+	if info {
+		fileInfo, err := getKeyFileInfo(keyFile)
+		if err != nil {
+			err := fmt.Errorf("could not get key file info: %w", err)
+			return err
 		}
 
-		configValues, err := getConfigValues(persistentFlags.ConfigFile)
+		jb, err := json.MarshalIndent(fileInfo, "", "  ")
 		if err != nil {
-			err := fmt.Errorf("could not get config values: %w", err)
+			err := fmt.Errorf("could not serialize key file info: %w", err)
 			return err
 		}
 
-		if configValues == nil || len(configValues.KeypairPath) == 0 {
-			err := fmt.Errorf("could not find a valid keypair path from config file")
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
 			return err
 		}
 
-		keyFile = configValues.KeypairPath
-	}
-
-	keyFile = removeSchemeFromPath(keyFile)
-
-	kmsClient, err := kms.NewKeyManagementClient(ctx)
-	if err != nil {
-		err := fmt.Errorf("failed to create kms client: %w", err)
-		return err
-	}
-	defer kmsClient.Close()
-
-	ciphertext, err := os.ReadFile(keyFile)
-	if err != nil {
-		err := fmt.Errorf("error reading input keypair file: %w", err)
-		return err
+		return nil
 	}
 
-	var key []byte
-	// try json parsing first and if it fails assume input to be
-	// encrypted
-	if err := json.Unmarshal(ciphertext, &key); err != nil {
-		decryptResponse, err := kmsClient.Decrypt(
-			ctx,
-			&kms2.DecryptRequest{
-				Name: getKmsName(
-					persistentFlags.Project,
-					persistentFlags.Location,
-					persistentFlags.Keyring,
-					persistentFlags.Key,
-				),
-				Ciphertext:                        ciphertext,
-				AdditionalAuthenticatedData:       nil,
-				CiphertextCrc32C:                  wrapperspb.Int64(int64(crc32Sum(ciphertext))),
-				AdditionalAuthenticatedDataCrc32C: nil,
-			},
-		)
+	if pubKey {
+		publicKey, err := readPublicKey(ctx, persistentFlags, keyFile)
 		if err != nil {
-			err := fmt.Errorf("could not decrypt private key: %w", err)
 			return err
 		}
 
-		key = decryptResponse.Plaintext
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), publicKey); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+
+		return nil
 	}
 
-	account, err := types.AccountFromBytes(key)
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
 	if err != nil {
-		err := fmt.Errorf("could not create key pair from decrypted data: %w", err)
+		err := fmt.Errorf("could not create key encrypter: %w", err)
 		return err
 	}
+	defer keyEncrypter.Close()
 
-	if pubKey {
-		if _, err := fmt.Fprintln(cmd.OutOrStdout(), account.PublicKey.ToBase58()); err != nil {
-			err := fmt.Errorf("could not write to cmd output: %w", err)
-			return err
-		}
-
-		return nil
+	account, keyBuffer, err := readAccountFromKeyFile(ctx, keyEncrypter, keyFile)
+	if err != nil {
+		return err
 	}
+	defer keyBuffer.Destroy()
 
 	keyValues := make([]int, len(account.PrivateKey))
+	defer func() {
+		for i := range keyValues {
+			keyValues[i] = 0
+		}
+	}()
 	for i, value := range account.PrivateKey {
 		keyValues[i] = int(value)
 	}
 
 	jb, err := json.Marshal(keyValues)
+	defer secure.Wipe(jb)
 	if err != nil {
 		err := fmt.Errorf("could not serialize private key for displaying as array: %w", err)
 		return err
 	}
 
-	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\n", string(jb)); err != nil {
+	// written as bytes since converting to string would leave a copy of the
+	// private key that cannot be wiped
+	if _, err := cmd.OutOrStdout().Write(jb); err != nil {
+		err := fmt.Errorf("could not print private key to cmd stdout: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout()); err != nil {
 		err := fmt.Errorf("could not print private key to cmd stdout: %w", err)
 		return err
 	}
 
 	return nil
 }
+
+// keyFileInfo describes key file format and how it is protected
+type keyFileInfo struct {
+	KeyFile    string     `json:"keyFile,omitempty"`
+	Format     string     `json:"format,omitempty"`
+	Version    uint8      `json:"version,omitempty"`
+	Backend    string     `json:"backend,omitempty"`
+	KeyVersion string     `json:"keyVersion,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	PublicKey  string     `json:"publicKey,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+}
+
+// getKeyFileInfo reads key file metadata without decrypting it
+func getKeyFileInfo(keyFile string) (*keyFileInfo, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		err := fmt.Errorf("error reading key file: %w", err)
+		return nil, err
+	}
+
+	info := &keyFileInfo{KeyFile: keyFile}
+
+	var key []byte
+	if err := json.Unmarshal(data, &key); err == nil {
+		info.Format = "plaintext"
+		if account, err := types.AccountFromBytes(key); err == nil {
+			info.PublicKey = account.PublicKey.ToBase58()
+		}
+		return info, nil
+	}
+
+	e, err := envelope.Unmarshal(data)
+	if err != nil {
+		if errors.Is(err, envelope.ErrNotEnvelope) {
+			info.Format = "legacy"
+			return info, nil
+		}
+		return nil, err
+	}
+
+	info.Format = "envelope"
+	info.Version = e.Version
+	info.Backend = e.Header.Backend
+	info.KeyVersion = e.Header.KeyVersion
+	info.Role = e.Header.Role
+	info.PublicKey = e.Header.PublicKey
+	if !e.Header.CreatedAt.IsZero() {
+		info.CreatedAt = &e.Header.CreatedAt
+	}
+
+	return info, nil
+}
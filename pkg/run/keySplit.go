@@ -0,0 +1,247 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/kubetrail/solana-kms/pkg/shamir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// seedShareFile describes an encrypted share of seed written to disk
+type seedShareFile struct {
+	Index      int    `json:"index,omitempty"`
+	ShareFile  string `json:"shareFile,omitempty"`
+	KeyVersion string `json:"keyVersion,omitempty"`
+}
+
+// KeySplit decrypts seed and splits it into Shamir shares such that any threshold
+// number of shares can reconstruct it. Shares are either written as files, each
+// optionally encrypted using a different KMS key, or shown as words on terminal.
+func KeySplit(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.SeedFile, cmd.Flags().Lookup(filepath.Base(flags.SeedFile)))
+	_ = viper.BindPFlag(flags.Shares, cmd.Flags().Lookup(filepath.Base(flags.Shares)))
+	_ = viper.BindPFlag(flags.Threshold, cmd.Flags().Lookup(filepath.Base(flags.Threshold)))
+	_ = viper.BindPFlag(flags.ShareKeys, cmd.Flags().Lookup(filepath.Base(flags.ShareKeys)))
+	_ = viper.BindPFlag(flags.OutDir, cmd.Flags().Lookup(filepath.Base(flags.OutDir)))
+	_ = viper.BindPFlag(flags.Mnemonic, cmd.Flags().Lookup(filepath.Base(flags.Mnemonic)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	seedFile := viper.GetString(flags.SeedFile)
+	shares := viper.GetInt(flags.Shares)
+	threshold := viper.GetInt(flags.Threshold)
+	shareKeys := viper.GetStringSlice(flags.ShareKeys)
+	outDir := viper.GetString(flags.OutDir)
+	mnemonic := viper.GetBool(flags.Mnemonic)
+
+	if (len(outDir) > 0) == mnemonic {
+		err := fmt.Errorf("please provide either --%s or --%s", flags.OutDir, flags.Mnemonic)
+		return err
+	}
+
+	if len(shareKeys) > 0 {
+		if mnemonic {
+			err := fmt.Errorf("--%s cannot be used with --%s", flags.ShareKeys, flags.Mnemonic)
+			return err
+		}
+
+		if len(shareKeys) != shares {
+			err := fmt.Errorf("number of --%s must match number of shares %d", flags.ShareKeys, shares)
+			return err
+		}
+	}
+
+	if len(seedFile) == 0 {
+		keyFile, err := resolveKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+		seedFile = fmt.Sprintf("%s.%s", keyFile, seedFileExt)
+	}
+
+	ciphertext, err := os.ReadFile(seedFile)
+	if err != nil {
+		err := fmt.Errorf("could not read seed file: %w", err)
+		return err
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	seedBuffer, e, err := decryptKeyData(ctx, keyEncrypter, ciphertext, envelope.RoleSeed)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt seed: %w", err)
+		return err
+	}
+	defer seedBuffer.Destroy()
+	seed := seedBuffer.Bytes()
+
+	account, keyBuffer, err := accountFromSeed(seed)
+	if err != nil {
+		return err
+	}
+	keyBuffer.Destroy()
+	publicKey := account.PublicKey.ToBase58()
+
+	if e != nil && len(e.Header.PublicKey) > 0 && e.Header.PublicKey != publicKey {
+		err := fmt.Errorf("decrypted seed does not match public key %s recorded in seed file", e.Header.PublicKey)
+		return err
+	}
+
+	splitShares, err := shamir.Split(seed, shares, threshold)
+	if err != nil {
+		err := fmt.Errorf("could not split seed: %w", err)
+		return err
+	}
+	defer func() {
+		for _, share := range splitShares {
+			secure.Wipe(share)
+		}
+	}()
+
+	// verify shares reconstruct the seed before handing them out
+	combined, err := shamir.Combine(splitShares[:threshold])
+	defer secure.Wipe(combined)
+	if err != nil || !bytes.Equal(combined, seed) {
+		err := fmt.Errorf("could not verify seed shares")
+		return err
+	}
+
+	if mnemonic {
+		parts := [][]byte{[]byte(fmt.Sprintf(
+			"pubkey: %s\nAny %d of the following %d shares can recover the seed, they will not be shown again:\n",
+			publicKey,
+			threshold,
+			shares,
+		))}
+		for i, share := range splitShares {
+			encoded := encodeShare(threshold, share)
+			words, err := shareToWords(encoded)
+			secure.Wipe(encoded)
+			if err != nil {
+				return err
+			}
+			defer words.Destroy()
+
+			parts = append(parts, []byte(fmt.Sprintf("\nshare %d of %d:\n", i+1, shares)), words.Bytes(), []byte("\n"))
+		}
+
+		message, err := joinSecret(parts...)
+		if err != nil {
+			return err
+		}
+		defer message.Destroy()
+
+		if err := writeToTerminal(message.Bytes()); err != nil {
+			err := fmt.Errorf("could not display shares: %w", err)
+			return err
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), publicKey); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+
+		return nil
+	}
+
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		err := fmt.Errorf("could not create output dir: %w", err)
+		return err
+	}
+
+	shareFiles := make([]*seedShareFile, 0, shares)
+	for i, share := range splitShares {
+		shareKeyEncrypter := keyEncrypter
+		if len(shareKeys) > 0 {
+			shareKeyEncrypter, err = newKeyEncrypter(ctx, withTargetKey(persistentFlags, shareKeys[i]))
+			if err != nil {
+				err := fmt.Errorf("could not create key encrypter for share %d: %w", i+1, err)
+				return err
+			}
+		}
+
+		shareFile, err := writeShareFile(ctx, shareKeyEncrypter, publicKey, threshold, share, outDir)
+		if shareKeyEncrypter != keyEncrypter {
+			_ = shareKeyEncrypter.Close()
+		}
+		if err != nil {
+			err := fmt.Errorf("could not write share %d: %w", i+1, err)
+			return err
+		}
+
+		shareFiles = append(shareFiles, shareFile)
+	}
+
+	jb, err := json.MarshalIndent(shareFiles, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize share files: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// writeShareFile encrypts share and writes it to outDir in a file named after
+// public key and share index. Existing files are not overwritten.
+func writeShareFile(
+	ctx context.Context,
+	keyEncrypter backend.KeyEncrypter,
+	publicKey string,
+	threshold int,
+	share []byte,
+	outDir string,
+) (*seedShareFile, error) {
+	encoded := encodeShare(threshold, share)
+	defer secure.Wipe(encoded)
+
+	ciphertext, err := encryptKeyData(ctx, keyEncrypter, encoded, envelope.RoleShare, publicKey)
+	if err != nil {
+		err := fmt.Errorf("could not encrypt share: %w", err)
+		return nil, err
+	}
+
+	shareFile := filepath.Join(outDir, fmt.Sprintf("%s.share-%d", publicKey, share[0]))
+	if err := atomicCreateFile(shareFile, ciphertext, 0400); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			err := fmt.Errorf("share file %s already exists", shareFile)
+			return nil, err
+		}
+		return nil, err
+	}
+
+	info, err := getKeyFileInfo(shareFile)
+	if err != nil {
+		err := fmt.Errorf("could not get share file info: %w", err)
+		return nil, err
+	}
+
+	return &seedShareFile{
+		Index:      int(share[0]),
+		ShareFile:  shareFile,
+		KeyVersion: info.KeyVersion,
+	}, nil
+}
@@ -0,0 +1,246 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// verifiedKey reports outcome of verifying a single keypair and seed file pair
+type verifiedKey struct {
+	KeyFile   string `json:"keyFile,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+	Verified  bool   `json:"verified"`
+	Error     string `json:"error,omitempty"`
+}
+
+// KeyVerify decrypts keypair and seed files and verifies that the keypair derived
+// from the seed matches the stored keypair and, optionally, an expected public key.
+// Integrity of ciphertext is checked via envelope and KMS checksums along the way.
+// Command fails if any key file does not verify.
+func KeyVerify(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.InDir, cmd.Flags().Lookup(filepath.Base(flags.InDir)))
+	_ = viper.BindPFlag(flags.PubKey, cmd.Flags().Lookup(filepath.Base(flags.PubKey)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	inDir := viper.GetString(flags.InDir)
+	pubKey := viper.GetString(flags.PubKey)
+
+	if len(inDir) > 0 && (len(keyFile) > 0 || len(pubKey) > 0) {
+		err := fmt.Errorf("--%s cannot be used with --%s or --%s", flags.InDir, flags.KeyFile, flags.PubKey)
+		return err
+	}
+
+	var keyFiles []string
+	if len(inDir) > 0 {
+		var err error
+		keyFiles, err = listAuditKeyFiles(inDir)
+		if err != nil {
+			return err
+		}
+
+		if len(keyFiles) == 0 {
+			err := fmt.Errorf("no keypair files found in %s", inDir)
+			return err
+		}
+	} else {
+		var err error
+		keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+		keyFiles = []string{keyFile}
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return err
+	}
+	defer keyEncrypter.Close()
+
+	var failed []*verifiedKey
+	results := make([]*verifiedKey, 0, len(keyFiles))
+	for _, keyFile := range keyFiles {
+		result := &verifiedKey{KeyFile: keyFile}
+		results = append(results, result)
+
+		publicKey, err := verifyKeyFiles(ctx, persistentFlags, keyEncrypter, keyFile, pubKey)
+		result.PublicKey = publicKey
+		if err != nil {
+			result.Error = err.Error()
+			failed = append(failed, result)
+			continue
+		}
+		result.Verified = true
+	}
+
+	jb, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize verification results: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(results) == 1:
+		err := fmt.Errorf("key file %s failed verification: %s", failed[0].KeyFile, failed[0].Error)
+		return err
+	default:
+		err := fmt.Errorf("%d of %d key files failed verification", len(failed), len(results))
+		return err
+	}
+}
+
+// verifyKeyFiles verifies keypair file and its seed file and returns public
+// key of the keypair, when known, along with the reason for a failure. Files
+// referencing KMS native signing keys have no seed file and are verified by
+// signing a test message in KMS instead.
+func verifyKeyFiles(
+	ctx context.Context,
+	persistentFlags persistentFlagValues,
+	keyEncrypter backend.KeyEncrypter,
+	keyFile, expectedPublicKey string,
+) (string, error) {
+	header, err := readSigningKeyHeader(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	if header != nil {
+		return verifySigningKeyFile(ctx, persistentFlags, header, expectedPublicKey)
+	}
+
+	account, keyBuffer, err := readAccountFromKeyFile(ctx, keyEncrypter, keyFile)
+	if err != nil {
+		return "", err
+	}
+	defer keyBuffer.Destroy()
+	publicKey := account.PublicKey.ToBase58()
+
+	seedCiphertext, err := os.ReadFile(fmt.Sprintf("%s.%s", keyFile, seedFileExt))
+	if err != nil {
+		err := fmt.Errorf("could not read seed file: %w", err)
+		return publicKey, err
+	}
+
+	seed, e, err := decryptKeyData(ctx, keyEncrypter, seedCiphertext, envelope.RoleSeed)
+	if err != nil {
+		err := fmt.Errorf("could not decrypt seed: %w", err)
+		return publicKey, err
+	}
+	defer seed.Destroy()
+
+	if e != nil && len(e.Header.PublicKey) > 0 && e.Header.PublicKey != publicKey {
+		err := fmt.Errorf("seed file records public key %s, keypair is %s", e.Header.PublicKey, publicKey)
+		return publicKey, err
+	}
+
+	seedAccount, seedKeyBuffer, err := accountFromSeed(seed.Bytes())
+	if err != nil {
+		return publicKey, err
+	}
+	defer seedKeyBuffer.Destroy()
+
+	if !bytes.Equal(seedAccount.PrivateKey, account.PrivateKey) {
+		err := fmt.Errorf(
+			"keypair derived from seed has public key %s, keypair is %s",
+			seedAccount.PublicKey.ToBase58(),
+			publicKey,
+		)
+		return publicKey, err
+	}
+
+	if len(expectedPublicKey) > 0 && expectedPublicKey != publicKey {
+		err := fmt.Errorf("public key %s does not match expected %s", publicKey, expectedPublicKey)
+		return publicKey, err
+	}
+
+	return publicKey, nil
+}
+
+// verifySigningKeyFile verifies that KMS native signing key recorded in key
+// file header exists, matches recorded public key and produces valid signatures
+func verifySigningKeyFile(
+	ctx context.Context,
+	persistentFlags persistentFlagValues,
+	header *envelope.Header,
+	expectedPublicKey string,
+) (string, error) {
+	publicKey := header.PublicKey
+
+	kmsKeySigner, err := newKmsSignerFromHeader(ctx, persistentFlags, header)
+	if err != nil {
+		return publicKey, err
+	}
+	defer kmsKeySigner.Close()
+
+	message := []byte("solana-kms key verify")
+	signature, err := kmsKeySigner.Sign(ctx, message)
+	if err != nil {
+		err := fmt.Errorf("could not sign test message: %w", err)
+		return publicKey, err
+	}
+
+	if !ed25519.Verify(kmsKeySigner.PublicKey().Bytes(), message, signature) {
+		err := fmt.Errorf("signature of kms key version %s does not verify", header.KeyVersion)
+		return publicKey, err
+	}
+
+	if len(expectedPublicKey) > 0 && expectedPublicKey != publicKey {
+		err := fmt.Errorf("public key %s does not match expected %s", publicKey, expectedPublicKey)
+		return publicKey, err
+	}
+
+	return publicKey, nil
+}
+
+// listAuditKeyFiles returns keypair files in dir. Unlike listKeyFiles, files
+// without a matching seed file are included so that they are reported.
+func listAuditKeyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		err := fmt.Errorf("could not read input dir: %w", err)
+		return nil, err
+	}
+
+	var keyFiles []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || validateKeyName(name) != nil {
+			continue
+		}
+
+		// skip files of other roles such as Shamir shares, however, include
+		// unreadable files so that the reason is reported during verification
+		keyFile := filepath.Join(dir, name)
+		if info, err := getKeyFileInfo(keyFile); err == nil &&
+			len(info.Role) > 0 && info.Role != envelope.RoleKeypair && info.Role != envelope.RoleSigningKey {
+			continue
+		}
+
+		keyFiles = append(keyFiles, keyFile)
+	}
+
+	return keyFiles, nil
+}
@@ -0,0 +1,100 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func TestKeyVerify(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	dir := t.TempDir()
+
+	for _, name := range []string{"a", "b"} {
+		if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+			f.String(flags.KeyFile, "", "")
+			f.String(flags.SeedFile, "", "")
+		}, "--keyfile", filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keyVerifyFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.InDir, "", "")
+		f.String(flags.PubKey, "", "")
+	}
+
+	if _, err := execute(t, KeyVerify, keyVerifyFlags, "--indir", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, KeyVerify, keyVerifyFlags,
+		"--keyfile", filepath.Join(dir, "a"), "--pubkey", account.PublicKey.ToBase58()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = execute(t, KeyVerify, keyVerifyFlags,
+		"--keyfile", filepath.Join(dir, "a"), "--pubkey", types.NewAccount().PublicKey.ToBase58())
+	if err == nil || !strings.Contains(err.Error(), "does not match expected") {
+		t.Fatalf("expected public key mismatch error, got %v", err)
+	}
+
+	// seed files drifted apart after manual copying
+	seed, err := os.ReadFile(filepath.Join(dir, "b.seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "a.seed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.seed"), seed, 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = execute(t, KeyVerify, keyVerifyFlags, "--keyfile", filepath.Join(dir, "a"))
+	if err == nil || !strings.Contains(err.Error(), "seed file records public key") {
+		t.Fatalf("expected seed mismatch error, got %v", err)
+	}
+
+	// legacy seed file without metadata is checked by deriving the keypair
+	ciphertext, err := keyEncrypter.Encrypt(context.Background(), types.NewAccount().PrivateKey.Seed(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "a.seed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.seed"), ciphertext.Data, 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = execute(t, KeyVerify, keyVerifyFlags, "--keyfile", filepath.Join(dir, "a"))
+	if err == nil || !strings.Contains(err.Error(), "keypair derived from seed") {
+		t.Fatalf("expected derived keypair mismatch error, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "b.seed")); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execute(t, KeyVerify, keyVerifyFlags, "--indir", dir)
+	if err == nil || !strings.Contains(err.Error(), "2 of 2") {
+		t.Fatalf("expected directory audit to fail for both keys, got %v", err)
+	}
+	if !strings.Contains(out, "could not read seed file") {
+		t.Fatalf("expected missing seed file to be reported: %s", out)
+	}
+}
@@ -0,0 +1,231 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	seedFileExt     = "seed" // extension of seed file accompanying keypair file
+	metadataFileExt = "meta" // extension of sidecar file holding key metadata
+	backupFileExt   = "bak"  // extension of timestamped backup of overwritten key file
+)
+
+// keyMetadata is stored in plaintext next to a named key in keystore and
+// holds information that is not part of the envelope header
+type keyMetadata struct {
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// keystoreEntry describes a named key in keystore
+type keystoreEntry struct {
+	Name       string            `json:"name,omitempty"`
+	PublicKey  string            `json:"publicKey,omitempty"`
+	Backend    string            `json:"backend,omitempty"`
+	KeyVersion string            `json:"keyVersion,omitempty"`
+	Format     string            `json:"format,omitempty"`
+	Role       string            `json:"role,omitempty"`
+	CreatedAt  *time.Time        `json:"createdAt,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// getDefaultKeystoreDir retrieves default keystore dir
+func getDefaultKeystoreDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		err := fmt.Errorf("could not get user home dir: %w", err)
+		return "", err
+	}
+
+	return filepath.Join(homeDir, ".config", "solana-kms", "keys"), nil
+}
+
+// getKeystoreDir returns keystore dir from persistent flags or the default one
+func getKeystoreDir(persistentFlags persistentFlagValues) (string, error) {
+	if len(persistentFlags.Keystore) > 0 {
+		return persistentFlags.Keystore, nil
+	}
+
+	return getDefaultKeystoreDir()
+}
+
+// getKeystoreFile returns keypair file path of the named key in keystore.
+// Keystore dir is created when create is set so that new keys can be written,
+// otherwise a missing keystore dir is reported.
+func getKeystoreFile(persistentFlags persistentFlagValues, create bool) (string, error) {
+	if err := validateKeyName(persistentFlags.KeyName); err != nil {
+		return "", err
+	}
+
+	dir, err := getKeystoreDir(persistentFlags)
+	if err != nil {
+		return "", err
+	}
+
+	if create {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			err := fmt.Errorf("could not create keystore dir: %w", err)
+			return "", err
+		}
+	} else if err := checkKeystoreDir(dir); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, persistentFlags.KeyName), nil
+}
+
+// checkKeystoreDir reports keystore dir that does not exist, which is likely
+// a mistyped --keystore rather than an empty keystore
+func checkKeystoreDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err := fmt.Errorf("keystore dir %s does not exist", dir)
+			return err
+		}
+		err := fmt.Errorf("could not access keystore dir: %w", err)
+		return err
+	}
+
+	if !info.IsDir() {
+		err := fmt.Errorf("keystore %s is not a directory", dir)
+		return err
+	}
+
+	return nil
+}
+
+// validateKeyName ensures key name can be used as a file name in keystore
+// without escaping it or colliding with seed, metadata and backup files.
+// Listings use it to skip files that are not keys.
+func validateKeyName(name string) error {
+	switch {
+	case len(name) == 0:
+		return fmt.Errorf("key name cannot be empty")
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("key name cannot start with a dot: %q", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("key name cannot contain path separators: %q", name)
+	case strings.HasSuffix(name, "."+seedFileExt), strings.HasSuffix(name, "."+metadataFileExt):
+		return fmt.Errorf("key name cannot end with .%s or .%s: %q", seedFileExt, metadataFileExt, name)
+	case strings.Contains(name, "."+backupFileExt+"-"):
+		return fmt.Errorf("key name cannot contain .%s-, which marks backups: %q", backupFileExt, name)
+	}
+
+	return nil
+}
+
+// parseLabels parses labels provided as key=value pairs
+func parseLabels(input []string) (map[string]string, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	labels := make(map[string]string, len(input))
+	for _, label := range input {
+		kv := strings.SplitN(label, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			err := fmt.Errorf("invalid label %q, expected key=value", label)
+			return nil, err
+		}
+		labels[kv[0]] = kv[1]
+	}
+
+	return labels, nil
+}
+
+// writeKeyMetadata writes metadata sidecar file for keyFile
+func writeKeyMetadata(keyFile string, metadata *keyMetadata) error {
+	jb, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize key metadata: %w", err)
+		return err
+	}
+
+	if err := atomicWriteFile(fmt.Sprintf("%s.%s", keyFile, metadataFileExt), jb, 0600); err != nil {
+		err := fmt.Errorf("could not write key metadata: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// readKeyMetadata reads metadata sidecar file for keyFile. Empty metadata
+// is returned if sidecar file does not exist.
+func readKeyMetadata(keyFile string) (*keyMetadata, error) {
+	metadata := &keyMetadata{}
+
+	jb, err := os.ReadFile(fmt.Sprintf("%s.%s", keyFile, metadataFileExt))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return metadata, nil
+		}
+		err := fmt.Errorf("could not read key metadata: %w", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(jb, metadata); err != nil {
+		err := fmt.Errorf("could not parse key metadata: %w", err)
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// listKeystore returns entries for all named keys in keystore dir sorted by
+// name. Key files are not decrypted.
+func listKeystore(dir string) ([]*keystoreEntry, error) {
+	if err := checkKeystoreDir(dir); err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		err := fmt.Errorf("could not read keystore dir: %w", err)
+		return nil, err
+	}
+
+	var entries []*keystoreEntry
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if !dirEntry.Type().IsRegular() || validateKeyName(name) != nil {
+			continue
+		}
+
+		keyFile := filepath.Join(dir, name)
+		info, err := getKeyFileInfo(keyFile)
+		if err != nil {
+			err := fmt.Errorf("could not get key file info for %s: %w", name, err)
+			return nil, err
+		}
+
+		metadata, err := readKeyMetadata(keyFile)
+		if err != nil {
+			err := fmt.Errorf("could not get metadata for %s: %w", name, err)
+			return nil, err
+		}
+
+		entries = append(entries, &keystoreEntry{
+			Name:       name,
+			PublicKey:  info.PublicKey,
+			Backend:    info.Backend,
+			KeyVersion: info.KeyVersion,
+			Format:     info.Format,
+			Role:       info.Role,
+			CreatedAt:  info.CreatedAt,
+			Labels:     metadata.Labels,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries, nil
+}
@@ -0,0 +1,166 @@
+package run
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+func TestValidateKeyName(t *testing.T) {
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", "key.seed", "key.meta", "key.bak-20060102T150405Z"} {
+		if err := validateKeyName(name); err == nil {
+			t.Fatalf("expected error for key name %q", name)
+		}
+	}
+
+	if err := validateKeyName("treasury-1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeystoreNewAndList(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keystore := t.TempDir()
+
+	keyNewFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+		f.StringSlice(flags.Label, nil, "")
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags,
+		"--keystore", keystore, "--key-name", "treasury", "--label", "team=payments", "--label", "env=prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keystore", keystore, "--key-name", "hot"); err != nil {
+		t.Fatal(err)
+	}
+
+	// named keys are never overwritten
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keystore", keystore, "--key-name", "hot"); err == nil {
+		t.Fatal("expected error creating existing named key")
+	}
+
+	pubKey, err := execute(t, KeyShow, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.PubKey, false, "")
+		f.Bool(flags.Info, false, "")
+	}, "--keystore", keystore, "--key-name", "treasury", "--pubkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execute(t, KeyList, func(*pflag.FlagSet) {}, "--keystore", keystore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []keystoreEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Name != "hot" || entries[1].Name != "treasury" {
+		t.Fatalf("unexpected keystore entries: %s", out)
+	}
+
+	treasury := entries[1]
+	if treasury.PublicKey != strings.TrimSpace(pubKey) ||
+		treasury.Backend != "memory" ||
+		treasury.KeyVersion != "memory/1" ||
+		treasury.CreatedAt == nil ||
+		treasury.Labels["team"] != "payments" ||
+		treasury.Labels["env"] != "prod" {
+		t.Fatalf("unexpected keystore entry: %s", out)
+	}
+}
+
+func TestKeystoreListSkipsBackups(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keystore := t.TempDir()
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}, "--keystore", keystore, "--key-name", "treasury"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, KeyRotate, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.TargetKey, "", "")
+		f.Bool(flags.DryRun, false, "")
+		f.Bool(flags.Backup, false, "")
+	}, "--keystore", keystore, "--key-name", "treasury", "--backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(keystore, "treasury."+backupFileExt+"-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected backup of key and seed files, got %v", backups)
+	}
+
+	out, err := execute(t, KeyList, func(*pflag.FlagSet) {}, "--keystore", keystore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []keystoreEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "treasury" {
+		t.Fatalf("expected backups not to be listed: %s", out)
+	}
+
+	keyFiles, err := listAuditKeyFiles(keystore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyFiles) != 1 || filepath.Base(keyFiles[0]) != "treasury" {
+		t.Fatalf("expected backups not to be audited, got %v", keyFiles)
+	}
+}
+
+func TestKeystoreReadPathsDoNotCreateDir(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keystore := filepath.Join(t.TempDir(), "mistyped")
+
+	if _, err := execute(t, KeyShow, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.PubKey, false, "")
+		f.Bool(flags.Info, false, "")
+	}, "--keystore", keystore, "--key-name", "treasury", "--pubkey"); err == nil ||
+		!strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing keystore to be reported, got %v", err)
+	}
+
+	if _, err := execute(t, KeyList, func(*pflag.FlagSet) {}, "--keystore", keystore); err == nil ||
+		!strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing keystore to be reported, got %v", err)
+	}
+
+	if _, err := os.Stat(keystore); !os.IsNotExist(err) {
+		t.Fatalf("expected keystore dir not to be created, got %v", err)
+	}
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}, "--keystore", keystore, "--key-name", "treasury"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(keystore, "treasury")); err != nil {
+		t.Fatalf("expected key to be written to new keystore dir: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package run
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// maxWordLength is the length of the longest word in BIP39 english wordlist
+const maxWordLength = 8
+
+// wordIndexes maps BIP39 english words to their position in the wordlist
+var wordIndexes = func() map[string]int {
+	wordList := bip39.GetWordList()
+	indexes := make(map[string]int, len(wordList))
+	for i, word := range wordList {
+		indexes[word] = i
+	}
+	return indexes
+}()
+
+// newMnemonic generates a new BIP39 mnemonic with given number of words.
+// Mnemonic is held in the returned secure buffer, which caller must destroy.
+func newMnemonic(words int) (*secure.Buffer, error) {
+	switch words {
+	case 12, 15, 18, 21, 24:
+	default:
+		err := fmt.Errorf("invalid number of mnemonic words %d, allowed values are 12, 15, 18, 21 or 24", words)
+		return nil, err
+	}
+
+	// each word encodes 11 bits, of which entropy makes 32 out of every 33 bits
+	entropy, err := secure.NewBuffer(words / 3 * 4)
+	if err != nil {
+		return nil, err
+	}
+	defer entropy.Destroy()
+
+	if _, err := rand.Read(entropy.Bytes()); err != nil {
+		err := fmt.Errorf("could not generate entropy: %w", err)
+		return nil, err
+	}
+
+	return entropyToMnemonic(entropy.Bytes())
+}
+
+// entropyToMnemonic encodes entropy followed by the leading bits of its SHA256
+// checksum as BIP39 words. Mnemonic is held in the returned secure buffer,
+// which caller must destroy.
+func entropyToMnemonic(entropy []byte) (*secure.Buffer, error) {
+	sum := sha256.Sum256(entropy)
+	defer secure.Wipe(sum[:])
+
+	entropyBits := len(entropy) * 8
+	bit := func(i int) int {
+		if i < entropyBits {
+			return int(entropy[i/8]>>(7-i%8)) & 1
+		}
+		i -= entropyBits
+		return int(sum[i/8]>>(7-i%8)) & 1
+	}
+
+	indexes := make([]int, (entropyBits+entropyBits/32)/11)
+	defer wipeIndexes(indexes)
+	for i := 0; i < len(indexes)*11; i++ {
+		indexes[i/11] |= bit(i) << (10 - i%11)
+	}
+
+	return joinWords(indexes)
+}
+
+// seedFromMnemonic validates mnemonic and returns 64 byte BIP39 seed. Words are
+// matched ignoring case and whitespace between them.
+func seedFromMnemonic(mnemonic, passphrase []byte) ([]byte, error) {
+	indexes, err := splitWords(mnemonic)
+	defer wipeIndexes(indexes)
+	if err != nil || !isMnemonicValid(indexes) {
+		err := fmt.Errorf("invalid mnemonic, please check words and their order")
+		return nil, err
+	}
+
+	// seed is derived from normalized mnemonic
+	normalized, err := joinWords(indexes)
+	if err != nil {
+		return nil, err
+	}
+	defer normalized.Destroy()
+
+	salt, err := secure.NewBuffer(len("mnemonic") + len(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	defer salt.Destroy()
+	copy(salt.Bytes()[copy(salt.Bytes(), "mnemonic"):], passphrase)
+
+	return pbkdf2.Key(normalized.Bytes(), salt.Bytes(), 2048, 64, sha512.New), nil
+}
+
+// isMnemonicValid checks number of words and checksum of mnemonic given by
+// its word indexes
+func isMnemonicValid(indexes []int) bool {
+	switch len(indexes) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return false
+	}
+
+	entropy, err := secure.NewBuffer(len(indexes) / 3 * 4)
+	if err != nil {
+		return false
+	}
+	defer entropy.Destroy()
+
+	bit := func(i int) int {
+		return indexes[i/11] >> (10 - i%11) & 1
+	}
+
+	data := entropy.Bytes()
+	for i := 0; i < len(data)*8; i++ {
+		data[i/8] |= byte(bit(i) << (7 - i%8))
+	}
+
+	sum := sha256.Sum256(data)
+	defer secure.Wipe(sum[:])
+
+	for i := 0; i < len(indexes)/3; i++ {
+		if bit(len(data)*8+i) != int(sum[0]>>(7-i))&1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitWords looks up whitespace separated BIP39 words of input ignoring case.
+// Returned indexes identify secret input and should be wiped by caller.
+func splitWords(input []byte) ([]int, error) {
+	fields := bytes.Fields(input)
+	indexes := make([]int, 0, len(fields))
+	for i, field := range fields {
+		index, ok := wordIndex(field)
+		if !ok {
+			err := fmt.Errorf("word %d is not in BIP39 english wordlist", i+1)
+			return indexes, err
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// wordIndex returns position of word in BIP39 english wordlist ignoring case.
+// Word is lowercased on the stack, so no copy of it is left on the heap.
+func wordIndex(word []byte) (int, bool) {
+	if len(word) > maxWordLength {
+		return 0, false
+	}
+
+	var lower [maxWordLength]byte
+	defer secure.Wipe(lower[:])
+	for i, c := range word {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+
+	index, ok := wordIndexes[string(lower[:len(word)])]
+	return index, ok
+}
+
+// joinWords joins BIP39 english words at indexes using a single space into a
+// secure buffer, which caller must destroy
+func joinWords(indexes []int) (*secure.Buffer, error) {
+	wordList := bip39.GetWordList()
+
+	size := 0
+	for i, index := range indexes {
+		if i > 0 {
+			size++
+		}
+		size += len(wordList[index])
+	}
+
+	buffer, err := secure.NewBuffer(size)
+	if err != nil {
+		return nil, err
+	}
+
+	data := buffer.Bytes()[:0]
+	for i, index := range indexes {
+		if i > 0 {
+			data = append(data, ' ')
+		}
+		data = append(data, wordList[index]...)
+	}
+
+	return buffer, nil
+}
+
+// wipeIndexes overwrites word indexes with zeros
+func wipeIndexes(indexes []int) {
+	for i := range indexes {
+		indexes[i] = 0
+	}
+}
@@ -0,0 +1,154 @@
+package run
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+// TestSeedFromMnemonic checks against BIP39 reference test vector
+func TestSeedFromMnemonic(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+
+	seed, err := seedFromMnemonic([]byte(strings.ToUpper("  "+mnemonic)), []byte("TREZOR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(seed) != expected {
+		t.Fatalf("unexpected seed %x", seed)
+	}
+
+	if _, err := seedFromMnemonic([]byte(strings.Replace(mnemonic, "about", "abandon", 1)), nil); err == nil {
+		t.Fatal("expected error for mnemonic with invalid checksum")
+	}
+}
+
+// TestEntropyToMnemonic checks against BIP39 reference test vectors
+func TestEntropyToMnemonic(t *testing.T) {
+	for entropy, expected := range map[string]string{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f":                                 "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff": "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+	} {
+		data, err := hex.DecodeString(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mnemonic, err := entropyToMnemonic(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(mnemonic.Bytes()) != expected {
+			t.Fatalf("unexpected mnemonic %q", mnemonic.Bytes())
+		}
+		mnemonic.Destroy()
+	}
+}
+
+func TestKeyNewMnemonicAndRecover(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	dir := t.TempDir()
+
+	var terminal string
+	origWriteToTerminal, origReadSecret := writeToTerminal, readSecret
+	t.Cleanup(func() { writeToTerminal, readSecret = origWriteToTerminal, origReadSecret })
+	writeToTerminal = func(message []byte) error {
+		terminal += string(message)
+		return nil
+	}
+	readSecret = func(prompt string) ([]byte, error) {
+		if strings.Contains(prompt, "BIP39 passphrase") {
+			return []byte("extra words"), nil
+		}
+		lines := strings.Split(strings.TrimSpace(terminal), "\n")
+		return []byte(lines[len(lines)-1]), nil
+	}
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+		f.Bool(flags.Mnemonic, false, "")
+		f.Int(flags.MnemonicWords, 12, "")
+		f.Bool(flags.MnemonicPassphrase, false, "")
+	}, "--keyfile", filepath.Join(dir, "id"), "--mnemonic", "--mnemonic-words", "24", "--mnemonic-passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey := regexp.MustCompile(`pubkey: (\w+)`).FindStringSubmatch(terminal)
+	if len(pubKey) != 2 {
+		t.Fatalf("public key not shown on terminal: %q", terminal)
+	}
+
+	lines := strings.Split(strings.TrimSpace(terminal), "\n")
+	if words := strings.Fields(lines[len(lines)-1]); len(words) != 24 {
+		t.Fatalf("expected 24 words, got %d", len(words))
+	}
+
+	recovered, err := execute(t, KeyRecover, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.MnemonicPassphrase, false, "")
+	}, "--keyfile", filepath.Join(dir, "recovered"), "--mnemonic-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(recovered) != pubKey[1] {
+		t.Fatalf("recovered public key %q does not match %q", recovered, pubKey[1])
+	}
+}
+
+func TestKeyNewMnemonicRefusesExistingFile(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, _ := newAgentKeyFile(t)
+
+	var terminal string
+	origWriteToTerminal := writeToTerminal
+	t.Cleanup(func() { writeToTerminal = origWriteToTerminal })
+	writeToTerminal = func(message []byte) error {
+		terminal += string(message)
+		return nil
+	}
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+		f.Bool(flags.Mnemonic, false, "")
+		f.Int(flags.MnemonicWords, 12, "")
+		f.Bool(flags.MnemonicPassphrase, false, "")
+		f.Bool(flags.Force, false, "")
+	}, "--keyfile", keyFile, "--mnemonic"); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("expected existing key file to be refused, got %v", err)
+	}
+
+	if len(terminal) > 0 {
+		t.Fatalf("expected nothing to be shown on terminal, got %q", terminal)
+	}
+}
+
+func TestKeyRecoverRefusesExistingFile(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, _ := newAgentKeyFile(t)
+
+	origReadSecret := readSecret
+	t.Cleanup(func() { readSecret = origReadSecret })
+	readSecret = func(prompt string) ([]byte, error) {
+		t.Fatalf("expected no prompt, got %q", prompt)
+		return nil, nil
+	}
+
+	if _, err := execute(t, KeyRecover, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.MnemonicPassphrase, false, "")
+		f.Bool(flags.Force, false, "")
+	}, "--keyfile", keyFile); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("expected existing key file to be refused, got %v", err)
+	}
+}
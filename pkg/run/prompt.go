@@ -0,0 +1,117 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"golang.org/x/term"
+)
+
+// readSecret prompts on the controlling terminal and reads input without echo.
+// Terminal is opened directly so that stdin and stdout remain free for piping.
+// It is declared as a variable so that tests can supply input non-interactively.
+var readSecret = func(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		err := fmt.Errorf("could not open terminal, secret input requires a tty: %w", err)
+		return nil, err
+	}
+	defer tty.Close()
+
+	if _, err := fmt.Fprint(tty, prompt); err != nil {
+		err := fmt.Errorf("could not write to terminal: %w", err)
+		return nil, err
+	}
+
+	secret, err := term.ReadPassword(int(tty.Fd()))
+	_, _ = fmt.Fprintln(tty)
+	if err != nil {
+		err := fmt.Errorf("could not read from terminal: %w", err)
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// writeToTerminal writes message directly to the controlling terminal so that
+// it is shown to the user but never ends up in piped or redirected output.
+// Message is taken as bytes so that secrets shown to the user can be wiped.
+// It is declared as a variable so that tests can capture output.
+var writeToTerminal = func(message []byte) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		err := fmt.Errorf("could not open terminal: %w", err)
+		return err
+	}
+	defer tty.Close()
+
+	if _, err := tty.Write(message); err != nil {
+		err := fmt.Errorf("could not write to terminal: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// joinSecret concatenates parts of a message into a secure buffer, which
+// caller must destroy, so that secret parts are not copied to the heap
+func joinSecret(parts ...[]byte) (*secure.Buffer, error) {
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+
+	buffer, err := secure.NewBuffer(size)
+	if err != nil {
+		return nil, err
+	}
+
+	data := buffer.Bytes()[:0]
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+
+	return buffer, nil
+}
+
+// readMnemonicPassphrase reads optional BIP39 passphrase from terminal
+// asking for it twice when confirm is true
+func readMnemonicPassphrase(confirm bool) ([]byte, error) {
+	return readConfirmedSecret("BIP39 passphrase", confirm)
+}
+
+// readPassphrase reads passphrase from terminal asking for it twice
+// when confirm is true
+func readPassphrase(confirm bool) ([]byte, error) {
+	return readConfirmedSecret("passphrase", confirm)
+}
+
+// readConfirmedSecret reads secret described by name from terminal asking
+// for it twice when confirm is true
+func readConfirmedSecret(name string, confirm bool) ([]byte, error) {
+	secret, err := readSecret(fmt.Sprintf("Enter %s: ", name))
+	if err != nil {
+		return nil, err
+	}
+
+	if !confirm {
+		return secret, nil
+	}
+
+	again, err := readSecret(fmt.Sprintf("Confirm %s: ", name))
+	if err != nil {
+		secure.Wipe(secret)
+		return nil, err
+	}
+	defer secure.Wipe(again)
+
+	if !bytes.Equal(secret, again) {
+		secure.Wipe(secret)
+		err := fmt.Errorf("%ss do not match", name)
+		return nil, err
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,523 @@
+package run
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/portto/solana-go-sdk/client"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/rpc"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+// defaultCommitment is used when Solana config does not set one, which matches
+// default of Solana CLI
+const defaultCommitment = rpc.CommitmentConfirmed
+
+// confirmationPollInterval is how often signature status is polled while
+// waiting for confirmation. It is declared as a variable so that tests can
+// shorten it.
+var confirmationPollInterval = time.Second
+
+// rpcSettings are RPC related settings resolved from flags and Solana config
+type rpcSettings struct {
+	endpoint      string
+	commitment    rpc.Commitment
+	addressLabels map[string]string
+}
+
+// getRpcSettings returns RPC endpoint from url flag or moniker, falling back to
+// Solana config, along with commitment level and address labels from Solana
+// config. Config file is only required when url is not provided.
+func getRpcSettings(url string, persistentFlags persistentFlagValues) (*rpcSettings, error) {
+	var err error
+	if len(persistentFlags.ConfigFile) == 0 {
+		persistentFlags.ConfigFile, err = getDefaultConfigFilename()
+		if err != nil {
+			err := fmt.Errorf("could not get default config filename: %w", err)
+			return nil, err
+		}
+	}
+
+	configValues, err := getConfigValues(persistentFlags.ConfigFile)
+	if err != nil {
+		if len(url) == 0 || !errors.Is(err, os.ErrNotExist) {
+			err := fmt.Errorf("could not get config values: %w", err)
+			return nil, err
+		}
+		configValues = &config{}
+	}
+
+	endpoint := getEndpointFromUrlOrMoniker(url, configValues)
+	if len(endpoint) == 0 {
+		err := fmt.Errorf("could not find a valid json rpc url from config file")
+		return nil, err
+	}
+
+	commitment, err := parseCommitment(configValues.Commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcSettings{
+		endpoint:      endpoint,
+		commitment:    commitment,
+		addressLabels: configValues.AddressLabels,
+	}, nil
+}
+
+// parseCommitment maps commitment level of Solana config, including deprecated
+// names, to one understood by RPC nodes
+func parseCommitment(commitment string) (rpc.Commitment, error) {
+	switch commitment {
+	case "":
+		return defaultCommitment, nil
+	case "processed", "recent":
+		return rpc.CommitmentProcessed, nil
+	case "confirmed", "single", "singleGossip":
+		return rpc.CommitmentConfirmed, nil
+	case "finalized", "max", "root":
+		return rpc.CommitmentFinalized, nil
+	default:
+		err := fmt.Errorf("invalid commitment level %q in config file", commitment)
+		return "", err
+	}
+}
+
+// rpcCall invokes RPC method that is not covered by the client and decodes its
+// result into result
+func rpcCall(ctx context.Context, c *client.Client, result interface{}, method string, params ...interface{}) error {
+	body, err := c.RpcClient.Call(ctx, append([]interface{}{method}, params...)...)
+	if err != nil {
+		err := fmt.Errorf("%s call failed: %w", method, err)
+		return err
+	}
+
+	var response struct {
+		rpc.GeneralResponse
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		err := fmt.Errorf("could not decode %s response: %w", method, err)
+		return err
+	}
+
+	if response.Error != nil {
+		err := fmt.Errorf("%s failed: %s (code %d)", method, response.Error.Message, response.Error.Code)
+		return err
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		err := fmt.Errorf("could not decode %s result: %w", method, err)
+		return err
+	}
+
+	return nil
+}
+
+// commitmentConfig is the config param of RPC methods that only take commitment
+type commitmentConfig struct {
+	Commitment rpc.Commitment `json:"commitment,omitempty"`
+}
+
+// getLatestBlockhash returns latest blockhash along with the last block height
+// at which a transaction referencing it is still valid
+func getLatestBlockhash(ctx context.Context, c *client.Client, commitment rpc.Commitment) (string, uint64, error) {
+	var result struct {
+		Value struct {
+			Blockhash            string `json:"blockhash"`
+			LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+		} `json:"value"`
+	}
+	if err := rpcCall(ctx, c, &result, "getLatestBlockhash", commitmentConfig{Commitment: commitment}); err != nil {
+		return "", 0, err
+	}
+
+	return result.Value.Blockhash, result.Value.LastValidBlockHeight, nil
+}
+
+// getFeeForMessage returns fee in lamports that network charges for message
+func getFeeForMessage(ctx context.Context, c *client.Client, message types.Message, commitment rpc.Commitment) (uint64, error) {
+	data, err := message.Serialize()
+	if err != nil {
+		err := fmt.Errorf("could not serialize message: %w", err)
+		return 0, err
+	}
+
+	var result struct {
+		Value *uint64 `json:"value"`
+	}
+	if err := rpcCall(
+		ctx,
+		c,
+		&result,
+		"getFeeForMessage",
+		base64.StdEncoding.EncodeToString(data),
+		commitmentConfig{Commitment: commitment},
+	); err != nil {
+		return 0, err
+	}
+
+	if result.Value == nil {
+		err := fmt.Errorf("could not get fee for message, blockhash may have expired")
+		return 0, err
+	}
+
+	return *result.Value, nil
+}
+
+// getBlockHeight returns current block height
+func getBlockHeight(ctx context.Context, c *client.Client, commitment rpc.Commitment) (uint64, error) {
+	var result uint64
+	if err := rpcCall(ctx, c, &result, "getBlockHeight", commitmentConfig{Commitment: commitment}); err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// signMessage signs message with all of its required signers, which must all
+// be among signers, and returns transaction ready to be sent
+func signMessage(ctx context.Context, message types.Message, signers ...signer) (types.Transaction, error) {
+	data, err := message.Serialize()
+	if err != nil {
+		err := fmt.Errorf("could not serialize message: %w", err)
+		return types.Transaction{}, err
+	}
+
+	bySigner := make(map[common.PublicKey]signer, len(signers))
+	for _, s := range signers {
+		bySigner[s.PublicKey()] = s
+	}
+
+	signatures := make([]types.Signature, message.Header.NumRequireSignatures)
+	for i := range signatures {
+		publicKey := message.Accounts[i]
+		s, ok := bySigner[publicKey]
+		if !ok {
+			err := fmt.Errorf("no key available to sign for %s", publicKey.ToBase58())
+			return types.Transaction{}, err
+		}
+
+		if signatures[i], err = s.Sign(ctx, data); err != nil {
+			err := fmt.Errorf("could not sign message as %s: %w", publicKey.ToBase58(), err)
+			return types.Transaction{}, err
+		}
+	}
+
+	return types.Transaction{
+		Signatures: signatures,
+		Message:    message,
+	}, nil
+}
+
+// sendAndConfirm sends transaction and waits until it reaches commitment level
+// or its blockhash expires. It returns signature of the transaction.
+func sendAndConfirm(
+	ctx context.Context,
+	c *client.Client,
+	tx types.Transaction,
+	lastValidBlockHeight uint64,
+	commitment rpc.Commitment,
+) (string, error) {
+	signature, err := c.SendTransactionWithConfig(ctx, tx, client.SendTransactionConfig{
+		PreflightCommitment: commitment,
+	})
+	if err != nil {
+		err := fmt.Errorf("could not send transaction: %w", err)
+		return "", err
+	}
+
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.GetSignatureStatus(ctx, signature)
+		if err != nil {
+			err := fmt.Errorf("could not get status of transaction %s: %w", signature, err)
+			return signature, err
+		}
+
+		if status != nil {
+			if status.Err != nil {
+				err := fmt.Errorf("transaction %s failed: %v", signature, status.Err)
+				return signature, err
+			}
+
+			if status.ConfirmationStatus != nil && reachedCommitment(*status.ConfirmationStatus, commitment) {
+				return signature, nil
+			}
+		} else {
+			blockHeight, err := getBlockHeight(ctx, c, commitment)
+			if err != nil {
+				return signature, err
+			}
+
+			if blockHeight > lastValidBlockHeight {
+				err := fmt.Errorf("transaction %s expired before it was confirmed", signature)
+				return signature, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			err := fmt.Errorf("gave up waiting for confirmation of transaction %s: %w", signature, ctx.Err())
+			return signature, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// reachedCommitment reports whether confirmation status satisfies commitment
+func reachedCommitment(status, commitment rpc.Commitment) bool {
+	levels := map[rpc.Commitment]int{
+		rpc.CommitmentProcessed: 0,
+		rpc.CommitmentConfirmed: 1,
+		rpc.CommitmentFinalized: 2,
+	}
+
+	return levels[status] >= levels[commitment]
+}
+
+// rpcSession holds RPC client along with signers of the key and of the fee
+// payer, which is the key itself unless a separate fee payer key file is used
+type rpcSession struct {
+	client          *client.Client
+	commitment      rpc.Commitment
+	persistentFlags persistentFlagValues
+	key             signer
+	feePayer        signer
+	// authorities are additional signers loaded during the session
+	authorities []signer
+}
+
+// newRpcSession resolves RPC settings and key file and loads signers of the key
+// and fee payer. Caller must close the session.
+func newRpcSession(
+	ctx context.Context,
+	persistentFlags persistentFlagValues,
+	url, keyFile, feePayerFile string,
+) (*rpcSession, error) {
+	settings, err := getRpcSettings(url, persistentFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	keySigner, err := newSigner(ctx, persistentFlags, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	feePayerSigner := keySigner
+	if len(feePayerFile) > 0 {
+		feePayerSigner, err = newSigner(ctx, persistentFlags, feePayerFile)
+		if err != nil {
+			_ = keySigner.Close()
+			return nil, err
+		}
+	}
+
+	return &rpcSession{
+		client:          client.NewClient(settings.endpoint),
+		commitment:      settings.commitment,
+		persistentFlags: persistentFlags,
+		key:             keySigner,
+		feePayer:        feePayerSigner,
+	}, nil
+}
+
+// authority returns signer for authority key file, which is the key itself
+// when keyFile is empty. Signer is closed along with the session.
+func (s *rpcSession) authority(ctx context.Context, keyFile string) (signer, error) {
+	if len(keyFile) == 0 {
+		return s.key, nil
+	}
+
+	authority, err := newSigner(ctx, s.persistentFlags, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.authorities = append(s.authorities, authority)
+	return authority, nil
+}
+
+// Close wipes keys of the session
+func (s *rpcSession) Close() error {
+	for _, authority := range s.authorities {
+		_ = authority.Close()
+	}
+
+	if s.feePayer != s.key {
+		_ = s.feePayer.Close()
+	}
+
+	return s.key.Close()
+}
+
+// send builds transaction of instructions with a recent blockhash, signs it with
+// fee payer and signers and waits for its confirmation
+func (s *rpcSession) send(ctx context.Context, instructions []types.Instruction, signers ...signer) (string, error) {
+	blockhash, lastValidBlockHeight, err := getLatestBlockhash(ctx, s.client, s.commitment)
+	if err != nil {
+		return "", err
+	}
+
+	message := types.NewMessage(types.NewMessageParam{
+		FeePayer:        s.feePayer.PublicKey(),
+		Instructions:    instructions,
+		RecentBlockhash: blockhash,
+	})
+
+	tx, err := signMessage(ctx, message, append(signers, s.feePayer)...)
+	if err != nil {
+		return "", err
+	}
+
+	return sendAndConfirm(ctx, s.client, tx, lastValidBlockHeight, s.commitment)
+}
+
+// getAccount returns account at address, which is nil when account does not exist
+func (s *rpcSession) getAccount(ctx context.Context, address common.PublicKey) (*client.AccountInfo, error) {
+	account, err := s.client.GetAccountInfoWithConfig(
+		ctx,
+		address.ToBase58(),
+		client.GetAccountInfoConfig{Commitment: s.commitment},
+	)
+	if err != nil {
+		err := fmt.Errorf("could not get account %s: %w", address.ToBase58(), err)
+		return nil, err
+	}
+
+	if len(account.Owner) == 0 {
+		return nil, nil
+	}
+
+	return &account, nil
+}
+
+// getBalance returns balance of address in lamports
+func (s *rpcSession) getBalance(ctx context.Context, address common.PublicKey) (uint64, error) {
+	balance, err := s.client.GetBalanceWithConfig(ctx, address.ToBase58(), rpc.GetBalanceConfig{Commitment: s.commitment})
+	if err != nil {
+		err := fmt.Errorf("could not get balance of %s: %w", address.ToBase58(), err)
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// rpcAccount is an account as returned by RPC methods with base64 encoding
+type rpcAccount struct {
+	Lamports uint64   `json:"lamports"`
+	Owner    string   `json:"owner"`
+	Data     []string `json:"data"`
+}
+
+// keyedRpcAccount is an account along with its address
+type keyedRpcAccount struct {
+	Pubkey  string     `json:"pubkey"`
+	Account rpcAccount `json:"account"`
+}
+
+// decodeData returns account data
+func (a *rpcAccount) decodeData() ([]byte, error) {
+	if len(a.Data) != 2 || a.Data[1] != "base64" {
+		err := fmt.Errorf("unexpected account data encoding")
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(a.Data[0])
+	if err != nil {
+		err := fmt.Errorf("could not decode account data: %w", err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// accountsConfig is the config param of RPC methods returning accounts
+type accountsConfig struct {
+	Encoding   string         `json:"encoding"`
+	Commitment rpc.Commitment `json:"commitment,omitempty"`
+}
+
+// maxMultipleAccounts is the number of accounts validators return at most per
+// getMultipleAccounts call
+const maxMultipleAccounts = 100
+
+// getTokenAccountsByOwner returns all token accounts of owner held by token
+// program
+func getTokenAccountsByOwner(
+	ctx context.Context,
+	c *client.Client,
+	owner, programID common.PublicKey,
+	commitment rpc.Commitment,
+) ([]keyedRpcAccount, error) {
+	var result struct {
+		Value []keyedRpcAccount `json:"value"`
+	}
+	if err := rpcCall(
+		ctx,
+		c,
+		&result,
+		"getTokenAccountsByOwner",
+		owner.ToBase58(),
+		map[string]string{"programId": programID.ToBase58()},
+		accountsConfig{Encoding: "base64", Commitment: commitment},
+	); err != nil {
+		return nil, err
+	}
+
+	return result.Value, nil
+}
+
+// getMultipleAccounts returns accounts at addresses in the same order, where
+// accounts that do not exist are nil
+func getMultipleAccounts(
+	ctx context.Context,
+	c *client.Client,
+	addresses []string,
+	commitment rpc.Commitment,
+) ([]*rpcAccount, error) {
+	accounts := make([]*rpcAccount, 0, len(addresses))
+	for start := 0; start < len(addresses); start += maxMultipleAccounts {
+		end := start + maxMultipleAccounts
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		var result struct {
+			Value []*rpcAccount `json:"value"`
+		}
+		if err := rpcCall(
+			ctx,
+			c,
+			&result,
+			"getMultipleAccounts",
+			addresses[start:end],
+			accountsConfig{Encoding: "base64", Commitment: commitment},
+		); err != nil {
+			return nil, err
+		}
+
+		if len(result.Value) != end-start {
+			err := fmt.Errorf("expected %d accounts, got %d", end-start, len(result.Value))
+			return nil, err
+		}
+
+		accounts = append(accounts, result.Value...)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,296 @@
+package run
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/mr-tron/base58"
+	"github.com/portto/solana-go-sdk/common"
+)
+
+// fakeRpc is a JSON-RPC stand-in for a Solana validator. It serves the methods
+// used to build, send and confirm transactions, and records sent transactions
+// after verifying their signatures.
+type fakeRpc struct {
+	URL string
+
+	mu sync.Mutex
+	// Balances holds lamports of accounts by base58 address
+	Balances map[string]uint64
+	// Accounts holds accounts served by getAccountInfo by base58 address
+	Accounts map[string]*fakeAccount
+	// Fee is charged per transaction
+	Fee uint64
+	// BlockHeight is the current block height
+	BlockHeight uint64
+	// PendingPolls is the number of status polls for which a sent transaction
+	// is not yet seen by the validator
+	PendingPolls int
+	// TxErr is reported as the error of sent transactions when set
+	TxErr interface{}
+	// Sent holds transactions sent to the validator
+	Sent []*transaction.Transaction
+	// Handlers override or extend served methods
+	Handlers map[string]func(params []json.RawMessage) (interface{}, error)
+
+	polls map[string]int
+}
+
+// fakeAccount is an account held by fakeRpc
+type fakeAccount struct {
+	Owner    common.PublicKey
+	Lamports uint64
+	Data     []byte
+}
+
+// encode returns account as served by RPC methods, which is null for accounts
+// that do not exist
+func (a *fakeAccount) encode() interface{} {
+	if a == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"data":       []string{base64.StdEncoding.EncodeToString(a.Data), "base64"},
+		"executable": false,
+		"lamports":   a.Lamports,
+		"owner":      a.Owner.ToBase58(),
+		"rentEpoch":  0,
+	}
+}
+
+// newFakeRpc starts fake validator that is stopped when the test completes
+func newFakeRpc(t *testing.T) *fakeRpc {
+	t.Helper()
+
+	f := &fakeRpc{
+		Balances:    make(map[string]uint64),
+		Accounts:    make(map[string]*fakeAccount),
+		Fee:         5000,
+		BlockHeight: 10,
+		Handlers:    make(map[string]func(params []json.RawMessage) (interface{}, error)),
+		polls:       make(map[string]int),
+	}
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	f.URL = server.URL
+
+	orig := confirmationPollInterval
+	confirmationPollInterval = time.Millisecond
+	t.Cleanup(func() { confirmationPollInterval = orig })
+
+	return f
+}
+
+// withContext wraps value the way validator does for methods returning context
+func withContext(value interface{}) interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   value,
+	}
+}
+
+func (f *fakeRpc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Id     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": request.Id}
+	result, err := f.call(request.Method, request.Params)
+	if err != nil {
+		response["error"] = map[string]interface{}{"code": -32002, "message": err.Error()}
+	} else {
+		response["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (f *fakeRpc) call(method string, params []json.RawMessage) (interface{}, error) {
+	f.mu.Lock()
+	handler, ok := f.Handlers[method]
+	f.mu.Unlock()
+	if ok {
+		return handler(params)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch method {
+	case "getLatestBlockhash":
+		return withContext(map[string]interface{}{
+			"blockhash":            common.SystemProgramID.ToBase58(),
+			"lastValidBlockHeight": f.BlockHeight + 150,
+		}), nil
+	case "getFeeForMessage":
+		return withContext(f.Fee), nil
+	case "getBlockHeight":
+		return f.BlockHeight, nil
+	case "getBalance":
+		var address string
+		if err := json.Unmarshal(params[0], &address); err != nil {
+			return nil, err
+		}
+		return withContext(f.Balances[address]), nil
+	case "getAccountInfo":
+		var address string
+		if err := json.Unmarshal(params[0], &address); err != nil {
+			return nil, err
+		}
+
+		return withContext(f.Accounts[address].encode()), nil
+	case "getMultipleAccounts":
+		var addresses []string
+		if err := json.Unmarshal(params[0], &addresses); err != nil {
+			return nil, err
+		}
+
+		accounts := make([]interface{}, len(addresses))
+		for i, address := range addresses {
+			accounts[i] = f.Accounts[address].encode()
+		}
+		return withContext(accounts), nil
+	case "getTokenAccountsByOwner":
+		var owner string
+		var filter struct {
+			ProgramId string `json:"programId"`
+		}
+		if err := json.Unmarshal(params[0], &owner); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(params[1], &filter); err != nil {
+			return nil, err
+		}
+
+		accounts := []interface{}{}
+		for address, account := range f.Accounts {
+			if account.Owner.ToBase58() == filter.ProgramId && len(account.Data) >= 64 &&
+				common.PublicKeyFromBytes(account.Data[32:64]).ToBase58() == owner {
+				accounts = append(accounts, map[string]interface{}{
+					"pubkey":  address,
+					"account": account.encode(),
+				})
+			}
+		}
+		return withContext(accounts), nil
+	case "getMinimumBalanceForRentExemption":
+		var size uint64
+		if err := json.Unmarshal(params[0], &size); err != nil {
+			return nil, err
+		}
+		return 890880 + 6960*size, nil
+	case "sendTransaction":
+		return f.sendTransaction(params)
+	case "getSignatureStatuses":
+		var signatures []string
+		if err := json.Unmarshal(params[0], &signatures); err != nil {
+			return nil, err
+		}
+
+		statuses := make([]interface{}, len(signatures))
+		for i, signature := range signatures {
+			polls, ok := f.polls[signature]
+			if !ok {
+				continue
+			}
+			f.polls[signature]++
+
+			if polls >= f.PendingPolls {
+				statuses[i] = map[string]interface{}{
+					"slot":               1,
+					"confirmations":      nil,
+					"confirmationStatus": "finalized",
+					"err":                f.TxErr,
+				}
+			}
+		}
+		return withContext(statuses), nil
+	default:
+		return nil, fmt.Errorf("method %s not found", method)
+	}
+}
+
+// sendTransaction verifies that transaction is fully signed and records it
+func (f *fakeRpc) sendTransaction(params []json.RawMessage) (interface{}, error) {
+	var encoded string
+	if err := json.Unmarshal(params[0], &encoded); err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := transaction.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	message := tx.Message.Serialize()
+	for i, signer := range tx.Message.Signers() {
+		if !ed25519.Verify(signer.Bytes(), message, tx.Signatures[i]) {
+			return nil, fmt.Errorf("signature verification failed for %s", signer.ToBase58())
+		}
+	}
+
+	f.Sent = append(f.Sent, tx)
+	signature := base58.Encode(tx.Signatures[0])
+	f.polls[signature] = 0
+
+	return signature, nil
+}
+
+// newRpcConfig writes Solana config pointing to url with commitment level
+func newRpcConfig(t *testing.T, url, commitment string) string {
+	t.Helper()
+
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	data := fmt.Sprintf("json_rpc_url: %s\ncommitment: %s\n", url, commitment)
+	if err := os.WriteFile(configFile, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return configFile
+}
+
+func TestParseCommitment(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":             "confirmed",
+		"recent":       "processed",
+		"singleGossip": "confirmed",
+		"max":          "finalized",
+		"finalized":    "finalized",
+	} {
+		commitment, err := parseCommitment(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(commitment) != expected {
+			t.Fatalf("expected %q to map to %s, got %s", input, expected, commitment)
+		}
+	}
+
+	if _, err := parseCommitment("eventually"); err == nil {
+		t.Fatal("expected invalid commitment to fail")
+	}
+}
@@ -0,0 +1,312 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// memoryKeyEncrypter is an in-memory fake of a KMS backend using AES-GCM
+type memoryKeyEncrypter struct {
+	aead cipher.AEAD
+}
+
+func newMemoryKeyEncrypter(t *testing.T) *memoryKeyEncrypter {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &memoryKeyEncrypter{aead: aead}
+}
+
+func (m *memoryKeyEncrypter) Encrypt(_ context.Context, plaintext, aad []byte) (*backend.Ciphertext, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &backend.Ciphertext{
+		Data:       m.aead.Seal(nonce, nonce, plaintext, aad),
+		Backend:    m.Type(),
+		KeyVersion: "memory/1",
+	}, nil
+}
+
+func (m *memoryKeyEncrypter) Decrypt(_ context.Context, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < m.aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:m.aead.NonceSize()], ciphertext[m.aead.NonceSize():]
+	return m.aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func (m *memoryKeyEncrypter) Type() string {
+	return "memory"
+}
+
+func (m *memoryKeyEncrypter) Describe() string {
+	return "memory"
+}
+
+func (m *memoryKeyEncrypter) Close() error {
+	return nil
+}
+
+// useKeyEncrypter replaces backend construction with given key encrypter for
+// the duration of the test
+func useKeyEncrypter(t *testing.T, keyEncrypter backend.KeyEncrypter) {
+	orig := newKeyEncrypter
+	newKeyEncrypter = func(context.Context, persistentFlagValues) (backend.KeyEncrypter, error) {
+		return keyEncrypter, nil
+	}
+	t.Cleanup(func() { newKeyEncrypter = orig })
+}
+
+// execute runs runE as a subcommand of a root command carrying persistent flags.
+// setFlags registers subcommand flags.
+func execute(t *testing.T, runE func(*cobra.Command, []string) error, setFlags func(f *pflag.FlagSet), args ...string) (string, error) {
+	// flag bindings are global, so start each command from a clean slate
+	viper.Reset()
+
+	rootCmd := &cobra.Command{Use: "solana-kms"}
+	rootCmd.PersistentFlags().String(filepath.Base(flags.Config), "", "")
+	rootCmd.PersistentFlags().String(filepath.Base(flags.Backend), backend.Google, "")
+	rootCmd.PersistentFlags().String(filepath.Base(flags.KeyName), "", "")
+	rootCmd.PersistentFlags().String(filepath.Base(flags.Keystore), "", "")
+
+	subCmd := &cobra.Command{Use: "sub", RunE: runE}
+	setFlags(subCmd.Flags())
+	rootCmd.AddCommand(subCmd)
+
+	out := &bytes.Buffer{}
+	rootCmd.SetOut(out)
+	rootCmd.SetArgs(append([]string{"sub"}, args...))
+	err := rootCmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestKeyNewShowAndRecover(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id")
+
+	keyNewFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}
+	keyShowFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.PubKey, false, "")
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key []byte
+	if err := json.Unmarshal(ciphertext, &key); err == nil {
+		t.Fatal("keypair file was written as plaintext")
+	}
+
+	if _, err := os.Stat(keyFile + ".seed"); err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey, err := execute(t, KeyShow, keyShowFlags, "--keyfile", keyFile, "--pubkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := filepath.Join(dir, "recovered")
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", recovered, "--seedfile", keyFile+".seed"); err != nil {
+		t.Fatal(err)
+	}
+
+	recoveredPubKey, err := execute(t, KeyShow, keyShowFlags, "--keyfile", recovered, "--pubkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pubKey != recoveredPubKey {
+		t.Fatalf("recovered public key %q does not match %q", recoveredPubKey, pubKey)
+	}
+
+	out, err := execute(t, KeyShow, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.PubKey, false, "")
+		f.Bool(flags.Info, false, "")
+	}, "--keyfile", keyFile, "--info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &keyFileInfo{}
+	if err := json.Unmarshal([]byte(out), info); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Format != "envelope" ||
+		info.Backend != "memory" ||
+		info.KeyVersion != "memory/1" ||
+		info.PublicKey != strings.TrimSpace(pubKey) {
+		t.Fatalf("unexpected key file info: %s", out)
+	}
+}
+
+func TestAccountBalanceFromKeyFile(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+
+	account := types.NewAccount()
+	ciphertext, err := keyEncrypter.Encrypt(context.Background(), account.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "id")
+	// raw ciphertext without envelope is how legacy key files were written
+	if err := os.WriteFile(keyFile, ciphertext.Data, 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.Method != "getBalance" || len(req.Params) == 0 || req.Params[0] != account.PublicKey.ToBase58() {
+			t.Errorf("unexpected request: %v", req)
+		}
+		_, _ = fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":42}}`)
+	}))
+	defer server.Close()
+
+	out, err := execute(t, AccountBalance, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.PubKey, "", "")
+		f.String(flags.Url, "", "")
+	}, "--keyfile", keyFile, "--url", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(out) != "42" {
+		t.Fatalf("unexpected balance output: %q", out)
+	}
+}
+
+func TestDecryptKeyDataVerifiesRole(t *testing.T) {
+	ctx := context.Background()
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	account := types.NewAccount()
+	publicKey := account.PublicKey.ToBase58()
+
+	seed, err := encryptKeyData(ctx, keyEncrypter, account.PrivateKey.Seed(), envelope.RoleSeed, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := decryptKeyData(ctx, keyEncrypter, seed, envelope.RoleSeed); err != nil {
+		t.Fatal(err)
+	}
+
+	// seed file swapped in place of keypair file must be rejected
+	if _, _, err := decryptKeyData(ctx, keyEncrypter, seed, envelope.RoleKeypair); err == nil {
+		t.Fatal("expected error decrypting seed as keypair")
+	}
+
+	// rewriting header role does not help since role is bound as additional data
+	e, err := envelope.Unmarshal(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Header.Role = envelope.RoleKeypair
+	swapped, err := e.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := decryptKeyData(ctx, keyEncrypter, swapped, envelope.RoleKeypair); err == nil {
+		t.Fatal("expected error decrypting seed with rewritten role as keypair")
+	}
+
+	// envelopes written without additional data continue to work
+	ciphertext, err := keyEncrypter.Encrypt(ctx, account.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noAad, err := envelope.New(envelope.Header{PublicKey: publicKey}, ciphertext.Data).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := decryptKeyData(ctx, keyEncrypter, noAad, envelope.RoleKeypair); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chdir changes working dir to dir until the test completes, so that key files
+// can be referred to by relative paths
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// renameKeyFile moves key file and its seed file to name within the same dir
+// and returns the new key file path
+func renameKeyFile(t *testing.T, keyFile, name string) string {
+	t.Helper()
+
+	renamed := filepath.Join(filepath.Dir(keyFile), name)
+	for _, ext := range []string{"", "." + seedFileExt} {
+		if err := os.Rename(keyFile+ext, renamed+ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return renamed
+}
@@ -0,0 +1,96 @@
+package run
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/kubetrail/solana-kms/pkg/secure"
+)
+
+// seedShareVersion is the current serialization version of seed shares
+const seedShareVersion = 1
+
+// encodeShare serializes a Shamir share of the seed along with the threshold
+// required to combine it. Returned data should be wiped by caller. Layout is
+// as follows, with checksum in big endian:
+//
+//	version (1 byte) | threshold (1 byte) | share | CRC32C of preceding bytes (4 bytes)
+func encodeShare(threshold int, share []byte) []byte {
+	data := make([]byte, 0, 2+len(share)+4)
+	data = append(data, seedShareVersion, byte(threshold))
+	data = append(data, share...)
+
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+	return append(data, sum...)
+}
+
+// decodeShare parses serialized share and returns threshold and share
+func decodeShare(data []byte) (int, []byte, error) {
+	if len(data) < 2+2+4 {
+		err := fmt.Errorf("share is truncated")
+		return 0, nil, err
+	}
+
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)) != sum {
+		err := fmt.Errorf("share checksum mismatch, please check share")
+		return 0, nil, err
+	}
+
+	if body[0] != seedShareVersion {
+		err := fmt.Errorf("unsupported share version %d", body[0])
+		return 0, nil, err
+	}
+
+	return int(body[1]), body[2:], nil
+}
+
+// shareToWords encodes serialized share as words from the BIP39 english
+// wordlist, 11 bits per word. Unlike a BIP39 mnemonic, data is prefixed with
+// its length so that shares of any size can be encoded. Words are held in the
+// returned secure buffer, which caller must destroy.
+func shareToWords(data []byte) (*secure.Buffer, error) {
+	// length prefix occupies the first byte
+	bit := func(i int) int {
+		if i < 8 {
+			return len(data) >> (7 - i) & 1
+		}
+		i -= 8
+		return int(data[i/8]>>(7-i%8)) & 1
+	}
+
+	bits := (len(data) + 1) * 8
+	indexes := make([]int, (bits+10)/11)
+	defer wipeIndexes(indexes)
+	for i := 0; i < bits; i++ {
+		indexes[i/11] |= bit(i) << (10 - i%11)
+	}
+
+	return joinWords(indexes)
+}
+
+// shareFromWords decodes words produced by shareToWords. Returned share
+// should be wiped by caller.
+func shareFromWords(input []byte) ([]byte, error) {
+	indexes, err := splitWords(input)
+	defer wipeIndexes(indexes)
+	if err != nil {
+		err := fmt.Errorf("invalid share words: %w", err)
+		return nil, err
+	}
+
+	data := make([]byte, len(indexes)*11/8)
+	for i := 0; i < len(data)*8; i++ {
+		data[i/8] |= byte(indexes[i/11] >> (10 - i%11) & 1 << (7 - i%8))
+	}
+
+	if len(data) == 0 || int(data[0]) > len(data)-1 {
+		secure.Wipe(data)
+		err := fmt.Errorf("share words are truncated")
+		return nil, err
+	}
+
+	return data[1 : 1+int(data[0])], nil
+}
@@ -0,0 +1,175 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+func TestShareWords(t *testing.T) {
+	data := encodeShare(3, bytes.Repeat([]byte{0xa5}, 65))
+
+	words, err := shareToWords(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer words.Destroy()
+
+	decoded, err := shareFromWords(bytes.ToUpper(words.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatal("decoded share words do not match")
+	}
+
+	threshold, share, err := decodeShare(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threshold != 3 || len(share) != 65 {
+		t.Fatalf("unexpected decoded share: threshold %d, length %d", threshold, len(share))
+	}
+
+	decoded[3] ^= 0x01
+	if _, _, err := decodeShare(decoded); err == nil {
+		t.Fatal("expected checksum error decoding modified share")
+	}
+
+	if _, err := shareFromWords([]byte("abandon notaword")); err == nil {
+		t.Fatal("expected error decoding invalid word")
+	}
+}
+
+func TestKeySplitCombine(t *testing.T) {
+	keyEncrypter := newMemoryKeyEncrypter(t)
+	useKeyEncrypter(t, keyEncrypter)
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id")
+
+	if _, err := execute(t, KeyNew, func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+	}, "--keyfile", keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// each share key name selects a different in-memory key
+	shareKeyEncrypters := map[string]backend.KeyEncrypter{}
+	for _, name := range []string{"k1", "k2", "k3"} {
+		shareKeyEncrypters[name] = newMemoryKeyEncrypter(t)
+	}
+	newKeyEncrypter = func(_ context.Context, persistentFlags persistentFlagValues) (backend.KeyEncrypter, error) {
+		if ke, ok := shareKeyEncrypters[persistentFlags.Key]; ok {
+			return ke, nil
+		}
+		return keyEncrypter, nil
+	}
+
+	keySplitFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+		f.Int(flags.Shares, 3, "")
+		f.Int(flags.Threshold, 2, "")
+		f.StringSlice(flags.ShareKeys, nil, "")
+		f.String(flags.OutDir, "", "")
+		f.Bool(flags.Mnemonic, false, "")
+	}
+	keyCombineFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.StringSlice(flags.Share, nil, "")
+		f.StringSlice(flags.ShareKeys, nil, "")
+		f.Bool(flags.Mnemonic, false, "")
+	}
+
+	out, err := execute(t, KeySplit, keySplitFlags,
+		"--keyfile", keyFile, "--share-keys", "k1,k2,k3", "--outdir", filepath.Join(dir, "shares"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var shareFiles []seedShareFile
+	if err := json.Unmarshal([]byte(out), &shareFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(shareFiles) != 3 {
+		t.Fatalf("unexpected split output: %s", out)
+	}
+
+	// share encrypted to k3 cannot be opened using k1
+	if _, err := execute(t, KeyCombine, keyCombineFlags,
+		"--keyfile", filepath.Join(dir, "bad"),
+		"--share", shareFiles[0].ShareFile+","+shareFiles[2].ShareFile,
+		"--share-keys", "k1,k1"); err == nil {
+		t.Fatal("expected error decrypting share using wrong key")
+	}
+
+	recovered := filepath.Join(dir, "recovered")
+	pubKey, err := execute(t, KeyCombine, keyCombineFlags,
+		"--keyfile", recovered,
+		"--share", shareFiles[0].ShareFile+","+shareFiles[2].ShareFile,
+		"--share-keys", "k1,k3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _, err := readAccountFromKeyFile(context.Background(), keyEncrypter, recovered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.PublicKey != original.PublicKey || strings.TrimSpace(pubKey) != original.PublicKey.ToBase58() {
+		t.Fatal("combined key does not match original")
+	}
+
+	origWriteToTerminal, origReadSecret := writeToTerminal, readSecret
+	t.Cleanup(func() { writeToTerminal, readSecret = origWriteToTerminal, origReadSecret })
+
+	var shown string
+	writeToTerminal = func(message []byte) error {
+		shown = string(message)
+		return nil
+	}
+
+	if _, err := execute(t, KeySplit, keySplitFlags, "--keyfile", keyFile, "--mnemonic"); err != nil {
+		t.Fatal(err)
+	}
+
+	shareWords := regexp.MustCompile(`share \d of 3:\n(.*)\n`).FindAllStringSubmatch(shown, -1)
+	if len(shareWords) != 3 {
+		t.Fatalf("unexpected shares shown: %s", shown)
+	}
+
+	var prompts int
+	readSecret = func(string) ([]byte, error) {
+		prompts++
+		return []byte(shareWords[3-prompts][1]), nil
+	}
+
+	recovered = filepath.Join(dir, "recovered-from-words")
+	if _, err := execute(t, KeyCombine, keyCombineFlags, "--keyfile", recovered, "--mnemonic"); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 2 {
+		t.Fatalf("expected prompts for 2 shares, got %d", prompts)
+	}
+
+	account, _, err = readAccountFromKeyFile(context.Background(), keyEncrypter, recovered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.PublicKey != original.PublicKey {
+		t.Fatal("key combined from words does not match original")
+	}
+}
@@ -0,0 +1,206 @@
+package run
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/agent"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/offchain"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// encodings of signatures and serialized transactions
+const (
+	encodingBase58 = "base58"
+	encodingBase64 = "base64"
+	encodingHex    = "hex"
+)
+
+// SignMessage signs a message passed as argument, or read from --input file or
+// stdin, and prints encoded signature. With --offchain the message is signed as
+// Solana off-chain message, which can never be mistaken for a transaction.
+func SignMessage(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Input, cmd.Flags().Lookup(filepath.Base(flags.Input)))
+	_ = viper.BindPFlag(flags.Offchain, cmd.Flags().Lookup(filepath.Base(flags.Offchain)))
+	_ = viper.BindPFlag(flags.Encoding, cmd.Flags().Lookup(filepath.Base(flags.Encoding)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	input := viper.GetString(flags.Input)
+	isOffchain := viper.GetBool(flags.Offchain)
+	encoding := viper.GetString(flags.Encoding)
+
+	// fail on invalid encoding before any key is decrypted
+	if err := checkEncoding(encoding); err != nil {
+		return err
+	}
+
+	message, err := readMessage(cmd, args, input)
+	if err != nil {
+		return err
+	}
+
+	data, err := messageToSign(message, isOffchain)
+	if err != nil {
+		return err
+	}
+
+	keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	keySigner, err := newSigner(ctx, persistentFlags, keyFile)
+	if err != nil {
+		return err
+	}
+	defer keySigner.Close()
+
+	// a transaction must not be approved under the guise of a message
+	if agent.IsTransactionSigner(data, keySigner.PublicKey()) {
+		err := fmt.Errorf("refusing to sign transaction message as an arbitrary message")
+		return err
+	}
+
+	signature, err := keySigner.Sign(ctx, data)
+	if err != nil {
+		err := fmt.Errorf("could not sign message: %w", err)
+		return err
+	}
+
+	encoded, err := encodeData(signature, encoding)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), encoded); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// readMessage returns message passed as the only argument or, otherwise, read
+// from input file, where an empty or "-" input reads from stdin
+func readMessage(cmd *cobra.Command, args []string, input string) ([]byte, error) {
+	if len(args) > 0 {
+		if len(input) > 0 {
+			err := fmt.Errorf("message argument cannot be used with --%s", flags.Input)
+			return nil, err
+		}
+
+		return []byte(args[0]), nil
+	}
+
+	var message []byte
+	var err error
+	if len(input) == 0 || input == "-" {
+		message, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		message, err = os.ReadFile(input)
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read message: %w", err)
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// messageToSign returns data that gets signed for the message. Message is
+// serialized as off-chain message when requested, whereas a message that is
+// already serialized as one is validated and signed as is.
+func messageToSign(message []byte, isOffchain bool) ([]byte, error) {
+	if len(message) == 0 {
+		err := fmt.Errorf("message is empty")
+		return nil, err
+	}
+
+	if isOffchain {
+		data, err := offchain.Encode(message)
+		if err != nil {
+			err := fmt.Errorf("could not serialize off-chain message: %w", err)
+			return nil, err
+		}
+
+		return data, nil
+	}
+
+	if offchain.IsOffchainMessage(message) {
+		if _, _, err := offchain.Decode(message); err != nil {
+			err := fmt.Errorf("invalid off-chain message: %w", err)
+			return nil, err
+		}
+	}
+
+	return message, nil
+}
+
+// checkEncoding validates encoding of signatures and serialized transactions
+func checkEncoding(encoding string) error {
+	switch encoding {
+	case encodingBase58, encodingBase64, encodingHex:
+		return nil
+	default:
+		err := fmt.Errorf(
+			"invalid --%s %q, allowed values are %s, %s or %s",
+			flags.Encoding,
+			encoding,
+			encodingBase58,
+			encodingBase64,
+			encodingHex,
+		)
+		return err
+	}
+}
+
+// encodeData encodes data as base58, base64 or hex
+func encodeData(data []byte, encoding string) (string, error) {
+	if err := checkEncoding(encoding); err != nil {
+		return "", err
+	}
+
+	switch encoding {
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case encodingHex:
+		return hex.EncodeToString(data), nil
+	default:
+		return base58.Encode(data), nil
+	}
+}
+
+// decodeData decodes base58, base64 or hex encoded data
+func decodeData(data, encoding string) ([]byte, error) {
+	if err := checkEncoding(encoding); err != nil {
+		return nil, err
+	}
+
+	var decoded []byte
+	var err error
+	switch encoding {
+	case encodingBase64:
+		decoded, err = base64.StdEncoding.DecodeString(data)
+	case encodingHex:
+		decoded, err = hex.DecodeString(data)
+	default:
+		decoded, err = base58.Decode(data)
+	}
+	if err != nil {
+		err := fmt.Errorf("could not decode %s data: %w", encoding, err)
+		return nil, err
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,123 @@
+package run
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/offchain"
+	"github.com/mr-tron/base58"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func signMessageFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.Input, "", "")
+	f.Bool(flags.Offchain, false, "")
+	f.String(flags.Encoding, encodingBase58, "")
+}
+
+func verifyMessageFlags(f *pflag.FlagSet) {
+	f.String(flags.PubKey, "", "")
+	f.String(flags.Signature, "", "")
+	f.String(flags.Input, "", "")
+	f.Bool(flags.Offchain, false, "")
+	f.String(flags.Encoding, encodingBase58, "")
+}
+
+func TestSignAndVerifyMessage(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+
+	for _, encoding := range []string{encodingBase58, encodingBase64, encodingHex} {
+		for _, isOffchain := range []string{"false", "true"} {
+			out, err := execute(t, SignMessage, signMessageFlags,
+				"--keyfile", keyFile, "--encoding", encoding, "--offchain="+isOffchain, "hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			signature := strings.TrimSpace(out)
+
+			if _, err := execute(t, VerifyMessage, verifyMessageFlags,
+				"--pubkey", publicKey, "--signature", signature, "--encoding", encoding,
+				"--offchain="+isOffchain, "hello"); err != nil {
+				t.Fatalf("%s signature with offchain=%s: %v", encoding, isOffchain, err)
+			}
+
+			if _, err := execute(t, VerifyMessage, verifyMessageFlags,
+				"--pubkey", publicKey, "--signature", signature, "--encoding", encoding,
+				"--offchain="+isOffchain, "hello!"); err == nil {
+				t.Fatalf("expected %s signature of another message not to verify", encoding)
+			}
+		}
+	}
+
+	// off-chain signature is over the serialized off-chain message
+	out, err := execute(t, SignMessage, signMessageFlags, "--keyfile", keyFile, "--offchain", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := base58.Decode(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := offchain.Encode([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := base58.Decode(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, data, signature) {
+		t.Fatal("off-chain signature does not verify")
+	}
+
+	// serialized off-chain message read from file verifies as is
+	input := filepath.Join(t.TempDir(), "message")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execute(t, VerifyMessage, verifyMessageFlags,
+		"--pubkey", publicKey, "--signature", base58.Encode(signature), "--input", input); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, SignMessage, signMessageFlags,
+		"--keyfile", keyFile, "--encoding", "base32", "hello"); err == nil {
+		t.Fatal("expected invalid encoding to fail")
+	}
+}
+
+func TestSignMessageRefusesTransaction(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+
+	from := common.PublicKeyFromString(publicKey)
+	message := types.NewMessage(types.NewMessageParam{
+		FeePayer: from,
+		Instructions: []types.Instruction{
+			sysprog.Transfer(sysprog.TransferParam{From: from, To: common.SystemProgramID, Amount: 1}),
+		},
+		RecentBlockhash: common.SystemProgramID.ToBase58(),
+	})
+	data, err := message.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := filepath.Join(t.TempDir(), "message")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, SignMessage, signMessageFlags, "--keyfile", keyFile, "--input", input); err == nil {
+		t.Fatal("expected signing transaction as a message to fail")
+	}
+}
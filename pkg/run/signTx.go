@@ -0,0 +1,194 @@
+package run
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signature states reported for transaction signers
+const (
+	signatureSigned  = "signed"
+	signatureMissing = "missing"
+	signatureInvalid = "invalid"
+)
+
+// transactionSigner describes a required signer of a transaction
+type transactionSigner struct {
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// transactionSummary describes a transaction in human readable form
+type transactionSummary struct {
+	Version             string                        `json:"version"`
+	RecentBlockhash     string                        `json:"recentBlockhash"`
+	FeePayer            string                        `json:"feePayer"`
+	Signers             []transactionSigner           `json:"signers"`
+	Instructions        []transaction.InstructionInfo `json:"instructions"`
+	AddressTableLookups []string                      `json:"addressTableLookups,omitempty"`
+}
+
+// SignTx reads a serialized legacy or v0 transaction from --input file or stdin,
+// displays its instructions on stderr, adds signature of the key in its signer
+// slot and prints partially or fully signed transaction in the same encoding.
+// Transaction is never broadcast.
+func SignTx(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Input, cmd.Flags().Lookup(filepath.Base(flags.Input)))
+	_ = viper.BindPFlag(flags.Encoding, cmd.Flags().Lookup(filepath.Base(flags.Encoding)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	input := viper.GetString(flags.Input)
+	encoding := viper.GetString(flags.Encoding)
+
+	if err := checkEncoding(encoding); err != nil {
+		return err
+	}
+
+	encoded, err := readMessage(cmd, nil, input)
+	if err != nil {
+		return err
+	}
+
+	data, err := decodeData(strings.TrimSpace(string(encoded)), encoding)
+	if err != nil {
+		err := fmt.Errorf("could not decode transaction: %w", err)
+		return err
+	}
+
+	tx, err := transaction.Decode(data)
+	if err != nil {
+		err := fmt.Errorf("invalid transaction: %w", err)
+		return err
+	}
+
+	// display what is about to be signed before any key is decrypted
+	if err := writeTransactionSummary(cmd, tx); err != nil {
+		return err
+	}
+
+	keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	keySigner, err := newSigner(ctx, persistentFlags, keyFile)
+	if err != nil {
+		return err
+	}
+	defer keySigner.Close()
+
+	publicKey := keySigner.PublicKey()
+	if tx.Message.SignerIndex(publicKey) < 0 {
+		err := fmt.Errorf("%s is not a required signer of the transaction", publicKey.ToBase58())
+		return err
+	}
+
+	signature, err := keySigner.Sign(ctx, tx.Message.Serialize())
+	if err != nil {
+		err := fmt.Errorf("could not sign transaction: %w", err)
+		return err
+	}
+
+	if err := tx.AddSignature(publicKey, signature); err != nil {
+		return err
+	}
+
+	var signed int
+	for i := range tx.Signatures {
+		if tx.IsSigned(i) {
+			signed++
+		}
+	}
+
+	if _, err := fmt.Fprintf(
+		cmd.ErrOrStderr(),
+		"signed by %s, %d of %d signatures present\n",
+		publicKey.ToBase58(),
+		signed,
+		len(tx.Signatures),
+	); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	output, err := encodeData(tx.Serialize(), encoding)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), output); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// summarizeTransaction describes transaction along with the state of each of
+// its signatures
+func summarizeTransaction(tx *transaction.Transaction) *transactionSummary {
+	message := tx.Message
+	data := message.Serialize()
+
+	summary := &transactionSummary{
+		Version:         "legacy",
+		RecentBlockhash: message.RecentBlockhash.ToBase58(),
+		FeePayer:        message.AccountKeys[0].ToBase58(),
+		Instructions:    message.DescribeInstructions(),
+	}
+
+	if message.Version != transaction.Legacy {
+		summary.Version = strconv.Itoa(message.Version)
+	}
+
+	for i, signer := range message.Signers() {
+		state := signatureMissing
+		if tx.IsSigned(i) {
+			state = signatureInvalid
+			if ed25519.Verify(signer.Bytes(), data, tx.Signatures[i]) {
+				state = signatureSigned
+			}
+		}
+
+		summary.Signers = append(summary.Signers, transactionSigner{
+			PublicKey: signer.ToBase58(),
+			Signature: state,
+		})
+	}
+
+	for _, lookup := range message.AddressTableLookups {
+		summary.AddressTableLookups = append(summary.AddressTableLookups, lookup.AccountKey.ToBase58())
+	}
+
+	return summary
+}
+
+// writeTransactionSummary writes transaction summary to stderr so that it does
+// not mix with transaction written to stdout
+func writeTransactionSummary(cmd *cobra.Command, tx *transaction.Transaction) error {
+	jb, err := json.MarshalIndent(summarizeTransaction(tx), "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize transaction summary: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.ErrOrStderr(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
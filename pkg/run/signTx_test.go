@@ -0,0 +1,149 @@
+package run
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/mr-tron/base58"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func signTxFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.Input, "", "")
+	f.String(flags.Encoding, encodingBase64, "")
+}
+
+// unsignedTransfer returns serialized transfer transaction with empty signature
+// slots for fee payer and from accounts
+func unsignedTransfer(t *testing.T, feePayer, from common.PublicKey) []byte {
+	t.Helper()
+
+	message := types.NewMessage(types.NewMessageParam{
+		FeePayer: feePayer,
+		Instructions: []types.Instruction{
+			sysprog.Transfer(sysprog.TransferParam{From: from, To: common.StakeProgramID, Amount: 1}),
+		},
+		RecentBlockhash: common.SystemProgramID.ToBase58(),
+	})
+
+	data, err := message.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := int(message.Header.NumRequireSignatures)
+	tx := append([]byte{byte(n)}, make([]byte, n*transaction.SignatureLength)...)
+	return append(tx, data...)
+}
+
+func TestSignTx(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	from := common.PublicKeyFromString(publicKey)
+	feePayer := types.NewAccount()
+
+	input := filepath.Join(t.TempDir(), "tx")
+	unsigned := unsignedTransfer(t, feePayer.PublicKey, from)
+	if err := os.WriteFile(input, []byte(base64.StdEncoding.EncodeToString(unsigned)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execute(t, SignTx, signTxFlags, "--keyfile", keyFile, "--input", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := transaction.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := tx.Message.SignerIndex(from)
+	if !ed25519.Verify(from.Bytes(), tx.Message.Serialize(), tx.Signatures[index]) {
+		t.Fatal("signature does not verify")
+	}
+	if tx.IsSigned(tx.Message.SignerIndex(feePayer.PublicKey)) {
+		t.Fatal("expected fee payer signature to remain missing")
+	}
+
+	// fee payer completes partially signed transaction elsewhere
+	if err := tx.AddSignature(feePayer.PublicKey, ed25519.Sign(feePayer.PrivateKey, tx.Message.Serialize())); err != nil {
+		t.Fatal(err)
+	}
+	summary := summarizeTransaction(tx)
+	for _, signer := range summary.Signers {
+		if signer.Signature != signatureSigned {
+			t.Fatalf("expected all signatures to be present, got %+v", summary.Signers)
+		}
+	}
+	if len(summary.Instructions) != 1 || summary.Instructions[0].Type != "transfer" {
+		t.Fatalf("unexpected instructions %+v", summary.Instructions)
+	}
+}
+
+func TestSignTxBase58(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	from := common.PublicKeyFromString(publicKey)
+
+	input := filepath.Join(t.TempDir(), "tx")
+	if err := os.WriteFile(input, []byte(base58.Encode(unsignedTransfer(t, from, from))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execute(t, SignTx, signTxFlags, "--keyfile", keyFile, "--input", input, "--encoding", encodingBase58)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base58.Decode(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := transaction.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := summarizeTransaction(tx); summary.Signers[0].Signature != signatureSigned {
+		t.Fatalf("expected transaction to be fully signed, got %+v", summary.Signers)
+	}
+}
+
+func TestSignTxRejectsOtherSigners(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, _ := newAgentKeyFile(t)
+	other := types.NewAccount().PublicKey
+
+	input := filepath.Join(t.TempDir(), "tx")
+	if err := os.WriteFile(input, []byte(base64.StdEncoding.EncodeToString(unsignedTransfer(t, other, other))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execute(t, SignTx, signTxFlags, "--keyfile", keyFile, "--input", input); err == nil ||
+		!strings.Contains(err.Error(), "not a required signer") {
+		t.Fatalf("expected signing transaction of other signer to fail, got %v", err)
+	}
+
+	if err := os.WriteFile(input, []byte("aGVsbG8="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execute(t, SignTx, signTxFlags, "--keyfile", keyFile, "--input", input); err == nil {
+		t.Fatal("expected invalid transaction to fail")
+	}
+}
@@ -0,0 +1,248 @@
+package run
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/secure"
+	"github.com/portto/solana-go-sdk/common"
+)
+
+// newKmsSigner creates a signer for a KMS native signing key. When keyVersion
+// is empty, a new key version is created under KMS key selected by persistent
+// flags. It is declared as a variable so that tests can substitute a fake.
+var newKmsSigner = func(ctx context.Context, persistentFlags persistentFlagValues, keyVersion string) (backend.Signer, error) {
+	if persistentFlags.Backend != backend.Google {
+		err := fmt.Errorf("KMS native signing keys are only supported by %s backend", backend.Google)
+		return nil, err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return nil, err
+	}
+
+	if len(keyVersion) > 0 {
+		return backend.NewGoogleKmsSigner(ctx, keyVersion)
+	}
+
+	return backend.CreateGoogleKmsSigner(
+		ctx,
+		getKmsName(
+			persistentFlags.Project,
+			persistentFlags.Location,
+			persistentFlags.Keyring,
+			persistentFlags.Key,
+		),
+	)
+}
+
+// signer signs messages using the key referenced by a key file without
+// exposing private key to the caller
+type signer interface {
+	PublicKey() common.PublicKey
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+	Close() error
+}
+
+// keySigner signs using decrypted private key held in locked memory
+type keySigner struct {
+	publicKey common.PublicKey
+	key       *secure.Buffer
+}
+
+func (k *keySigner) PublicKey() common.PublicKey {
+	return k.publicKey
+}
+
+func (k *keySigner) Sign(_ context.Context, message []byte) ([]byte, error) {
+	return secure.Sign(k.key.Bytes(), message)
+}
+
+// Close wipes the private key
+func (k *keySigner) Close() error {
+	k.key.Destroy()
+	return nil
+}
+
+// kmsSigner signs using a KMS native signing key, private key never leaves KMS
+type kmsSigner struct {
+	publicKey common.PublicKey
+	signer    backend.Signer
+}
+
+func (k *kmsSigner) PublicKey() common.PublicKey {
+	return k.publicKey
+}
+
+func (k *kmsSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	return k.signer.Sign(ctx, message)
+}
+
+func (k *kmsSigner) Close() error {
+	return k.signer.Close()
+}
+
+// newSigner returns signer for key file, which either holds encrypted keypair
+// that is decrypted into locked memory or references a KMS native signing key.
+// Caller must close the signer.
+func newSigner(ctx context.Context, persistentFlags persistentFlagValues, keyFile string) (signer, error) {
+	header, err := readSigningKeyHeader(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if header != nil {
+		return newKmsSignerFromHeader(ctx, persistentFlags, header)
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return nil, err
+	}
+	defer keyEncrypter.Close()
+
+	account, keyBuffer, err := readAccountFromKeyFile(ctx, keyEncrypter, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keySigner{
+		publicKey: account.PublicKey,
+		key:       keyBuffer,
+	}, nil
+}
+
+// newKmsSignerFromHeader connects to KMS signing key version recorded in key
+// file header and verifies that its public key matches the recorded one
+func newKmsSignerFromHeader(ctx context.Context, persistentFlags persistentFlagValues, header *envelope.Header) (*kmsSigner, error) {
+	if header.Backend != persistentFlags.Backend {
+		err := fmt.Errorf(
+			"signing key is held by %s backend (key version %s), however, %s backend is in use",
+			header.Backend,
+			header.KeyVersion,
+			persistentFlags.Backend,
+		)
+		return nil, err
+	}
+
+	kmsKeySigner, err := newKmsSigner(ctx, persistentFlags, header.KeyVersion)
+	if err != nil {
+		err := fmt.Errorf("could not create kms signer: %w", err)
+		return nil, err
+	}
+
+	publicKey, err := kmsKeySigner.PublicKey(ctx)
+	if err != nil {
+		_ = kmsKeySigner.Close()
+		return nil, err
+	}
+
+	if common.PublicKeyFromBytes(publicKey).ToBase58() != header.PublicKey {
+		_ = kmsKeySigner.Close()
+		err := fmt.Errorf("kms key version %s does not match public key %s recorded in key file", header.KeyVersion, header.PublicKey)
+		return nil, err
+	}
+
+	return &kmsSigner{
+		publicKey: common.PublicKeyFromBytes(publicKey),
+		signer:    kmsKeySigner,
+	}, nil
+}
+
+// readSigningKeyHeader returns header of key file when it references a KMS
+// native signing key and nil when it holds key material
+func readSigningKeyHeader(keyFile string) (*envelope.Header, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		err := fmt.Errorf("error reading input keypair file: %w", err)
+		return nil, err
+	}
+
+	if !envelope.IsEnvelope(data) {
+		return nil, nil
+	}
+
+	e, err := envelope.Unmarshal(data)
+	if err != nil {
+		err := fmt.Errorf("invalid key file: %w", err)
+		return nil, err
+	}
+
+	if e.Header.Role != envelope.RoleSigningKey {
+		return nil, nil
+	}
+
+	return &e.Header, nil
+}
+
+// readPublicKey returns public key of key file. Files referencing KMS native
+// signing keys carry it in their header, other files are decrypted.
+func readPublicKey(ctx context.Context, persistentFlags persistentFlagValues, keyFile string) (string, error) {
+	header, err := readSigningKeyHeader(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	if header != nil {
+		return header.PublicKey, nil
+	}
+
+	keyEncrypter, err := newKeyEncrypter(ctx, persistentFlags)
+	if err != nil {
+		err := fmt.Errorf("could not create key encrypter: %w", err)
+		return "", err
+	}
+	defer keyEncrypter.Close()
+
+	account, keyBuffer, err := readAccountFromKeyFile(ctx, keyEncrypter, keyFile)
+	if err != nil {
+		return "", err
+	}
+	keyBuffer.Destroy()
+
+	return account.PublicKey.ToBase58(), nil
+}
+
+// signingKeyFileData serializes key file referencing KMS native signing key
+func signingKeyFileData(backendType, keyVersion string, publicKey ed25519.PublicKey) ([]byte, error) {
+	return envelope.New(
+		envelope.Header{
+			Backend:    backendType,
+			KeyVersion: keyVersion,
+			Role:       envelope.RoleSigningKey,
+			PublicKey:  common.PublicKeyFromBytes(publicKey).ToBase58(),
+			CreatedAt:  time.Now().UTC(),
+		},
+		nil,
+	).Marshal()
+}
+
+// newEphemeralSigner generates a new keypair in locked memory for accounts such
+// as mints that only need to sign their own creation. Caller must close the
+// signer, after which the private key is gone.
+func newEphemeralSigner() (signer, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		err := fmt.Errorf("could not generate keypair: %w", err)
+		return nil, err
+	}
+
+	key, err := secure.Copy(privateKey)
+	if err != nil {
+		err := fmt.Errorf("could not allocate secure memory: %w", err)
+		return nil, err
+	}
+
+	return &keySigner{
+		publicKey: common.PublicKeyFromBytes(publicKey),
+		key:       key,
+	}, nil
+}
@@ -0,0 +1,204 @@
+package run
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/backend"
+	"github.com/kubetrail/solana-kms/pkg/envelope"
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+// memoryKmsKeys holds KMS native signing keys in memory for tests
+type memoryKmsKeys struct {
+	mu       sync.Mutex
+	versions map[string]ed25519.PrivateKey
+}
+
+// memoryKmsSigner signs with a key version held in memoryKmsKeys
+type memoryKmsSigner struct {
+	keyVersion string
+	privateKey ed25519.PrivateKey
+}
+
+func (m *memoryKmsSigner) PublicKey(context.Context) (ed25519.PublicKey, error) {
+	return m.privateKey.Public().(ed25519.PublicKey), nil
+}
+
+func (m *memoryKmsSigner) Sign(_ context.Context, message []byte) ([]byte, error) {
+	return ed25519.Sign(m.privateKey, message), nil
+}
+
+func (m *memoryKmsSigner) KeyVersion() string {
+	return m.keyVersion
+}
+
+func (m *memoryKmsSigner) Close() error {
+	return nil
+}
+
+// useKmsSigner replaces KMS native signing keys with in memory ones for the
+// duration of the test
+func useKmsSigner(t *testing.T) *memoryKmsKeys {
+	keys := &memoryKmsKeys{versions: make(map[string]ed25519.PrivateKey)}
+
+	orig := newKmsSigner
+	newKmsSigner = func(_ context.Context, _ persistentFlagValues, keyVersion string) (backend.Signer, error) {
+		keys.mu.Lock()
+		defer keys.mu.Unlock()
+
+		if len(keyVersion) == 0 {
+			_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, err
+			}
+			keyVersion = fmt.Sprintf("memory/signer/%d", len(keys.versions)+1)
+			keys.versions[keyVersion] = privateKey
+		}
+
+		privateKey, ok := keys.versions[keyVersion]
+		if !ok {
+			return nil, fmt.Errorf("key version %s not found", keyVersion)
+		}
+
+		return &memoryKmsSigner{keyVersion: keyVersion, privateKey: privateKey}, nil
+	}
+	t.Cleanup(func() { newKmsSigner = orig })
+
+	return keys
+}
+
+func TestKeyNewKmsNative(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keys := useKmsSigner(t)
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id")
+
+	keyNewFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.SeedFile, "", "")
+		f.Bool(flags.Mnemonic, false, "")
+		f.Bool(flags.KmsNative, false, "")
+		f.Bool(flags.Force, false, "")
+		f.Bool(flags.Backup, false, "")
+	}
+	keyShowFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.Bool(flags.PubKey, false, "")
+		f.Bool(flags.Info, false, "")
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", keyFile, "--kms-native"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(keyFile + "." + seedFileExt); !os.IsNotExist(err) {
+		t.Fatalf("expected no seed file for kms native key, got %v", err)
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := envelope.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Header.Role != envelope.RoleSigningKey || e.Header.KeyVersion != "memory/signer/1" || len(e.Ciphertext) != 0 {
+		t.Fatalf("unexpected signing key file header %+v", e.Header)
+	}
+
+	out, err := execute(t, KeyShow, keyShowFlags, "--keyfile", keyFile, "--pubkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != e.Header.PublicKey {
+		t.Fatalf("expected public key %s, got %s", e.Header.PublicKey, out)
+	}
+
+	if _, err := execute(t, KeyShow, keyShowFlags, "--keyfile", keyFile); err == nil ||
+		!strings.Contains(err.Error(), "never leaves KMS") {
+		t.Fatalf("expected showing kms native key to fail, got %v", err)
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", keyFile, "--kms-native"); err == nil {
+		t.Fatal("expected existing key file not to be overwritten")
+	}
+	if len(keys.versions) != 1 {
+		t.Fatalf("expected no new key version to be created, got %d", len(keys.versions))
+	}
+
+	if _, err := execute(t, KeyNew, keyNewFlags, "--keyfile", keyFile, "--kms-native", "--mnemonic"); err == nil {
+		t.Fatal("expected --kms-native with --mnemonic to fail")
+	}
+
+	keyVerifyFlags := func(f *pflag.FlagSet) {
+		f.String(flags.KeyFile, "", "")
+		f.String(flags.InDir, "", "")
+		f.String(flags.PubKey, "", "")
+	}
+	if _, err := execute(t, KeyVerify, keyVerifyFlags, "--indir", dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSignerKmsNative(t *testing.T) {
+	keys := useKmsSigner(t)
+	keyFile := filepath.Join(t.TempDir(), "id")
+
+	kmsKeySigner, err := newKmsSigner(context.Background(), persistentFlagValues{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := kmsKeySigner.PublicKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := signingKeyFileData(backend.Google, kmsKeySigner.KeyVersion(), publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, data, 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	persistentFlags := persistentFlagValues{Backend: backend.Google}
+	keySigner, err := newSigner(context.Background(), persistentFlags, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keySigner.Close()
+
+	message := []byte("hello")
+	signature, err := keySigner.Sign(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, message, signature) {
+		t.Fatal("signature does not verify")
+	}
+
+	// key file is bound to its backend
+	if _, err := newSigner(context.Background(), persistentFlagValues{Backend: backend.Local}, keyFile); err == nil {
+		t.Fatal("expected backend mismatch to fail")
+	}
+
+	// key version replaced in KMS no longer matches recorded public key
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.versions[kmsKeySigner.KeyVersion()] = other
+	if _, err := newSigner(context.Background(), persistentFlags, keyFile); err == nil {
+		t.Fatal("expected public key mismatch to fail")
+	}
+}
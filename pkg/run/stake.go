@@ -0,0 +1,257 @@
+package run
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/portto/solana-go-sdk/client"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/rpc"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+// stake account states as encoded by Stake program
+const (
+	stakeStateUninitialized = "uninitialized"
+	stakeStateInitialized   = "initialized"
+	stakeStateDelegated     = "delegated"
+	stakeStateRewardsPool   = "rewardsPool"
+)
+
+// stakeLockup describes lockup of a stake account, during which only the
+// custodian can withdraw or change withdraw authority
+type stakeLockup struct {
+	UnixTimestamp int64  `json:"unixTimestamp"`
+	Epoch         uint64 `json:"epoch"`
+	Custodian     string `json:"custodian"`
+}
+
+// stakeDelegation describes delegation of a stake account to a vote account
+type stakeDelegation struct {
+	Voter             string  `json:"voter"`
+	Stake             uint64  `json:"stake"`
+	ActivationEpoch   uint64  `json:"activationEpoch"`
+	DeactivationEpoch *uint64 `json:"deactivationEpoch,omitempty"`
+	CreditsObserved   uint64  `json:"creditsObserved"`
+}
+
+// stakeAccountInfo describes a stake account in human readable form
+type stakeAccountInfo struct {
+	Address           string           `json:"address"`
+	Lamports          uint64           `json:"lamports"`
+	Balance           string           `json:"balance"`
+	State             string           `json:"state"`
+	RentExemptReserve uint64           `json:"rentExemptReserve,omitempty"`
+	Staker            string           `json:"staker,omitempty"`
+	Withdrawer        string           `json:"withdrawer,omitempty"`
+	Lockup            *stakeLockup     `json:"lockup,omitempty"`
+	Delegation        *stakeDelegation `json:"delegation,omitempty"`
+}
+
+// decodeStakeAccount decodes bincode serialized state of a stake account, which
+// is an enum of uninitialized, initialized with meta, delegated with meta and
+// stake, or rewards pool
+func decodeStakeAccount(address string, lamports uint64, data []byte) (*stakeAccountInfo, error) {
+	info := &stakeAccountInfo{
+		Address:  address,
+		Lamports: lamports,
+		Balance:  formatAmount(lamports, solDecimals),
+	}
+
+	if len(data) < 4 {
+		err := fmt.Errorf("%s is not a valid stake account", address)
+		return nil, err
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case 0:
+		info.State = stakeStateUninitialized
+		return info, nil
+	case 1:
+		info.State = stakeStateInitialized
+	case 2:
+		info.State = stakeStateDelegated
+	case 3:
+		info.State = stakeStateRewardsPool
+		return info, nil
+	default:
+		err := fmt.Errorf("%s has unknown stake state", address)
+		return nil, err
+	}
+
+	// meta is rent exempt reserve, staker, withdrawer and lockup
+	const metaEnd = 4 + 8 + 32 + 32 + 8 + 8 + 32
+	if len(data) < metaEnd {
+		err := fmt.Errorf("%s is not a valid stake account", address)
+		return nil, err
+	}
+
+	info.RentExemptReserve = binary.LittleEndian.Uint64(data[4:])
+	info.Staker = common.PublicKeyFromBytes(data[12:44]).ToBase58()
+	info.Withdrawer = common.PublicKeyFromBytes(data[44:76]).ToBase58()
+
+	lockup := &stakeLockup{
+		UnixTimestamp: int64(binary.LittleEndian.Uint64(data[76:])),
+		Epoch:         binary.LittleEndian.Uint64(data[84:]),
+		Custodian:     common.PublicKeyFromBytes(data[92:124]).ToBase58(),
+	}
+	if lockup.UnixTimestamp != 0 || lockup.Epoch != 0 || lockup.Custodian != (common.PublicKey{}).ToBase58() {
+		info.Lockup = lockup
+	}
+
+	if info.State != stakeStateDelegated {
+		return info, nil
+	}
+
+	// delegation is voter, stake, activation and deactivation epochs and warmup
+	// cooldown rate, followed by credits observed
+	const stakeEnd = metaEnd + 32 + 8 + 8 + 8 + 8 + 8
+	if len(data) < stakeEnd {
+		err := fmt.Errorf("%s is not a valid stake account", address)
+		return nil, err
+	}
+
+	info.Delegation = &stakeDelegation{
+		Voter:           common.PublicKeyFromBytes(data[metaEnd : metaEnd+32]).ToBase58(),
+		Stake:           binary.LittleEndian.Uint64(data[metaEnd+32:]),
+		ActivationEpoch: binary.LittleEndian.Uint64(data[metaEnd+40:]),
+		CreditsObserved: binary.LittleEndian.Uint64(data[metaEnd+64:]),
+	}
+
+	if deactivationEpoch := binary.LittleEndian.Uint64(data[metaEnd+48:]); deactivationEpoch != math.MaxUint64 {
+		info.Delegation.DeactivationEpoch = &deactivationEpoch
+	}
+
+	return info, nil
+}
+
+// getStakeAccount returns stake account at address, which must exist and be
+// owned by Stake program
+func getStakeAccount(
+	ctx context.Context,
+	c *client.Client,
+	commitment rpc.Commitment,
+	address common.PublicKey,
+) (*stakeAccountInfo, error) {
+	account, err := c.GetAccountInfoWithConfig(
+		ctx,
+		address.ToBase58(),
+		client.GetAccountInfoConfig{Commitment: commitment},
+	)
+	if err != nil {
+		err := fmt.Errorf("could not get account %s: %w", address.ToBase58(), err)
+		return nil, err
+	}
+
+	if len(account.Owner) == 0 {
+		err := fmt.Errorf("stake account %s not found", address.ToBase58())
+		return nil, err
+	}
+
+	if account.Owner != common.StakeProgramID.ToBase58() {
+		err := fmt.Errorf("%s is not a stake account", address.ToBase58())
+		return nil, err
+	}
+
+	return decodeStakeAccount(address.ToBase58(), account.Lamports, account.Data)
+}
+
+// checkStakeAuthority verifies that authority is the staker, or the withdrawer
+// when withdraw is set, of an initialized or delegated stake account
+func checkStakeAuthority(info *stakeAccountInfo, authority common.PublicKey, withdraw bool) error {
+	if info.State != stakeStateInitialized && info.State != stakeStateDelegated {
+		err := fmt.Errorf("stake account %s is %s", info.Address, info.State)
+		return err
+	}
+
+	expected, role := info.Staker, "stake"
+	if withdraw {
+		expected, role = info.Withdrawer, "withdraw"
+	}
+
+	if authority.ToBase58() != expected {
+		err := fmt.Errorf(
+			"%s is not %s authority of %s, which is %s",
+			authority.ToBase58(),
+			role,
+			info.Address,
+			expected,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// authorityAddress returns address of an authority given either as address or
+// as key file, which falls back to defaultAddress when empty
+func authorityAddress(
+	ctx context.Context,
+	persistentFlags persistentFlagValues,
+	value string,
+	defaultAddress common.PublicKey,
+) (common.PublicKey, error) {
+	if len(value) == 0 {
+		return defaultAddress, nil
+	}
+
+	if address, err := parsePublicKey(value); err == nil {
+		return address, nil
+	}
+
+	pubKey, err := readPublicKey(ctx, persistentFlags, value)
+	if err != nil {
+		return common.PublicKey{}, err
+	}
+
+	return parsePublicKey(pubKey)
+}
+
+// maxSeedLength is the longest seed accepted when deriving addresses
+const maxSeedLength = 32
+
+// createStakeAccount returns instruction creating an account owned by Stake
+// program, funded by from. Address is derived from base and seed when seed is
+// set, otherwise address is a new keypair whose signer is returned, which is
+// discarded once the account is created. Caller must close returned signer.
+func createStakeAccount(
+	from, base common.PublicKey,
+	seed string,
+	lamports uint64,
+) (common.PublicKey, types.Instruction, signer, error) {
+	if len(seed) > 0 {
+		if len(seed) > maxSeedLength {
+			err := fmt.Errorf("seed must not be longer than %d bytes", maxSeedLength)
+			return common.PublicKey{}, types.Instruction{}, nil, err
+		}
+
+		address := common.CreateWithSeed(base, seed, common.StakeProgramID)
+		return address, sysprog.CreateAccountWithSeed(sysprog.CreateAccountWithSeedParam{
+			From:     from,
+			New:      address,
+			Base:     base,
+			Owner:    common.StakeProgramID,
+			Seed:     seed,
+			Lamports: lamports,
+			Space:    stakeprog.AccountSize,
+		}), nil, nil
+	}
+
+	accountSigner, err := newEphemeralSigner()
+	if err != nil {
+		return common.PublicKey{}, types.Instruction{}, nil, err
+	}
+
+	address := accountSigner.PublicKey()
+	return address, sysprog.CreateAccount(sysprog.CreateAccountParam{
+		From:     from,
+		New:      address,
+		Owner:    common.StakeProgramID,
+		Lamports: lamports,
+		Space:    stakeprog.AccountSize,
+	}), accountSigner, nil
+}
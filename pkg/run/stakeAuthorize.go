@@ -0,0 +1,120 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeAuthorize sets new stake authority and/or new withdraw authority of stake
+// account, given as addresses or key files. Changes are signed by the current
+// authorities, which are the key unless --stake-authority or
+// --withdraw-authority key files are set.
+func StakeAuthorize(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.StakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.StakeAuthority)))
+	_ = viper.BindPFlag(flags.WithdrawAuthority, cmd.Flags().Lookup(filepath.Base(flags.WithdrawAuthority)))
+	_ = viper.BindPFlag(flags.NewStakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.NewStakeAuthority)))
+	_ = viper.BindPFlag(flags.NewWithdrawAuthority, cmd.Flags().Lookup(filepath.Base(flags.NewWithdrawAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	stakeAuthorityFile := viper.GetString(flags.StakeAuthority)
+	withdrawAuthorityFile := viper.GetString(flags.WithdrawAuthority)
+	newStakeAuthority := viper.GetString(flags.NewStakeAuthority)
+	newWithdrawAuthority := viper.GetString(flags.NewWithdrawAuthority)
+
+	if len(args) != 1 {
+		err := fmt.Errorf("expected stake account as argument")
+		return err
+	}
+
+	if len(newStakeAuthority) == 0 && len(newWithdrawAuthority) == 0 {
+		err := fmt.Errorf(
+			"at least one of --%s and --%s is required",
+			flags.NewStakeAuthority,
+			flags.NewWithdrawAuthority,
+		)
+		return err
+	}
+
+	stake, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	info, err := getStakeAccount(ctx, session.client, session.commitment, stake)
+	if err != nil {
+		return err
+	}
+
+	var instructions []types.Instruction
+	var signers []signer
+
+	// each authorization is signed by the current authority of the same type
+	for _, authorization := range []struct {
+		authorityFile string
+		newAuthority  string
+		authType      stakeprog.StakeAuthorizationType
+		withdraw      bool
+	}{
+		{stakeAuthorityFile, newStakeAuthority, stakeprog.StakeAuthorizationTypeStaker, false},
+		{withdrawAuthorityFile, newWithdrawAuthority, stakeprog.StakeAuthorizationTypeWithdrawer, true},
+	} {
+		if len(authorization.newAuthority) == 0 {
+			continue
+		}
+
+		authority, err := session.authority(ctx, authorization.authorityFile)
+		if err != nil {
+			return err
+		}
+
+		if err := checkStakeAuthority(info, authority.PublicKey(), authorization.withdraw); err != nil {
+			return err
+		}
+
+		newAuthority, err := authorityAddress(ctx, persistentFlags, authorization.newAuthority, common.PublicKey{})
+		if err != nil {
+			return err
+		}
+
+		instructions = append(instructions, stakeprog.Authorize(stakeprog.AuthorizeParam{
+			Stake:    stake,
+			Auth:     authority.PublicKey(),
+			NewAuth:  newAuthority,
+			AuthType: authorization.authType,
+		}))
+		signers = append(signers, authority)
+	}
+
+	signature, err := session.send(ctx, instructions, signers...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
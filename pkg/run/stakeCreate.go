@@ -0,0 +1,126 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeCreate creates a stake account funded with amount of SOL by the key and
+// prints its address. Stake and withdraw authorities default to the key and can
+// be set to other addresses or key files. Stake account address is derived from
+// the key and --seed when set, otherwise it is a new keypair that only signs
+// creation of the account and is discarded afterwards.
+func StakeCreate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.Seed, cmd.Flags().Lookup(filepath.Base(flags.Seed)))
+	_ = viper.BindPFlag(flags.StakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.StakeAuthority)))
+	_ = viper.BindPFlag(flags.WithdrawAuthority, cmd.Flags().Lookup(filepath.Base(flags.WithdrawAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	seed := viper.GetString(flags.Seed)
+	stakeAuthority := viper.GetString(flags.StakeAuthority)
+	withdrawAuthority := viper.GetString(flags.WithdrawAuthority)
+
+	if len(args) != 1 {
+		err := fmt.Errorf("expected amount as argument")
+		return err
+	}
+
+	lamports, err := parseAmount(args[0], solDecimals)
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	key := session.key.PublicKey()
+
+	staker, err := authorityAddress(ctx, persistentFlags, stakeAuthority, key)
+	if err != nil {
+		return err
+	}
+
+	withdrawer, err := authorityAddress(ctx, persistentFlags, withdrawAuthority, key)
+	if err != nil {
+		return err
+	}
+
+	rent, err := session.client.GetMinimumBalanceForRentExemption(ctx, stakeprog.AccountSize)
+	if err != nil {
+		err := fmt.Errorf("could not get rent exempt balance: %w", err)
+		return err
+	}
+
+	if lamports < rent {
+		err := fmt.Errorf(
+			"amount must be at least rent exempt balance of %s SOL",
+			formatAmount(rent, solDecimals),
+		)
+		return err
+	}
+
+	stake, createInstruction, stakeSigner, err := createStakeAccount(key, key, seed, lamports)
+	if err != nil {
+		return err
+	}
+
+	signers := []signer{session.key}
+	if stakeSigner != nil {
+		defer stakeSigner.Close()
+		signers = append(signers, stakeSigner)
+	}
+
+	if account, err := session.getAccount(ctx, stake); err != nil {
+		return err
+	} else if account != nil {
+		err := fmt.Errorf("account %s already exists", stake.ToBase58())
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			createInstruction,
+			stakeprog.Initialize(stakeprog.InitializeParam{
+				Stake: stake,
+				Auth: stakeprog.Authorized{
+					Staker:     staker,
+					Withdrawer: withdrawer,
+				},
+			}),
+		},
+		signers...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "signature %s\n", signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), stake.ToBase58()); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeDeactivate deactivates delegated stake account, after which it can be
+// withdrawn from once cooled down. Deactivation is signed by stake authority,
+// which is the key unless --stake-authority key file is set.
+func StakeDeactivate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.StakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.StakeAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	stakeAuthorityFile := viper.GetString(flags.StakeAuthority)
+
+	if len(args) != 1 {
+		err := fmt.Errorf("expected stake account as argument")
+		return err
+	}
+
+	stake, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	authority, err := session.authority(ctx, stakeAuthorityFile)
+	if err != nil {
+		return err
+	}
+
+	info, err := getStakeAccount(ctx, session.client, session.commitment, stake)
+	if err != nil {
+		return err
+	}
+
+	if err := checkStakeAuthority(info, authority.PublicKey(), false); err != nil {
+		return err
+	}
+
+	if info.Delegation == nil {
+		err := fmt.Errorf("stake account %s is not delegated", info.Address)
+		return err
+	}
+
+	if info.Delegation.DeactivationEpoch != nil {
+		err := fmt.Errorf(
+			"stake account %s is already deactivated in epoch %d",
+			info.Address,
+			*info.Delegation.DeactivationEpoch,
+		)
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			stakeprog.Deactivate(stakeprog.DeactivateParam{
+				Stake: stake,
+				Auth:  authority.PublicKey(),
+			}),
+		},
+		authority,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeMerge merges source stake account into destination stake account, which
+// closes the source. Both accounts must share stake authority, which signs the
+// merge and is the key unless --stake-authority key file is set.
+func StakeMerge(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.StakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.StakeAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	stakeAuthorityFile := viper.GetString(flags.StakeAuthority)
+
+	if len(args) != 2 {
+		err := fmt.Errorf("expected destination and source stake accounts as arguments")
+		return err
+	}
+
+	destination, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	source, err := parsePublicKey(args[1])
+	if err != nil {
+		return err
+	}
+
+	if destination == source {
+		err := fmt.Errorf("cannot merge stake account %s into itself", source.ToBase58())
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	authority, err := session.authority(ctx, stakeAuthorityFile)
+	if err != nil {
+		return err
+	}
+
+	for _, stake := range []common.PublicKey{destination, source} {
+		info, err := getStakeAccount(ctx, session.client, session.commitment, stake)
+		if err != nil {
+			return err
+		}
+
+		if err := checkStakeAuthority(info, authority.PublicKey(), false); err != nil {
+			return err
+		}
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			stakeprog.Merge(stakeprog.MergeParam{
+				From: source,
+				To:   destination,
+				Auth: authority.PublicKey(),
+			}),
+		},
+		authority,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeShow prints state of stake account as JSON, including its authorities,
+// lockup and delegation. No key is needed to show a stake account.
+func StakeShow(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+
+	url := viper.GetString(flags.Url)
+
+	if len(args) != 1 {
+		err := fmt.Errorf("expected stake account as argument")
+		return err
+	}
+
+	stake, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	settings, err := getRpcSettings(url, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	info, err := getStakeAccount(ctx, client.NewClient(settings.endpoint), settings.commitment, stake)
+	if err != nil {
+		return err
+	}
+
+	jb, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not serialize stake account: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeSplit moves amount of SOL from stake account to a new stake account with
+// the same authorities and delegation, and prints address of the new account.
+// New account is prefunded with rent exempt balance by fee payer. Its address
+// is derived from the key and --seed when set, otherwise it is a new keypair
+// that only signs creation of the account and is discarded afterwards.
+func StakeSplit(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.Seed, cmd.Flags().Lookup(filepath.Base(flags.Seed)))
+	_ = viper.BindPFlag(flags.StakeAuthority, cmd.Flags().Lookup(filepath.Base(flags.StakeAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	seed := viper.GetString(flags.Seed)
+	stakeAuthorityFile := viper.GetString(flags.StakeAuthority)
+
+	if len(args) != 2 {
+		err := fmt.Errorf("expected stake account and amount as arguments")
+		return err
+	}
+
+	stake, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	lamports, err := parseAmount(args[1], solDecimals)
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	authority, err := session.authority(ctx, stakeAuthorityFile)
+	if err != nil {
+		return err
+	}
+
+	info, err := getStakeAccount(ctx, session.client, session.commitment, stake)
+	if err != nil {
+		return err
+	}
+
+	if err := checkStakeAuthority(info, authority.PublicKey(), false); err != nil {
+		return err
+	}
+
+	if lamports > info.Lamports {
+		err := fmt.Errorf(
+			"insufficient stake account balance of %s SOL",
+			formatAmount(info.Lamports, solDecimals),
+		)
+		return err
+	}
+
+	rent, err := session.client.GetMinimumBalanceForRentExemption(ctx, stakeprog.AccountSize)
+	if err != nil {
+		err := fmt.Errorf("could not get rent exempt balance: %w", err)
+		return err
+	}
+
+	splitStake, createInstruction, splitSigner, err := createStakeAccount(
+		session.feePayer.PublicKey(),
+		session.key.PublicKey(),
+		seed,
+		rent,
+	)
+	if err != nil {
+		return err
+	}
+
+	signers := []signer{session.key, authority}
+	if splitSigner != nil {
+		defer splitSigner.Close()
+		signers = append(signers, splitSigner)
+	}
+
+	if account, err := session.getAccount(ctx, splitStake); err != nil {
+		return err
+	} else if account != nil {
+		err := fmt.Errorf("account %s already exists", splitStake.ToBase58())
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			createInstruction,
+			stakeprog.Split(stakeprog.SplitParam{
+				Stake:      stake,
+				Auth:       authority.PublicKey(),
+				SplitStake: splitStake,
+				Lamports:   lamports,
+			}),
+		},
+		signers...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "signature %s\n", signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), splitStake.ToBase58()); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
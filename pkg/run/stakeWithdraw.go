@@ -0,0 +1,107 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StakeWithdraw withdraws amount of SOL, or ALL to close the account, from stake
+// account to recipient. Withdrawal is signed by withdraw authority, which is the
+// key unless --withdraw-authority key file is set.
+func StakeWithdraw(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.WithdrawAuthority, cmd.Flags().Lookup(filepath.Base(flags.WithdrawAuthority)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	withdrawAuthorityFile := viper.GetString(flags.WithdrawAuthority)
+
+	if len(args) != 3 {
+		err := fmt.Errorf("expected stake account, recipient and amount as arguments")
+		return err
+	}
+
+	stake, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	recipient, err := parsePublicKey(args[1])
+	if err != nil {
+		return err
+	}
+
+	var lamports uint64
+	all := args[2] == amountAll
+	if !all {
+		lamports, err = parseAmount(args[2], solDecimals)
+		if err != nil {
+			return err
+		}
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	authority, err := session.authority(ctx, withdrawAuthorityFile)
+	if err != nil {
+		return err
+	}
+
+	info, err := getStakeAccount(ctx, session.client, session.commitment, stake)
+	if err != nil {
+		return err
+	}
+
+	if err := checkStakeAuthority(info, authority.PublicKey(), true); err != nil {
+		return err
+	}
+
+	if all {
+		lamports = info.Lamports
+	} else if lamports > info.Lamports {
+		err := fmt.Errorf(
+			"insufficient stake account balance of %s SOL",
+			formatAmount(info.Lamports, solDecimals),
+		)
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			stakeprog.Withdraw(stakeprog.WithdrawParam{
+				Stake:    stake,
+				Auth:     authority.PublicKey(),
+				To:       recipient,
+				Lamports: lamports,
+			}),
+		},
+		authority,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,362 @@
+package run
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/stakeprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func stakeFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.Url, "", "")
+	f.String(flags.FeePayer, "", "")
+	f.String(flags.Seed, "", "")
+	f.String(flags.StakeAuthority, "", "")
+	f.String(flags.WithdrawAuthority, "", "")
+	f.String(flags.NewStakeAuthority, "", "")
+	f.String(flags.NewWithdrawAuthority, "", "")
+}
+
+// stakeData serializes stake account state, which is initialized when voter is
+// empty and delegated to voter otherwise
+func stakeData(staker, withdrawer, voter common.PublicKey, stake, deactivationEpoch uint64) []byte {
+	data := make([]byte, stakeprog.AccountSize)
+	binary.LittleEndian.PutUint32(data, 1)
+	binary.LittleEndian.PutUint64(data[4:], 2282880)
+	copy(data[12:], staker.Bytes())
+	copy(data[44:], withdrawer.Bytes())
+
+	if voter != (common.PublicKey{}) {
+		binary.LittleEndian.PutUint32(data, 2)
+		copy(data[124:], voter.Bytes())
+		binary.LittleEndian.PutUint64(data[156:], stake)
+		binary.LittleEndian.PutUint64(data[164:], 100)
+		binary.LittleEndian.PutUint64(data[172:], deactivationEpoch)
+		binary.LittleEndian.PutUint64(data[188:], 42)
+	}
+
+	return data
+}
+
+// stakeInstruction returns instruction type and data of instruction at index of
+// tx after checking that it belongs to Stake program
+func stakeInstruction(t *testing.T, tx *transaction.Transaction, index int) (stakeprog.Instruction, []byte) {
+	t.Helper()
+
+	instruction := tx.Message.Instructions[index]
+	if programID := tx.Message.AccountKeys[instruction.ProgramIDIndex]; programID != common.StakeProgramID {
+		t.Fatalf("expected Stake program instruction, got program %s", programID.ToBase58())
+	}
+
+	return stakeprog.Instruction(binary.LittleEndian.Uint32(instruction.Data)), instruction.Data[4:]
+}
+
+func TestDecodeStakeAccount(t *testing.T) {
+	staker := types.NewAccount().PublicKey
+	withdrawer := types.NewAccount().PublicKey
+	voter := types.NewAccount().PublicKey
+
+	info, err := decodeStakeAccount("stake", 3000000000, stakeData(staker, withdrawer, voter, 2000000000, math.MaxUint64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.State != stakeStateDelegated || info.Balance != "3" || info.RentExemptReserve != 2282880 ||
+		info.Staker != staker.ToBase58() || info.Withdrawer != withdrawer.ToBase58() || info.Lockup != nil {
+		t.Fatalf("unexpected stake account %+v", info)
+	}
+
+	delegation := info.Delegation
+	if delegation == nil || delegation.Voter != voter.ToBase58() || delegation.Stake != 2000000000 ||
+		delegation.ActivationEpoch != 100 || delegation.DeactivationEpoch != nil || delegation.CreditsObserved != 42 {
+		t.Fatalf("unexpected delegation %+v", delegation)
+	}
+
+	info, err = decodeStakeAccount("stake", 1, stakeData(staker, withdrawer, voter, 1, 120))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Delegation.DeactivationEpoch == nil || *info.Delegation.DeactivationEpoch != 120 {
+		t.Fatalf("expected deactivation epoch 120, got %+v", info.Delegation)
+	}
+
+	info, err = decodeStakeAccount("stake", 1, stakeData(staker, withdrawer, common.PublicKey{}, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State != stakeStateInitialized || info.Delegation != nil {
+		t.Fatalf("expected initialized stake account, got %+v", info)
+	}
+
+	if _, err := decodeStakeAccount("stake", 1, []byte{1, 0, 0, 0}); err == nil {
+		t.Fatal("expected truncated stake account to fail")
+	}
+}
+
+func TestStakeCreate(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	withdrawer := types.NewAccount().PublicKey
+	rpc := newFakeRpc(t)
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	out, err := execute(t, StakeCreate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--withdraw-authority", withdrawer.ToBase58(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stake := strings.TrimSpace(out)
+
+	tx := rpc.Sent[0]
+	signers := tx.Message.Signers()
+	if len(signers) != 2 || signers[0] != key || signers[1].ToBase58() != stake {
+		t.Fatalf("expected key and stake account to sign, got %v", signers)
+	}
+
+	infos := tx.Message.DescribeInstructions()
+	if infos[0].Type != "createAccount" || infos[0].Parsed["owner"] != common.StakeProgramID.ToBase58() ||
+		infos[0].Parsed["lamports"] != uint64(2000000000) || infos[0].Parsed["space"] != stakeprog.AccountSize {
+		t.Fatalf("expected stake account to be created, got %+v", infos[0])
+	}
+
+	instruction, data := stakeInstruction(t, tx, 1)
+	if instruction != stakeprog.InstructionInitialize ||
+		common.PublicKeyFromBytes(data[:32]) != key || common.PublicKeyFromBytes(data[32:64]) != withdrawer {
+		t.Fatalf("unexpected stake initialization %d %x", instruction, data)
+	}
+
+	// seeded stake account is created at address derived from the key
+	out, err = execute(t, StakeCreate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--seed", "stake:0", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := common.CreateWithSeed(key, "stake:0", common.StakeProgramID)
+	if address := strings.TrimSpace(out); address != expected.ToBase58() {
+		t.Fatalf("expected seeded stake account %s, got %s", expected.ToBase58(), address)
+	}
+	if signers := rpc.Sent[1].Message.Signers(); len(signers) != 1 || signers[0] != key {
+		t.Fatalf("expected only key to sign, got %v", signers)
+	}
+
+	if _, err := execute(t, StakeCreate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "0.001"); err == nil ||
+		!strings.Contains(err.Error(), "rent exempt") {
+		t.Fatalf("expected amount below rent exempt balance to fail, got %v", err)
+	}
+}
+
+func TestStakeDelegateAndDeactivate(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	authorityFile, authorityKey := newAgentKeyFile(t)
+	authority := common.PublicKeyFromString(authorityKey)
+
+	// relative path starting with letters of stdin: scheme is used as is
+	chdir(t, filepath.Dir(renameKeyFile(t, authorityFile, "stake-auth.json")))
+	authorityFile = "stake-auth.json"
+
+	stake := types.NewAccount().PublicKey
+	vote := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	rpc.Accounts[stake.ToBase58()] = &fakeAccount{
+		Owner:    common.StakeProgramID,
+		Lamports: 2000000000,
+		Data:     stakeData(authority, key, common.PublicKey{}, 0, 0),
+	}
+	rpc.Accounts[vote.ToBase58()] = &fakeAccount{Owner: common.VoteProgramID, Lamports: 1, Data: []byte{0}}
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, StakeDelegate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58(), vote.ToBase58()); err == nil ||
+		!strings.Contains(err.Error(), "is not stake authority") {
+		t.Fatalf("expected delegation by key that is not stake authority to fail, got %v", err)
+	}
+
+	if _, err := execute(t, StakeDelegate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--stake-authority", authorityFile,
+		stake.ToBase58(), stake.ToBase58()); err == nil || !strings.Contains(err.Error(), "not a vote account") {
+		t.Fatalf("expected delegation to account other than vote account to fail, got %v", err)
+	}
+
+	if _, err := execute(t, StakeDelegate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--stake-authority", authorityFile,
+		stake.ToBase58(), vote.ToBase58()); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := rpc.Sent[0]
+	if signers := tx.Message.Signers(); len(signers) != 2 || signers[0] != key || signers[1] != authority {
+		t.Fatalf("expected key to pay fee and stake authority to sign, got %v", signers)
+	}
+	if instruction, _ := stakeInstruction(t, tx, 0); instruction != stakeprog.InstructionDelegateStake {
+		t.Fatalf("expected delegate instruction, got %d", instruction)
+	}
+
+	if _, err := execute(t, StakeDeactivate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--stake-authority", authorityFile, stake.ToBase58()); err == nil ||
+		!strings.Contains(err.Error(), "is not delegated") {
+		t.Fatalf("expected deactivating undelegated stake to fail, got %v", err)
+	}
+
+	rpc.Accounts[stake.ToBase58()].Data = stakeData(authority, key, vote, 1000000000, math.MaxUint64)
+	if _, err := execute(t, StakeDeactivate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--stake-authority", authorityFile, stake.ToBase58()); err != nil {
+		t.Fatal(err)
+	}
+	if instruction, _ := stakeInstruction(t, rpc.Sent[1], 0); instruction != stakeprog.InstructionDeactivate {
+		t.Fatalf("expected deactivate instruction, got %d", instruction)
+	}
+
+	rpc.Accounts[stake.ToBase58()].Data = stakeData(authority, key, vote, 1000000000, 120)
+	if _, err := execute(t, StakeDeactivate, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--stake-authority", authorityFile, stake.ToBase58()); err == nil ||
+		!strings.Contains(err.Error(), "already deactivated") {
+		t.Fatalf("expected deactivating deactivated stake to fail, got %v", err)
+	}
+}
+
+func TestStakeWithdrawSplitAndMerge(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	stake := types.NewAccount().PublicKey
+	other := types.NewAccount().PublicKey
+	recipient := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	for _, address := range []common.PublicKey{stake, other} {
+		rpc.Accounts[address.ToBase58()] = &fakeAccount{
+			Owner:    common.StakeProgramID,
+			Lamports: 3000000000,
+			Data:     stakeData(key, key, common.PublicKey{}, 0, 0),
+		}
+	}
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, StakeWithdraw, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58(), recipient.ToBase58(), "ALL"); err != nil {
+		t.Fatal(err)
+	}
+	if instruction, data := stakeInstruction(t, rpc.Sent[0], 0); instruction != stakeprog.InstructionWithdraw ||
+		binary.LittleEndian.Uint64(data) != 3000000000 {
+		t.Fatalf("expected entire stake account balance to be withdrawn, got %d %x", instruction, data)
+	}
+
+	if _, err := execute(t, StakeWithdraw, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58(), recipient.ToBase58(), "4"); err == nil ||
+		!strings.Contains(err.Error(), "insufficient stake account balance") {
+		t.Fatalf("expected withdrawing more than balance to fail, got %v", err)
+	}
+
+	out, err := execute(t, StakeSplit, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--seed", "split", stake.ToBase58(), "1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	splitStake := common.CreateWithSeed(key, "split", common.StakeProgramID)
+	if address := strings.TrimSpace(out); address != splitStake.ToBase58() {
+		t.Fatalf("expected split stake account %s, got %s", splitStake.ToBase58(), address)
+	}
+	if instruction, data := stakeInstruction(t, rpc.Sent[1], 1); instruction != stakeprog.InstructionSplit ||
+		binary.LittleEndian.Uint64(data) != 1500000000 {
+		t.Fatalf("unexpected split instruction %d %x", instruction, data)
+	}
+
+	if _, err := execute(t, StakeMerge, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58(), other.ToBase58()); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := rpc.Sent[2]
+	instruction := tx.Message.Instructions[0]
+	if kind, _ := stakeInstruction(t, tx, 0); kind != stakeprog.InstructionMerge ||
+		tx.Message.AccountKeys[instruction.Accounts[0]] != stake ||
+		tx.Message.AccountKeys[instruction.Accounts[1]] != other {
+		t.Fatalf("expected %s to be merged into %s", other.ToBase58(), stake.ToBase58())
+	}
+
+	if _, err := execute(t, StakeMerge, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58(), stake.ToBase58()); err == nil {
+		t.Fatal("expected merging stake account into itself to fail")
+	}
+}
+
+func TestStakeAuthorizeAndShow(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	newAuthorityFile, newAuthorityKey := newAgentKeyFile(t)
+	chdir(t, filepath.Dir(renameKeyFile(t, newAuthorityFile, "stake-auth.json")))
+	newAuthorityFile = "stake-auth.json"
+	newStaker := types.NewAccount().PublicKey
+	stake := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	rpc.Accounts[stake.ToBase58()] = &fakeAccount{
+		Owner:    common.StakeProgramID,
+		Lamports: 3000000000,
+		Data:     stakeData(key, key, common.PublicKey{}, 0, 0),
+	}
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, StakeAuthorize, stakeFlags,
+		"--config", config, "--keyfile", keyFile, stake.ToBase58()); err == nil {
+		t.Fatal("expected authorize without new authority to fail")
+	}
+
+	// new withdraw authority is read from its key file
+	if _, err := execute(t, StakeAuthorize, stakeFlags,
+		"--config", config, "--keyfile", keyFile, "--new-stake-authority", newStaker.ToBase58(),
+		"--new-withdraw-authority", newAuthorityFile, stake.ToBase58()); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := rpc.Sent[0]
+	for i, expected := range []struct {
+		authority common.PublicKey
+		authType  stakeprog.StakeAuthorizationType
+	}{
+		{newStaker, stakeprog.StakeAuthorizationTypeStaker},
+		{common.PublicKeyFromString(newAuthorityKey), stakeprog.StakeAuthorizationTypeWithdrawer},
+	} {
+		instruction, data := stakeInstruction(t, tx, i)
+		if instruction != stakeprog.InstructionAuthorize || common.PublicKeyFromBytes(data[:32]) != expected.authority ||
+			stakeprog.StakeAuthorizationType(binary.LittleEndian.Uint32(data[32:])) != expected.authType {
+			t.Fatalf("unexpected authorize instruction %d %x", instruction, data)
+		}
+	}
+
+	out, err := execute(t, StakeShow, stakeFlags, "--config", config, stake.ToBase58())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info stakeAccountInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Address != stake.ToBase58() || info.State != stakeStateInitialized || info.Staker != publicKey {
+		t.Fatalf("unexpected stake account %+v", info)
+	}
+
+	if _, err := execute(t, StakeShow, stakeFlags, "--config", config, key.ToBase58()); err == nil ||
+		!strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected showing missing stake account to fail, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package run
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+)
+
+// getMint returns mint account at address, which must be an initialized mint
+// of Token program
+func getMint(ctx context.Context, session *rpcSession, mint common.PublicKey) (*tokenprog.MintAccount, error) {
+	account, err := session.getAccount(ctx, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		err := fmt.Errorf("mint %s not found", mint.ToBase58())
+		return nil, err
+	}
+
+	if account.Owner != common.TokenProgramID.ToBase58() {
+		err := fmt.Errorf("%s is not a mint of Token program", mint.ToBase58())
+		return nil, err
+	}
+
+	mintAccount, err := tokenprog.MintAccountFromData(account.Data)
+	if err != nil || !mintAccount.IsInitialized {
+		err := fmt.Errorf("%s is not a valid mint account", mint.ToBase58())
+		return nil, err
+	}
+
+	return &mintAccount, nil
+}
+
+// getTokenAccount returns token account at address, which is nil when account
+// does not exist
+func getTokenAccount(ctx context.Context, session *rpcSession, address common.PublicKey) (*tokenprog.TokenAccount, error) {
+	account, err := session.getAccount(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, nil
+	}
+
+	if account.Owner != common.TokenProgramID.ToBase58() {
+		err := fmt.Errorf("%s is not a token account of Token program", address.ToBase58())
+		return nil, err
+	}
+
+	tokenAccount, err := tokenprog.TokenAccountFromData(account.Data)
+	if err != nil {
+		err := fmt.Errorf("%s is not a valid token account", address.ToBase58())
+		return nil, err
+	}
+
+	return &tokenAccount, nil
+}
+
+// getOwnTokenAccount returns associated token account of owner for mint, which
+// must exist
+func getOwnTokenAccount(
+	ctx context.Context,
+	session *rpcSession,
+	owner, mint common.PublicKey,
+) (common.PublicKey, *tokenprog.TokenAccount, error) {
+	address, err := associatedTokenAddress(owner, mint)
+	if err != nil {
+		return common.PublicKey{}, nil, err
+	}
+
+	tokenAccount, err := getTokenAccount(ctx, session, address)
+	if err != nil {
+		return common.PublicKey{}, nil, err
+	}
+
+	if tokenAccount == nil {
+		err := fmt.Errorf(
+			"token account %s of %s for mint %s not found",
+			address.ToBase58(),
+			owner.ToBase58(),
+			mint.ToBase58(),
+		)
+		return common.PublicKey{}, nil, err
+	}
+
+	if tokenAccount.State == tokenprog.TokenAccountFrozen {
+		err := fmt.Errorf("token account %s is frozen", address.ToBase58())
+		return common.PublicKey{}, nil, err
+	}
+
+	return address, tokenAccount, nil
+}
+
+// associatedTokenAddress returns address of associated token account of owner
+// for mint
+func associatedTokenAddress(owner, mint common.PublicKey) (common.PublicKey, error) {
+	address, _, err := common.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		err := fmt.Errorf("could not derive associated token account address: %w", err)
+		return common.PublicKey{}, err
+	}
+
+	return address, nil
+}
+
+// parseTokenAmount parses decimal token amount, where ALL selects entire
+// balance, which must cover the amount
+func parseTokenAmount(amount string, decimals uint8, balance uint64) (uint64, error) {
+	if amount == amountAll {
+		if balance == 0 {
+			err := fmt.Errorf("token balance is zero")
+			return 0, err
+		}
+		return balance, nil
+	}
+
+	value, err := parseAmount(amount, int(decimals))
+	if err != nil {
+		return 0, err
+	}
+
+	if value > balance {
+		err := fmt.Errorf(
+			"insufficient token balance of %s, %s required",
+			formatAmount(balance, int(decimals)),
+			formatAmount(value, int(decimals)),
+		)
+		return 0, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,307 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/portto/solana-go-sdk/client"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// output formats
+const (
+	outputTable = "table"
+	outputJson  = "json"
+)
+
+// token program names as displayed
+var tokenPrograms = []struct {
+	name      string
+	programID common.PublicKey
+}{
+	{name: "token", programID: common.TokenProgramID},
+	{name: "token-2022", programID: transaction.Token2022ProgramID},
+}
+
+// tokenAccountInfo describes a token account in human readable form
+type tokenAccountInfo struct {
+	Address         string `json:"address"`
+	Label           string `json:"label,omitempty"`
+	Program         string `json:"program"`
+	Mint            string `json:"mint"`
+	MintLabel       string `json:"mintLabel,omitempty"`
+	Owner           string `json:"owner"`
+	Amount          string `json:"amount"`
+	Decimals        *uint8 `json:"decimals,omitempty"`
+	UiAmount        string `json:"uiAmount,omitempty"`
+	Delegate        string `json:"delegate,omitempty"`
+	DelegatedAmount string `json:"delegatedAmount,omitempty"`
+	CloseAuthority  string `json:"closeAuthority,omitempty"`
+	State           string `json:"state"`
+	IsNative        bool   `json:"isNative,omitempty"`
+}
+
+// TokenAccounts lists token accounts of the key, or of --pubkey, held by both
+// Token and Token-2022 programs, along with balances in units of their mints.
+// Addresses are labeled using address labels of Solana config.
+func TokenAccounts(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.PubKey, cmd.Flags().Lookup(filepath.Base(flags.PubKey)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.Output, cmd.Flags().Lookup(filepath.Base(flags.Output)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	pubKey := viper.GetString(flags.PubKey)
+	url := viper.GetString(flags.Url)
+	output := viper.GetString(flags.Output)
+
+	if output != outputTable && output != outputJson {
+		err := fmt.Errorf("invalid output format %s, expected %s or %s", output, outputTable, outputJson)
+		return err
+	}
+
+	settings, err := getRpcSettings(url, persistentFlags)
+	if err != nil {
+		return err
+	}
+
+	if len(pubKey) == 0 {
+		keyFile, err = resolveKeyFile(keyFile, persistentFlags)
+		if err != nil {
+			return err
+		}
+
+		pubKey, err = readPublicKey(ctx, persistentFlags, keyFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	owner, err := parsePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	c := client.NewClient(settings.endpoint)
+
+	var infos []*tokenAccountInfo
+	for _, program := range tokenPrograms {
+		accounts, err := getTokenAccountsByOwner(ctx, c, owner, program.programID, settings.commitment)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			data, err := account.Account.decodeData()
+			if err != nil {
+				return err
+			}
+
+			info, err := describeTokenAccount(account.Pubkey, program.name, data)
+			if err != nil {
+				return err
+			}
+
+			infos = append(infos, info)
+		}
+	}
+
+	if err := setTokenAccountDecimals(ctx, c, settings, infos); err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		info.Label = settings.addressLabels[info.Address]
+		info.MintLabel = settings.addressLabels[info.Mint]
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Program != infos[j].Program {
+			return infos[i].Program < infos[j].Program
+		}
+		if infos[i].Mint != infos[j].Mint {
+			return infos[i].Mint < infos[j].Mint
+		}
+		return infos[i].Address < infos[j].Address
+	})
+
+	if output == outputJson {
+		if infos == nil {
+			infos = []*tokenAccountInfo{}
+		}
+
+		jb, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			err := fmt.Errorf("could not serialize token accounts: %w", err)
+			return err
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			err := fmt.Errorf("could not write to cmd output: %w", err)
+			return err
+		}
+
+		return nil
+	}
+
+	return writeTokenAccountsTable(cmd, infos, settings.addressLabels)
+}
+
+// describeTokenAccount decodes token account data. Token-2022 accounts carry
+// extensions after the layout shared with Token program, which are ignored.
+func describeTokenAccount(address, program string, data []byte) (*tokenAccountInfo, error) {
+	if len(data) < tokenprog.TokenAccountSize {
+		err := fmt.Errorf("%s is not a valid token account", address)
+		return nil, err
+	}
+
+	account, err := tokenprog.TokenAccountFromData(data[:tokenprog.TokenAccountSize])
+	if err != nil {
+		err := fmt.Errorf("%s is not a valid token account: %w", address, err)
+		return nil, err
+	}
+
+	info := &tokenAccountInfo{
+		Address:  address,
+		Program:  program,
+		Mint:     account.Mint.ToBase58(),
+		Owner:    account.Owner.ToBase58(),
+		Amount:   strconv.FormatUint(account.Amount, 10),
+		State:    "initialized",
+		IsNative: account.IsNative != nil,
+	}
+
+	if account.State == tokenprog.TokenAccountFrozen {
+		info.State = "frozen"
+	}
+
+	if account.Delegate != nil {
+		info.Delegate = account.Delegate.ToBase58()
+		info.DelegatedAmount = strconv.FormatUint(account.DelegatedAmount, 10)
+	}
+
+	if account.CloseAuthority != nil {
+		info.CloseAuthority = account.CloseAuthority.ToBase58()
+	}
+
+	return info, nil
+}
+
+// setTokenAccountDecimals fetches mints of token accounts and sets decimals
+// and decimal amounts. Accounts of mints that cannot be found are left in base
+// units.
+func setTokenAccountDecimals(ctx context.Context, c *client.Client, settings *rpcSettings, infos []*tokenAccountInfo) error {
+	var mints []string
+	seen := make(map[string]bool)
+	for _, info := range infos {
+		if !seen[info.Mint] {
+			seen[info.Mint] = true
+			mints = append(mints, info.Mint)
+		}
+	}
+
+	accounts, err := getMultipleAccounts(ctx, c, mints, settings.commitment)
+	if err != nil {
+		return err
+	}
+
+	decimals := make(map[string]uint8)
+	for i, account := range accounts {
+		if account == nil {
+			continue
+		}
+
+		data, err := account.decodeData()
+		if err != nil {
+			return err
+		}
+
+		// decimals are at the same offset for mints of both programs
+		if len(data) < tokenprog.MintAccountSize {
+			continue
+		}
+
+		mint, err := tokenprog.MintAccountFromData(data[:tokenprog.MintAccountSize])
+		if err != nil || !mint.IsInitialized {
+			continue
+		}
+
+		decimals[mints[i]] = mint.Decimals
+	}
+
+	for _, info := range infos {
+		d, ok := decimals[info.Mint]
+		if !ok {
+			continue
+		}
+
+		info.Decimals = &d
+		amount, _ := strconv.ParseUint(info.Amount, 10, 64)
+		info.UiAmount = formatAmount(amount, int(d))
+	}
+
+	return nil
+}
+
+// writeTokenAccountsTable writes token accounts as a table where addresses
+// are replaced with their labels
+func writeTokenAccountsTable(cmd *cobra.Command, infos []*tokenAccountInfo, addressLabels map[string]string) error {
+	label := func(address string) string {
+		if len(address) == 0 {
+			return "-"
+		}
+		if name, ok := addressLabels[address]; ok {
+			return name
+		}
+		return address
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ADDRESS\tPROGRAM\tMINT\tBALANCE\tSTATE\tDELEGATE\tCLOSE AUTHORITY")
+	for _, info := range infos {
+		balance, delegated := info.UiAmount, info.DelegatedAmount
+		if info.Decimals == nil {
+			balance = info.Amount + " (base units)"
+		} else if len(delegated) > 0 {
+			amount, _ := strconv.ParseUint(delegated, 10, 64)
+			delegated = formatAmount(amount, int(*info.Decimals))
+		}
+
+		delegate := label(info.Delegate)
+		if len(info.Delegate) > 0 {
+			delegate = fmt.Sprintf("%s (%s)", delegate, delegated)
+		}
+
+		_, _ = fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			label(info.Address),
+			info.Program,
+			label(info.Mint),
+			balance,
+			info.State,
+			delegate,
+			label(info.CloseAuthority),
+		)
+	}
+
+	if err := w.Flush(); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
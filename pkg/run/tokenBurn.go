@@ -0,0 +1,84 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenBurn burns decimal amount, or ALL, of tokens of mint held in associated
+// token account of the key
+func TokenBurn(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+
+	if len(args) != 2 {
+		err := fmt.Errorf("expected mint and amount as arguments")
+		return err
+	}
+
+	mint, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	owner := session.key.PublicKey()
+
+	mintAccount, err := getMint(ctx, session, mint)
+	if err != nil {
+		return err
+	}
+
+	address, tokenAccount, err := getOwnTokenAccount(ctx, session, owner, mint)
+	if err != nil {
+		return err
+	}
+
+	amount, err := parseTokenAmount(args[1], mintAccount.Decimals, tokenAccount.Amount)
+	if err != nil {
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			tokenprog.BurnChecked(tokenprog.BurnCheckedParam{
+				Account:  address,
+				Auth:     owner,
+				Mint:     mint,
+				Amount:   amount,
+				Decimals: mintAccount.Decimals,
+			}),
+		},
+		session.key,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
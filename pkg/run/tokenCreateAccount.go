@@ -0,0 +1,97 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/assotokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenCreateAccount creates associated token account for mint passed as
+// argument, owned by --owner or the key, funded by the fee payer and prints
+// its address
+func TokenCreateAccount(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.Owner, cmd.Flags().Lookup(filepath.Base(flags.Owner)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	ownerAddress := viper.GetString(flags.Owner)
+
+	if len(args) != 1 {
+		err := fmt.Errorf("expected mint as argument")
+		return err
+	}
+
+	mint, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	owner := session.key.PublicKey()
+	if len(ownerAddress) > 0 {
+		owner, err = parsePublicKey(ownerAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := getMint(ctx, session, mint); err != nil {
+		return err
+	}
+
+	address, err := associatedTokenAddress(owner, mint)
+	if err != nil {
+		return err
+	}
+
+	account, err := session.getAccount(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	if account != nil {
+		err := fmt.Errorf("token account %s already exists", address.ToBase58())
+		return err
+	}
+
+	signature, err := session.send(ctx, []types.Instruction{
+		assotokenprog.CreateAssociatedTokenAccount(assotokenprog.CreateAssociatedTokenAccountParam{
+			Funder:                 session.feePayer.PublicKey(),
+			Owner:                  owner,
+			Mint:                   mint,
+			AssociatedTokenAccount: address,
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "signature %s\n", signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), address.ToBase58()); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
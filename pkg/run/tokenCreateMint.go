@@ -0,0 +1,101 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenCreateMint creates a new token mint with the key as mint authority and
+// prints its address. Mint address is a new keypair that only signs creation
+// of the mint account and is discarded afterwards.
+func TokenCreateMint(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.Decimals, cmd.Flags().Lookup(filepath.Base(flags.Decimals)))
+	_ = viper.BindPFlag(flags.EnableFreeze, cmd.Flags().Lookup(filepath.Base(flags.EnableFreeze)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	decimals := viper.GetInt(flags.Decimals)
+	enableFreeze := viper.GetBool(flags.EnableFreeze)
+
+	// token amounts are u64, which cannot hold more than 19 decimal digits
+	if decimals < 0 || decimals > 19 {
+		err := fmt.Errorf("decimals must be between 0 and 19, got %d", decimals)
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	mintSigner, err := newEphemeralSigner()
+	if err != nil {
+		return err
+	}
+	defer mintSigner.Close()
+
+	rent, err := session.client.GetMinimumBalanceForRentExemption(ctx, tokenprog.MintAccountSize)
+	if err != nil {
+		err := fmt.Errorf("could not get rent exempt balance: %w", err)
+		return err
+	}
+
+	authority := session.key.PublicKey()
+	mint := mintSigner.PublicKey()
+
+	var freezeAuthority *common.PublicKey
+	if enableFreeze {
+		freezeAuthority = &authority
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			sysprog.CreateAccount(sysprog.CreateAccountParam{
+				From:     session.feePayer.PublicKey(),
+				New:      mint,
+				Owner:    common.TokenProgramID,
+				Lamports: rent,
+				Space:    tokenprog.MintAccountSize,
+			}),
+			tokenprog.InitializeMint2(tokenprog.InitializeMint2Param{
+				Decimals:   uint8(decimals),
+				Mint:       mint,
+				MintAuth:   authority,
+				FreezeAuth: freezeAuthority,
+			}),
+		},
+		mintSigner,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "signature %s\n", signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), mint.ToBase58()); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
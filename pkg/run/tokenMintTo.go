@@ -0,0 +1,103 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenMintTo mints decimal amount of tokens of mint to associated token account
+// of --owner or the key. Key must be mint authority of the mint.
+func TokenMintTo(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.Owner, cmd.Flags().Lookup(filepath.Base(flags.Owner)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	ownerAddress := viper.GetString(flags.Owner)
+
+	if len(args) != 2 {
+		err := fmt.Errorf("expected mint and amount as arguments")
+		return err
+	}
+
+	mint, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	authority := session.key.PublicKey()
+	owner := authority
+	if len(ownerAddress) > 0 {
+		owner, err = parsePublicKey(ownerAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	mintAccount, err := getMint(ctx, session, mint)
+	if err != nil {
+		return err
+	}
+
+	if mintAccount.MintAuthority == nil || *mintAccount.MintAuthority != authority {
+		err := fmt.Errorf("%s is not mint authority of %s", authority.ToBase58(), mint.ToBase58())
+		return err
+	}
+
+	amount, err := parseAmount(args[1], int(mintAccount.Decimals))
+	if err != nil {
+		return err
+	}
+
+	if _, err := checkedAdd(mintAccount.Supply, amount); err != nil {
+		err := fmt.Errorf("amount exceeds maximum supply of mint: %w", err)
+		return err
+	}
+
+	address, _, err := getOwnTokenAccount(ctx, session, owner, mint)
+	if err != nil {
+		return err
+	}
+
+	signature, err := session.send(
+		ctx,
+		[]types.Instruction{
+			tokenprog.MintToChecked(tokenprog.MintToCheckedParam{
+				Mint:     mint,
+				Auth:     authority,
+				To:       address,
+				Amount:   amount,
+				Decimals: mintAccount.Decimals,
+			}),
+		},
+		session.key,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
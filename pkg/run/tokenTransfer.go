@@ -0,0 +1,129 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/portto/solana-go-sdk/program/assotokenprog"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenTransfer transfers decimal amount, or ALL, of tokens of mint from
+// associated token account of the key to that of recipient wallet. Recipient
+// token account is created when --fund-recipient is set.
+func TokenTransfer(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.KeyFile, cmd.Flags().Lookup(filepath.Base(flags.KeyFile)))
+	_ = viper.BindPFlag(flags.Url, cmd.Flags().Lookup(filepath.Base(flags.Url)))
+	_ = viper.BindPFlag(flags.FeePayer, cmd.Flags().Lookup(filepath.Base(flags.FeePayer)))
+	_ = viper.BindPFlag(flags.FundRecipient, cmd.Flags().Lookup(filepath.Base(flags.FundRecipient)))
+
+	keyFile := viper.GetString(flags.KeyFile)
+	url := viper.GetString(flags.Url)
+	feePayerFile := viper.GetString(flags.FeePayer)
+	fundRecipient := viper.GetBool(flags.FundRecipient)
+
+	if len(args) != 3 {
+		err := fmt.Errorf("expected mint, recipient and amount as arguments")
+		return err
+	}
+
+	mint, err := parsePublicKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	recipient, err := parsePublicKey(args[1])
+	if err != nil {
+		return err
+	}
+
+	session, err := newRpcSession(ctx, persistentFlags, url, keyFile, feePayerFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	owner := session.key.PublicKey()
+	if recipient == owner {
+		err := fmt.Errorf("recipient %s is the sender", recipient.ToBase58())
+		return err
+	}
+
+	mintAccount, err := getMint(ctx, session, mint)
+	if err != nil {
+		return err
+	}
+
+	from, fromAccount, err := getOwnTokenAccount(ctx, session, owner, mint)
+	if err != nil {
+		return err
+	}
+
+	amount, err := parseTokenAmount(args[2], mintAccount.Decimals, fromAccount.Amount)
+	if err != nil {
+		return err
+	}
+
+	to, err := associatedTokenAddress(recipient, mint)
+	if err != nil {
+		return err
+	}
+
+	toAccount, err := getTokenAccount(ctx, session, to)
+	if err != nil {
+		return err
+	}
+
+	var instructions []types.Instruction
+	if toAccount == nil {
+		if !fundRecipient {
+			err := fmt.Errorf(
+				"recipient %s has no token account for mint %s, use --%s to create it",
+				recipient.ToBase58(),
+				mint.ToBase58(),
+				flags.FundRecipient,
+			)
+			return err
+		}
+
+		instructions = append(instructions,
+			assotokenprog.CreateAssociatedTokenAccount(assotokenprog.CreateAssociatedTokenAccountParam{
+				Funder:                 session.feePayer.PublicKey(),
+				Owner:                  recipient,
+				Mint:                   mint,
+				AssociatedTokenAccount: to,
+			}),
+		)
+	} else if toAccount.State == tokenprog.TokenAccountFrozen {
+		err := fmt.Errorf("token account %s of recipient is frozen", to.ToBase58())
+		return err
+	}
+
+	instructions = append(instructions, tokenprog.TransferChecked(tokenprog.TransferCheckedParam{
+		From:     from,
+		To:       to,
+		Mint:     mint,
+		Auth:     owner,
+		Amount:   amount,
+		Decimals: mintAccount.Decimals,
+	}))
+
+	signature, err := session.send(ctx, instructions, session.key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(cmd.OutOrStdout(), signature); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package run
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/kubetrail/solana-kms/pkg/transaction"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/tokenprog"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/pflag"
+)
+
+func tokenFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.Url, "", "")
+	f.String(flags.FeePayer, "", "")
+	f.Int(flags.Decimals, 9, "")
+	f.Bool(flags.EnableFreeze, false, "")
+	f.String(flags.Owner, "", "")
+	f.Bool(flags.FundRecipient, false, "")
+}
+
+// mintData serializes initialized mint account of Token program
+func mintData(authority common.PublicKey, supply uint64, decimals uint8) []byte {
+	data := make([]byte, tokenprog.MintAccountSize)
+	binary.LittleEndian.PutUint32(data, 1)
+	copy(data[4:], authority.Bytes())
+	binary.LittleEndian.PutUint64(data[36:], supply)
+	data[44] = decimals
+	data[45] = 1
+	return data
+}
+
+// tokenAccountData serializes initialized token account of Token program
+func tokenAccountData(mint, owner common.PublicKey, amount uint64) []byte {
+	data := make([]byte, tokenprog.TokenAccountSize)
+	copy(data, mint.Bytes())
+	copy(data[32:], owner.Bytes())
+	binary.LittleEndian.PutUint64(data[64:], amount)
+	data[108] = byte(tokenprog.TokenAccountStateInitialized)
+	return data
+}
+
+// addTokenAccount adds associated token account of owner for mint to rpc
+func addTokenAccount(t *testing.T, rpc *fakeRpc, mint, owner common.PublicKey, amount uint64) common.PublicKey {
+	t.Helper()
+
+	address, err := associatedTokenAddress(owner, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rpc.Accounts[address.ToBase58()] = &fakeAccount{
+		Owner: common.TokenProgramID,
+		Data:  tokenAccountData(mint, owner, amount),
+	}
+
+	return address
+}
+
+// tokenInstruction returns instruction at index of tx after checking that it
+// belongs to Token program, along with its amount and decimals
+func tokenInstruction(t *testing.T, tx *transaction.Transaction, index int) (byte, uint64, uint8) {
+	t.Helper()
+
+	instruction := tx.Message.Instructions[index]
+	if programID := tx.Message.AccountKeys[instruction.ProgramIDIndex]; programID != common.TokenProgramID {
+		t.Fatalf("expected Token program instruction, got program %s", programID.ToBase58())
+	}
+	if len(instruction.Data) != 10 {
+		t.Fatalf("expected checked instruction data, got %x", instruction.Data)
+	}
+
+	return instruction.Data[0], binary.LittleEndian.Uint64(instruction.Data[1:]), instruction.Data[9]
+}
+
+func TestTokenCreateMint(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	rpc := newFakeRpc(t)
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	out, err := execute(t, TokenCreateMint, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--decimals", "6", "--enable-freeze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint := strings.TrimSpace(out)
+
+	tx := rpc.Sent[0]
+	signers := tx.Message.Signers()
+	if len(signers) != 2 || signers[0].ToBase58() != publicKey || signers[1].ToBase58() != mint {
+		t.Fatalf("expected key and mint to sign, got %v", signers)
+	}
+
+	infos := tx.Message.DescribeInstructions()
+	if infos[0].Type != "createAccount" || infos[0].Parsed["owner"] != common.TokenProgramID.ToBase58() ||
+		infos[0].Parsed["space"] != uint64(tokenprog.MintAccountSize) {
+		t.Fatalf("expected mint account to be created, got %+v", infos[0])
+	}
+
+	// InitializeMint2 with decimals, mint authority and freeze authority
+	data := tx.Message.Instructions[1].Data
+	if data[0] != byte(tokenprog.InstructionInitializeMint2) || data[1] != 6 ||
+		common.PublicKeyFromBytes(data[2:34]).ToBase58() != publicKey || data[34] != 1 {
+		t.Fatalf("unexpected mint initialization %x", data)
+	}
+
+	if _, err := execute(t, TokenCreateMint, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--decimals", "20"); err == nil {
+		t.Fatal("expected too many decimals to fail")
+	}
+}
+
+func TestTokenCreateAccountAndMintTo(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	mint := types.NewAccount().PublicKey
+	owner := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	rpc.Accounts[mint.ToBase58()] = &fakeAccount{Owner: common.TokenProgramID, Data: mintData(key, 0, 6)}
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	out, err := execute(t, TokenCreateAccount, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := associatedTokenAddress(owner, mint)
+	if address := strings.TrimSpace(out); address != expected.ToBase58() {
+		t.Fatalf("expected associated token account %s, got %s", expected.ToBase58(), address)
+	}
+	if programID := rpc.Sent[0].Message.AccountKeys[rpc.Sent[0].Message.Instructions[0].ProgramIDIndex]; programID !=
+		common.SPLAssociatedTokenAccountProgramID {
+		t.Fatalf("expected associated token account program, got %s", programID.ToBase58())
+	}
+
+	// minting requires existing token account
+	if _, err := execute(t, TokenMintTo, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58(), "1.5"); err == nil ||
+		!strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected minting to missing token account to fail, got %v", err)
+	}
+
+	addTokenAccount(t, rpc, mint, owner, 0)
+	if _, err := execute(t, TokenCreateAccount, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58()); err == nil ||
+		!strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected creating existing token account to fail, got %v", err)
+	}
+
+	if _, err := execute(t, TokenMintTo, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58(), "1.5"); err != nil {
+		t.Fatal(err)
+	}
+	if instruction, amount, decimals := tokenInstruction(t, rpc.Sent[1], 0); instruction != byte(tokenprog.InstructionMintToChecked) ||
+		amount != 1500000 || decimals != 6 {
+		t.Fatalf("unexpected mint-to instruction %d of %d with %d decimals", instruction, amount, decimals)
+	}
+
+	if _, err := execute(t, TokenMintTo, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58(), "0.0000001"); err == nil {
+		t.Fatal("expected amount with too many decimal places to fail")
+	}
+
+	rpc.Accounts[mint.ToBase58()].Data = mintData(owner, 0, 6)
+	if _, err := execute(t, TokenMintTo, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--owner", owner.ToBase58(), mint.ToBase58(), "1"); err == nil ||
+		!strings.Contains(err.Error(), "not mint authority") {
+		t.Fatalf("expected minting without mint authority to fail, got %v", err)
+	}
+}
+
+func TestTokenTransferAndBurn(t *testing.T) {
+	useKeyEncrypter(t, newMemoryKeyEncrypter(t))
+	keyFile, publicKey := newAgentKeyFile(t)
+	key := common.PublicKeyFromString(publicKey)
+	mint := types.NewAccount().PublicKey
+	recipient := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	rpc.Accounts[mint.ToBase58()] = &fakeAccount{Owner: common.TokenProgramID, Data: mintData(key, 500, 2)}
+	addTokenAccount(t, rpc, mint, key, 500)
+	config := newRpcConfig(t, rpc.URL, "confirmed")
+
+	if _, err := execute(t, TokenTransfer, tokenFlags,
+		"--config", config, "--keyfile", keyFile, mint.ToBase58(), recipient.ToBase58(), "1"); err == nil ||
+		!strings.Contains(err.Error(), "--fund-recipient") {
+		t.Fatalf("expected transfer to recipient without token account to fail, got %v", err)
+	}
+
+	if _, err := execute(t, TokenTransfer, tokenFlags,
+		"--config", config, "--keyfile", keyFile, "--fund-recipient",
+		mint.ToBase58(), recipient.ToBase58(), "1.25"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rpc.Sent[0].Message.Instructions); n != 2 {
+		t.Fatalf("expected token account creation and transfer, got %d instructions", n)
+	}
+	if instruction, amount, decimals := tokenInstruction(t, rpc.Sent[0], 1); instruction != byte(tokenprog.InstructionTransferChecked) ||
+		amount != 125 || decimals != 2 {
+		t.Fatalf("unexpected transfer instruction %d of %d with %d decimals", instruction, amount, decimals)
+	}
+
+	addTokenAccount(t, rpc, mint, recipient, 0)
+	if _, err := execute(t, TokenTransfer, tokenFlags,
+		"--config", config, "--keyfile", keyFile, mint.ToBase58(), recipient.ToBase58(), "ALL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, amount, _ := tokenInstruction(t, rpc.Sent[1], 0); amount != 500 {
+		t.Fatalf("expected entire token balance to be transferred, got %d", amount)
+	}
+
+	if _, err := execute(t, TokenBurn, tokenFlags,
+		"--config", config, "--keyfile", keyFile, mint.ToBase58(), "0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if instruction, amount, decimals := tokenInstruction(t, rpc.Sent[2], 0); instruction != byte(tokenprog.InstructionBurnChecked) ||
+		amount != 50 || decimals != 2 {
+		t.Fatalf("unexpected burn instruction %d of %d with %d decimals", instruction, amount, decimals)
+	}
+
+	if _, err := execute(t, TokenBurn, tokenFlags,
+		"--config", config, "--keyfile", keyFile, mint.ToBase58(), "5.01"); err == nil ||
+		!strings.Contains(err.Error(), "insufficient token balance") {
+		t.Fatalf("expected burning more than balance to fail, got %v", err)
+	}
+}
+
+func tokenAccountsFlags(f *pflag.FlagSet) {
+	f.String(flags.KeyFile, "", "")
+	f.String(flags.PubKey, "", "")
+	f.String(flags.Url, "", "")
+	f.String(flags.Output, outputTable, "")
+}
+
+func TestTokenAccounts(t *testing.T) {
+	owner := types.NewAccount().PublicKey
+	delegate := types.NewAccount().PublicKey
+	mint := types.NewAccount().PublicKey
+	mint2022 := types.NewAccount().PublicKey
+	closedMint := types.NewAccount().PublicKey
+
+	rpc := newFakeRpc(t)
+	rpc.Accounts[mint.ToBase58()] = &fakeAccount{Owner: common.TokenProgramID, Data: mintData(owner, 0, 2)}
+	// Token-2022 mints and accounts carry account type and extensions
+	rpc.Accounts[mint2022.ToBase58()] = &fakeAccount{
+		Owner: transaction.Token2022ProgramID,
+		Data:  append(append(mintData(owner, 0, 0), make([]byte, 83)...), 1, 0, 0, 0, 0),
+	}
+
+	frozen := addTokenAccount(t, rpc, mint, owner, 12345)
+	data := rpc.Accounts[frozen.ToBase58()].Data
+	binary.LittleEndian.PutUint32(data[72:], 1)
+	copy(data[76:], delegate.Bytes())
+	data[108] = byte(tokenprog.TokenAccountFrozen)
+	binary.LittleEndian.PutUint64(data[121:], 100)
+
+	account2022 := types.NewAccount().PublicKey
+	rpc.Accounts[account2022.ToBase58()] = &fakeAccount{
+		Owner: transaction.Token2022ProgramID,
+		Data:  append(tokenAccountData(mint2022, owner, 7), 2, 0, 0, 0, 0),
+	}
+	addTokenAccount(t, rpc, closedMint, owner, 5)
+	addTokenAccount(t, rpc, mint, types.NewAccount().PublicKey, 1)
+
+	config := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(config, []byte(fmt.Sprintf(
+		"json_rpc_url: %s\naddress_labels:\n  %s: USD\n", rpc.URL, mint.ToBase58())), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execute(t, TokenAccounts, tokenAccountsFlags,
+		"--config", config, "--pubkey", owner.ToBase58(), "--output", outputJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []tokenAccountInfo
+	if err := json.Unmarshal([]byte(out), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 token accounts of owner, got %d", len(infos))
+	}
+
+	byMint := make(map[string]tokenAccountInfo)
+	for _, info := range infos {
+		byMint[info.Mint] = info
+	}
+
+	if info := byMint[mint.ToBase58()]; info.UiAmount != "123.45" || info.State != "frozen" ||
+		info.Delegate != delegate.ToBase58() || info.DelegatedAmount != "100" || info.MintLabel != "USD" {
+		t.Fatalf("unexpected token account %+v", info)
+	}
+	if info := byMint[mint2022.ToBase58()]; info.Program != "token-2022" || info.UiAmount != "7" || *info.Decimals != 0 {
+		t.Fatalf("unexpected Token-2022 account %+v", info)
+	}
+	if info := byMint[closedMint.ToBase58()]; info.Decimals != nil || info.Amount != "5" {
+		t.Fatalf("expected account of missing mint in base units, got %+v", info)
+	}
+
+	out, err = execute(t, TokenAccounts, tokenAccountsFlags, "--config", config, "--pubkey", owner.ToBase58())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"USD", "123.45", "frozen", "(1)", "5 (base units)", "token-2022"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected table to contain %q, got\n%s", expected, out)
+		}
+	}
+
+	if _, err := execute(t, TokenAccounts, tokenAccountsFlags,
+		"--config", config, "--pubkey", owner.ToBase58(), "--output", "yaml"); err == nil {
+		t.Fatal("expected invalid output format to fail")
+	}
+}
@@ -1,12 +1,14 @@
 package run
 
 import (
+	"errors"
 	"fmt"
-	"hash/crc32"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/kubetrail/solana-kms/pkg/backend"
 	"github.com/kubetrail/solana-kms/pkg/flags"
 	"github.com/portto/solana-go-sdk/rpc"
 	"github.com/spf13/cobra"
@@ -43,12 +45,6 @@ func getDefaultConfigFilename() (string, error) {
 	return filepath.Join(homeDir, ".config", "solana", "cli", "config.yml"), nil
 }
 
-// crc32Sum produces crc32 sum
-func crc32Sum(data []byte) uint32 {
-	t := crc32.MakeTable(crc32.Castagnoli)
-	return crc32.Checksum(data, t)
-}
-
 // getKmsName constructs the canonical URI endpoint path for KMS encryption call
 func getKmsName(projectId, kmsLocation, keyringName, keyName string) string {
 	return fmt.Sprintf(
@@ -67,6 +63,17 @@ type persistentFlagValues struct {
 	Location               string `json:"location,omitempty"`
 	Keyring                string `json:"keyring,omitempty"`
 	Key                    string `json:"key,omitempty"`
+	Backend                string `json:"backend,omitempty"`
+	AwsKmsKeyArn           string `json:"awsKmsKeyArn,omitempty"`
+	AwsRegion              string `json:"awsRegion,omitempty"`
+	AwsProfile             string `json:"awsProfile,omitempty"`
+	AwsKmsEndpoint         string `json:"awsKmsEndpoint,omitempty"`
+	VaultAddr              string `json:"vaultAddr,omitempty"`
+	VaultToken             string `json:"vaultToken,omitempty"`
+	VaultTransitMount      string `json:"vaultTransitMount,omitempty"`
+	VaultTransitKey        string `json:"vaultTransitKey,omitempty"`
+	KeyName                string `json:"keyName,omitempty"`
+	Keystore               string `json:"keystore,omitempty"`
 }
 
 func getPersistentFlags(cmd *cobra.Command) persistentFlagValues {
@@ -79,12 +86,33 @@ func getPersistentFlags(cmd *cobra.Command) persistentFlagValues {
 	_ = viper.BindPFlag(flags.KmsKeyring, rootCmd.Lookup(b(flags.KmsKeyring)))
 	_ = viper.BindPFlag(flags.KmsKey, rootCmd.Lookup(b(flags.KmsKey)))
 	_ = viper.BindPFlag(flags.GoogleApplicationCredentials, rootCmd.Lookup(b(flags.GoogleApplicationCredentials)))
+	_ = viper.BindPFlag(flags.Backend, rootCmd.Lookup(b(flags.Backend)))
+	_ = viper.BindPFlag(flags.AwsKmsKeyArn, rootCmd.Lookup(b(flags.AwsKmsKeyArn)))
+	_ = viper.BindPFlag(flags.AwsRegion, rootCmd.Lookup(b(flags.AwsRegion)))
+	_ = viper.BindPFlag(flags.AwsProfile, rootCmd.Lookup(b(flags.AwsProfile)))
+	_ = viper.BindPFlag(flags.AwsKmsEndpoint, rootCmd.Lookup(b(flags.AwsKmsEndpoint)))
+	_ = viper.BindPFlag(flags.VaultAddr, rootCmd.Lookup(b(flags.VaultAddr)))
+	_ = viper.BindPFlag(flags.VaultToken, rootCmd.Lookup(b(flags.VaultToken)))
+	_ = viper.BindPFlag(flags.VaultTransitMount, rootCmd.Lookup(b(flags.VaultTransitMount)))
+	_ = viper.BindPFlag(flags.VaultTransitKey, rootCmd.Lookup(b(flags.VaultTransitKey)))
+	_ = viper.BindPFlag(flags.KeyName, rootCmd.Lookup(b(flags.KeyName)))
+	_ = viper.BindPFlag(flags.Keystore, rootCmd.Lookup(b(flags.Keystore)))
 
 	_ = viper.BindEnv(flags.Config, "SOLANA_CONFIG")
 	_ = viper.BindEnv(flags.GoogleProjectID, "GOOGLE_PROJECT_ID")
 	_ = viper.BindEnv(flags.KmsLocation, "KMS_LOCATION")
 	_ = viper.BindEnv(flags.KmsKeyring, "KMS_KEYRING")
 	_ = viper.BindEnv(flags.KmsKey, "KMS_KEY")
+	_ = viper.BindEnv(flags.Backend, "KMS_BACKEND")
+	_ = viper.BindEnv(flags.AwsKmsKeyArn, "AWS_KMS_KEY_ARN")
+	_ = viper.BindEnv(flags.AwsRegion, "AWS_REGION")
+	_ = viper.BindEnv(flags.AwsProfile, "AWS_PROFILE")
+	_ = viper.BindEnv(flags.AwsKmsEndpoint, "AWS_KMS_ENDPOINT")
+	_ = viper.BindEnv(flags.VaultAddr, "VAULT_ADDR")
+	_ = viper.BindEnv(flags.VaultToken, "VAULT_TOKEN")
+	_ = viper.BindEnv(flags.VaultTransitMount, "VAULT_TRANSIT_MOUNT")
+	_ = viper.BindEnv(flags.VaultTransitKey, "VAULT_TRANSIT_KEY")
+	_ = viper.BindEnv(flags.Keystore, "SOLANA_KMS_KEYSTORE")
 
 	configFile := viper.GetString(flags.Config)
 	applicationCredentials := viper.GetString(flags.GoogleApplicationCredentials)
@@ -92,6 +120,17 @@ func getPersistentFlags(cmd *cobra.Command) persistentFlagValues {
 	location := viper.GetString(flags.KmsLocation)
 	keyring := viper.GetString(flags.KmsKeyring)
 	key := viper.GetString(flags.KmsKey)
+	backend := viper.GetString(flags.Backend)
+	awsKmsKeyArn := viper.GetString(flags.AwsKmsKeyArn)
+	awsRegion := viper.GetString(flags.AwsRegion)
+	awsProfile := viper.GetString(flags.AwsProfile)
+	awsKmsEndpoint := viper.GetString(flags.AwsKmsEndpoint)
+	vaultAddr := viper.GetString(flags.VaultAddr)
+	vaultToken := viper.GetString(flags.VaultToken)
+	vaultTransitMount := viper.GetString(flags.VaultTransitMount)
+	vaultTransitKey := viper.GetString(flags.VaultTransitKey)
+	keyName := viper.GetString(flags.KeyName)
+	keystore := viper.GetString(flags.Keystore)
 
 	return persistentFlagValues{
 		ConfigFile:             configFile,
@@ -100,6 +139,17 @@ func getPersistentFlags(cmd *cobra.Command) persistentFlagValues {
 		Location:               location,
 		Keyring:                keyring,
 		Key:                    key,
+		Backend:                backend,
+		AwsKmsKeyArn:           awsKmsKeyArn,
+		AwsRegion:              awsRegion,
+		AwsProfile:             awsProfile,
+		AwsKmsEndpoint:         awsKmsEndpoint,
+		VaultAddr:              vaultAddr,
+		VaultToken:             vaultToken,
+		VaultTransitMount:      vaultTransitMount,
+		VaultTransitKey:        vaultTransitKey,
+		KeyName:                keyName,
+		Keystore:               keystore,
 	}
 }
 
@@ -131,6 +181,332 @@ func getEndpointFromUrlOrMoniker(url string, configValues *config) string {
 	}
 }
 
+// resolveNewKeyFile resolves key file like resolveKeyFile for commands that write
+// a new key, creating keystore dir when the key is named
+func resolveNewKeyFile(keyFile string, persistentFlags persistentFlagValues) (string, error) {
+	if len(persistentFlags.KeyName) > 0 && len(keyFile) == 0 {
+		return getKeystoreFile(persistentFlags, true)
+	}
+
+	return resolveKeyFile(keyFile, persistentFlags)
+}
+
+// resolveKeyFile returns keyFile if set, otherwise the named key in keystore when
+// a key name is provided, otherwise falls back to keypair path from Solana config
+// file. Scheme prefix such as stdin: is removed.
+func resolveKeyFile(keyFile string, persistentFlags persistentFlagValues) (string, error) {
+	if len(persistentFlags.KeyName) > 0 {
+		if len(keyFile) > 0 {
+			err := fmt.Errorf("--%s and --%s cannot be used together", flags.KeyFile, flags.KeyName)
+			return "", err
+		}
+
+		return getKeystoreFile(persistentFlags, false)
+	}
+
+	if len(keyFile) == 0 {
+		if len(persistentFlags.ConfigFile) == 0 {
+			var err error
+			persistentFlags.ConfigFile, err = getDefaultConfigFilename()
+			if err != nil {
+				err := fmt.Errorf("could not get default config filename: %w", err)
+				return "", err
+			}
+		}
+
+		configValues, err := getConfigValues(persistentFlags.ConfigFile)
+		if err != nil {
+			err := fmt.Errorf("could not get config values: %w", err)
+			return "", err
+		}
+
+		if configValues == nil || len(configValues.KeypairPath) == 0 {
+			err := fmt.Errorf("could not find a valid keypair path from config file")
+			return "", err
+		}
+
+		keyFile = configValues.KeypairPath
+	}
+
+	return removeSchemeFromPath(keyFile), nil
+}
+
+// removeSchemeFromPath removes stdin: scheme prefix from key file path
 func removeSchemeFromPath(input string) string {
-	return strings.TrimLeft(input, "stdin:")
+	return strings.TrimPrefix(input, "stdin:")
+}
+
+// withTargetKey returns a copy of persistent flag values where key name of the
+// selected backend is replaced with targetKey
+func withTargetKey(persistentFlags persistentFlagValues, targetKey string) persistentFlagValues {
+	switch persistentFlags.Backend {
+	case backend.Aws:
+		persistentFlags.AwsKmsKeyArn = targetKey
+	case backend.Vault:
+		persistentFlags.VaultTransitKey = targetKey
+	default:
+		persistentFlags.Key = targetKey
+	}
+
+	return persistentFlags
+}
+
+// atomicWriteFile writes data to a temporary file in the same directory, syncs it
+// to disk and renames it over name so that readers never observe a partial file
+func atomicWriteFile(name string, data []byte, perm os.FileMode) error {
+	tmpName, err := writeTempFile(name, data, perm)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if err := os.Rename(tmpName, name); err != nil {
+		err := fmt.Errorf("could not rename temp file: %w", err)
+		return err
+	}
+
+	return syncDir(filepath.Dir(name))
+}
+
+// atomicCreateFile writes data to a new file name, failing with an error that
+// matches os.ErrExist when name already exists. Temp file is linked rather than
+// renamed into place since rename would replace a file created concurrently.
+func atomicCreateFile(name string, data []byte, perm os.FileMode) error {
+	tmpName, err := writeTempFile(name, data, perm)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if err := os.Link(tmpName, name); err != nil {
+		err := fmt.Errorf("could not link temp file: %w", err)
+		return err
+	}
+
+	return syncDir(filepath.Dir(name))
+}
+
+// writeTempFile writes data to a hidden temporary file next to name and syncs
+// it to disk. Name of the temporary file is returned.
+func writeTempFile(name string, data []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), fmt.Sprintf(".%s.tmp-*", filepath.Base(name)))
+	if err != nil {
+		err := fmt.Errorf("could not create temp file: %w", err)
+		return "", err
+	}
+	tmpName := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpName)
+		err := fmt.Errorf("could not write temp file: %w", err)
+		return "", err
+	}
+
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpName)
+		err := fmt.Errorf("could not set temp file permissions: %w", err)
+		return "", err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpName)
+		err := fmt.Errorf("could not sync temp file: %w", err)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		err := fmt.Errorf("could not close temp file: %w", err)
+		return "", err
+	}
+
+	return tmpName, nil
+}
+
+// checkFilesAbsent refuses existing files unless force is set. Commands call it
+// before doing anything that cannot be repeated, such as showing a new mnemonic.
+func checkFilesAbsent(force bool, names ...string) error {
+	if force {
+		return nil
+	}
+
+	for _, name := range names {
+		if _, err := os.Lstat(name); err == nil {
+			err := fmt.Errorf("refusing to overwrite existing file %s, use --%s to overwrite", name, flags.Force)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pendingFile is a file to be written by writeFilesAtomically
+type pendingFile struct {
+	// Name is the destination file name
+	Name string
+	// Data is the file content
+	Data []byte
+	// Backup is the name under which an existing file is kept. Existing
+	// file is discarded once all files are in place when empty.
+	Backup string
+}
+
+// writeFilesAtomically writes files as a single transaction. Existing files are
+// not overwritten unless force is set. All data is first written to synced temp
+// files, existing files are then backed up or moved aside and temp files put into
+// place. Existing backups are never overwritten. If any step fails, files written
+// so far are removed and previous files restored.
+func writeFilesAtomically(files []*pendingFile, perm os.FileMode, force bool) error {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+
+	if err := checkFilesAbsent(force, names...); err != nil {
+		return err
+	}
+
+	tmpNames := make([]string, len(files))
+	defer func() {
+		for _, tmpName := range tmpNames {
+			if len(tmpName) > 0 {
+				_ = os.Remove(tmpName)
+			}
+		}
+	}()
+
+	for i, file := range files {
+		tmpName, err := writeTempFile(file.Name, file.Data, perm)
+		if err != nil {
+			return err
+		}
+		tmpNames[i] = tmpName
+	}
+
+	// prevNames holds where existing files were moved aside to, backups
+	// tracks backups linked to existing files and placed tracks which
+	// destination files now hold new data
+	prevNames := make([]string, len(files))
+	backups := make([]bool, len(files))
+	placed := make([]bool, len(files))
+	rollback := func() {
+		for i, file := range files {
+			if placed[i] {
+				_ = os.Remove(file.Name)
+			}
+			if len(prevNames[i]) > 0 {
+				_ = os.Rename(prevNames[i], file.Name)
+			}
+			if backups[i] {
+				_ = os.Remove(file.Backup)
+			}
+		}
+	}
+
+	// existing files are only replaced when forced, in which case they are
+	// linked to their backup names, which unlike rename fails when a backup
+	// of that name already exists, and then moved aside
+	for i, file := range files {
+		if !force {
+			break
+		}
+
+		if _, err := os.Lstat(file.Name); err != nil {
+			continue
+		}
+
+		if len(file.Backup) > 0 {
+			if err := os.Link(file.Name, file.Backup); err != nil {
+				rollback()
+				err := fmt.Errorf("could not back up existing file %s: %w", file.Name, err)
+				return err
+			}
+			backups[i] = true
+		}
+
+		prevName := fmt.Sprintf("%s.prev", tmpNames[i])
+		if err := os.Rename(file.Name, prevName); err != nil {
+			rollback()
+			err := fmt.Errorf("could not move existing file %s aside: %w", file.Name, err)
+			return err
+		}
+		prevNames[i] = prevName
+	}
+
+	// without force temp files are linked into place so that files created
+	// since the check above are not replaced
+	for i, file := range files {
+		place := os.Rename
+		if !force {
+			place = os.Link
+		}
+
+		if err := place(tmpNames[i], file.Name); err != nil {
+			rollback()
+			if errors.Is(err, os.ErrExist) {
+				err := fmt.Errorf("refusing to overwrite existing file %s, use --%s to overwrite", file.Name, flags.Force)
+				return err
+			}
+			err := fmt.Errorf("could not move temp file to %s: %w", file.Name, err)
+			return err
+		}
+		placed[i] = true
+	}
+
+	for _, prevName := range prevNames {
+		if len(prevName) > 0 {
+			_ = os.Remove(prevName)
+		}
+	}
+
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		dir := filepath.Dir(file.Name)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+
+		if err := syncDir(dir); err != nil {
+			err := fmt.Errorf("could not sync dir %s: %w", dir, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyFileBackupSuffix returns a timestamped suffix for backups of key files
+func keyFileBackupSuffix() string {
+	return fmt.Sprintf("%s-%s", backupFileExt, time.Now().UTC().Format("20060102T150405Z"))
+}
+
+// writeKeyFilePair writes keypair and seed ciphertext to keyFile and keyFile.seed
+// as a single transaction. When backup is set, existing files are kept with a
+// timestamp suffix placed in front of the seed extension so that backups remain a pair.
+func writeKeyFilePair(keyFile string, keyCiphertext, seedCiphertext []byte, force, backup bool) error {
+	keyPending := &pendingFile{Name: keyFile, Data: keyCiphertext}
+	seedPending := &pendingFile{Name: fmt.Sprintf("%s.%s", keyFile, seedFileExt), Data: seedCiphertext}
+
+	if backup {
+		backupFile := fmt.Sprintf("%s.%s", keyFile, keyFileBackupSuffix())
+		keyPending.Backup = backupFile
+		seedPending.Backup = fmt.Sprintf("%s.%s", backupFile, seedFileExt)
+	}
+
+	return writeFilesAtomically([]*pendingFile{keyPending, seedPending}, 0400, force)
+}
+
+// syncDir syncs directory so that renames within it are durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
 }
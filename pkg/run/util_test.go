@@ -0,0 +1,154 @@
+package run
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTestFile(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteKeyFilePair(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id")
+
+	if err := writeKeyFilePair(keyFile, []byte("key1"), []byte("seed1"), false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeKeyFilePair(keyFile, []byte("key2"), []byte("seed2"), false, false); err == nil {
+		t.Fatal("expected error overwriting existing key files without force")
+	}
+
+	if readTestFile(t, keyFile) != "key1" || readTestFile(t, keyFile+".seed") != "seed1" {
+		t.Fatal("existing key files were modified")
+	}
+
+	if err := writeKeyFilePair(keyFile, []byte("key2"), []byte("seed2"), true, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if readTestFile(t, keyFile) != "key2" || readTestFile(t, keyFile+".seed") != "seed2" {
+		t.Fatal("key files were not overwritten")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	// id, id.seed and a backup pair, without leftover temp files
+	if len(names) != 4 {
+		t.Fatalf("unexpected files: %v", names)
+	}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, "id.bak-") {
+			continue
+		}
+		if strings.HasSuffix(name, ".seed") {
+			if readTestFile(t, filepath.Join(dir, name)) != "seed1" {
+				t.Fatal("seed backup does not hold previous seed")
+			}
+		} else if readTestFile(t, filepath.Join(dir, name)) != "key1" {
+			t.Fatal("keypair backup does not hold previous keypair")
+		}
+	}
+}
+
+func TestWriteFilesAtomicallyRollback(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	for name, data := range map[string]string{a: "old a", b: "old b"} {
+		if err := os.WriteFile(name, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// moving b aside fails since backup dir does not exist, which must
+	// restore a as well
+	err := writeFilesAtomically([]*pendingFile{
+		{Name: a, Data: []byte("new a")},
+		{Name: b, Data: []byte("new b"), Backup: filepath.Join(dir, "missing", "b")},
+	}, 0400, true)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if readTestFile(t, a) != "old a" || readTestFile(t, b) != "old b" {
+		t.Fatal("previous files were not restored")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected temp files to be removed, found %d files", len(entries))
+	}
+}
+
+func TestWriteFilesAtomicallyKeepsExistingBackup(t *testing.T) {
+	dir := t.TempDir()
+	a, backup := filepath.Join(dir, "a"), filepath.Join(dir, "a.bak")
+
+	for name, data := range map[string]string{a: "old a", backup: "older a"} {
+		if err := os.WriteFile(name, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writeFilesAtomically([]*pendingFile{
+		{Name: a, Data: []byte("new a"), Backup: backup},
+	}, 0400, true); err == nil {
+		t.Fatal("expected error replacing existing backup")
+	}
+
+	if readTestFile(t, a) != "old a" || readTestFile(t, backup) != "older a" {
+		t.Fatal("existing file or backup was modified")
+	}
+}
+
+func TestAtomicCreateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "share")
+
+	if err := atomicCreateFile(name, []byte("first"), 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := atomicCreateFile(name, []byte("second"), 0400); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected existing file error, got %v", err)
+	}
+
+	if readTestFile(t, name) != "first" {
+		t.Fatal("existing file was overwritten")
+	}
+}
+
+func TestRemoveSchemeFromPath(t *testing.T) {
+	for input, expected := range map[string]string{
+		"stdin:/tmp/key": "/tmp/key",
+		"stdin:":         "",
+		"solPay1":        "solPay1",
+		"signer.json":    "signer.json",
+		"/tmp/stdin:key": "/tmp/stdin:key",
+	} {
+		if output := removeSchemeFromPath(input); output != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, input, output)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package run
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubetrail/solana-kms/pkg/flags"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// VerifyMessage verifies signature of a message passed as argument, or read from
+// --input file or stdin, against a base58 public key. Message is serialized the
+// same way as by SignMessage. No key is decrypted.
+func VerifyMessage(cmd *cobra.Command, args []string) error {
+	_ = viper.BindPFlag(flags.PubKey, cmd.Flags().Lookup(filepath.Base(flags.PubKey)))
+	_ = viper.BindPFlag(flags.Signature, cmd.Flags().Lookup(filepath.Base(flags.Signature)))
+	_ = viper.BindPFlag(flags.Input, cmd.Flags().Lookup(filepath.Base(flags.Input)))
+	_ = viper.BindPFlag(flags.Offchain, cmd.Flags().Lookup(filepath.Base(flags.Offchain)))
+	_ = viper.BindPFlag(flags.Encoding, cmd.Flags().Lookup(filepath.Base(flags.Encoding)))
+
+	pubKey := viper.GetString(flags.PubKey)
+	signature := viper.GetString(flags.Signature)
+	input := viper.GetString(flags.Input)
+	isOffchain := viper.GetBool(flags.Offchain)
+	encoding := viper.GetString(flags.Encoding)
+
+	if len(pubKey) == 0 || len(signature) == 0 {
+		err := fmt.Errorf("--%s and --%s are required", flags.PubKey, flags.Signature)
+		return err
+	}
+
+	publicKey, err := base58.Decode(pubKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		err := fmt.Errorf("invalid public key %s", pubKey)
+		return err
+	}
+
+	signatureBytes, err := decodeData(strings.TrimSpace(signature), encoding)
+	if err != nil {
+		return err
+	}
+
+	if len(signatureBytes) != ed25519.SignatureSize {
+		err := fmt.Errorf("invalid signature length %d, expected %d", len(signatureBytes), ed25519.SignatureSize)
+		return err
+	}
+
+	message, err := readMessage(cmd, args, input)
+	if err != nil {
+		return err
+	}
+
+	data, err := messageToSign(message, isOffchain)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, data, signatureBytes) {
+		err := fmt.Errorf("signature is not valid for public key %s", pubKey)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "signature is valid for public key %s\n", pubKey); err != nil {
+		err := fmt.Errorf("could not write to cmd output: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// Sign signs message using 64 byte ed25519 private key as specified in RFC 8032.
+// Unlike crypto/ed25519, which caches expanded keys keyed by their address and
+// therefore cannot operate on memory outside of the Go heap, intermediate key
+// material is wiped before returning, so private key may live in a Buffer.
+func Sign(privateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		err := fmt.Errorf("invalid private key length %d", len(privateKey))
+		return nil, err
+	}
+
+	seed, publicKey := privateKey[:ed25519.SeedSize], privateKey[ed25519.SeedSize:]
+
+	h := sha512.Sum512(seed)
+	defer Wipe(h[:])
+
+	s, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		return nil, err
+	}
+	defer s.Set(edwards25519.NewScalar())
+
+	digest := sha512.New()
+	_, _ = digest.Write(h[32:])
+	_, _ = digest.Write(message)
+	nonce := digest.Sum(nil)
+	defer Wipe(nonce)
+
+	r, err := edwards25519.NewScalar().SetUniformBytes(nonce)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Set(edwards25519.NewScalar())
+
+	R := (&edwards25519.Point{}).ScalarBaseMult(r)
+
+	digest.Reset()
+	_, _ = digest.Write(R.Bytes())
+	_, _ = digest.Write(publicKey)
+	_, _ = digest.Write(message)
+
+	k, err := edwards25519.NewScalar().SetUniformBytes(digest.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	S := edwards25519.NewScalar().MultiplyAdd(k, s, r)
+
+	signature := make([]byte, 0, ed25519.SignatureSize)
+	signature = append(signature, R.Bytes()...)
+	signature = append(signature, S.Bytes()...)
+	return signature, nil
+}
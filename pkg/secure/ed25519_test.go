@@ -0,0 +1,40 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello")
+	want := ed25519.Sign(privateKey, message)
+
+	b, err := Copy(append([]byte{}, privateKey...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+
+	signature, err := Sign(b.Bytes(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(signature, want) {
+		t.Fatal("signature does not match crypto/ed25519")
+	}
+
+	if !ed25519.Verify(publicKey, message, signature) {
+		t.Fatal("signature does not verify")
+	}
+
+	if _, err := Sign(b.Bytes()[:32], message); err == nil {
+		t.Fatal("expected short private key to be rejected")
+	}
+}
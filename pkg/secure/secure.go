@@ -0,0 +1,57 @@
+// Package secure provides memory for key material that is kept out of swap and
+// core dumps where the platform allows it and is wiped as soon as it is released.
+package secure
+
+// Buffer holds sensitive data in memory that is locked, excluded from core
+// dumps and wiped on Destroy. Locking is best effort since it is subject to
+// RLIMIT_MEMLOCK, use Locked to find out whether it succeeded.
+type Buffer struct {
+	data   []byte
+	mem    []byte
+	locked bool
+}
+
+// Copy returns a new buffer holding a copy of src and wipes src
+func Copy(src []byte) (*Buffer, error) {
+	b, err := NewBuffer(len(src))
+	if err != nil {
+		Wipe(src)
+		return nil, err
+	}
+
+	copy(b.data, src)
+	Wipe(src)
+	return b, nil
+}
+
+// Bytes returns buffer contents, which must not be used after Destroy
+func (b *Buffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	return b.data
+}
+
+// Locked reports whether buffer memory is locked against swapping
+func (b *Buffer) Locked() bool {
+	return b != nil && b.locked
+}
+
+// Destroy wipes and releases buffer. It is safe to call on a nil buffer
+// and more than once.
+func (b *Buffer) Destroy() {
+	if b == nil || b.mem == nil {
+		return
+	}
+
+	Wipe(b.mem)
+	release(b.mem, b.locked)
+	b.data, b.mem, b.locked = nil, nil, false
+}
+
+// Wipe overwrites data with zeros
+func Wipe(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package secure
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// NewBuffer allocates a zeroed buffer of given size outside of Go heap so that
+// it is never copied by the runtime, then locks it and excludes it from core dumps
+func NewBuffer(size int) (*Buffer, error) {
+	pageSize := os.Getpagesize()
+	length := (size + pageSize - 1) / pageSize * pageSize
+	if length == 0 {
+		length = pageSize
+	}
+
+	mem, err := unix.Mmap(-1, 0, length, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		err := fmt.Errorf("could not allocate secure memory: %w", err)
+		return nil, err
+	}
+
+	_ = unix.Madvise(mem, unix.MADV_DONTDUMP)
+
+	return &Buffer{
+		data:   mem[:size],
+		mem:    mem,
+		locked: unix.Mlock(mem) == nil,
+	}, nil
+}
+
+func release(mem []byte, locked bool) {
+	if locked {
+		_ = unix.Munlock(mem)
+	}
+	_ = unix.Munmap(mem)
+}
+
+// DisableCoreDumps prevents the process from writing core dumps, which would
+// otherwise contain any key material held in memory at the time of a crash.
+// It also prevents other processes of the same user from attaching to it.
+func DisableCoreDumps() error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{}); err != nil {
+		err := fmt.Errorf("could not set core dump size limit: %w", err)
+		return err
+	}
+
+	if err := unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		err := fmt.Errorf("could not mark process as not dumpable: %w", err)
+		return err
+	}
+
+	return nil
+}
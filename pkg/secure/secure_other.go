@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package secure
+
+// NewBuffer allocates a zeroed buffer of given size. Memory locking is not
+// supported on this platform, so data is only wiped on Destroy.
+func NewBuffer(size int) (*Buffer, error) {
+	mem := make([]byte, size)
+	return &Buffer{data: mem, mem: mem}, nil
+}
+
+func release([]byte, bool) {}
+
+// DisableCoreDumps is not supported on this platform and does nothing
+func DisableCoreDumps() error {
+	return nil
+}
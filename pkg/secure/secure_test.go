@@ -0,0 +1,49 @@
+package secure
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCopyAndDestroy(t *testing.T) {
+	src := []byte("this is a private key")
+	want := append([]byte{}, src...)
+
+	b, err := Copy(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Fatal("buffer does not hold copied data")
+	}
+
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Fatal("source was not wiped")
+	}
+
+	b.Destroy()
+	b.Destroy()
+
+	if b.Bytes() != nil || b.Locked() {
+		t.Fatal("destroyed buffer still exposes data")
+	}
+
+	var nilBuffer *Buffer
+	nilBuffer.Destroy()
+	if nilBuffer.Bytes() != nil {
+		t.Fatal("nil buffer returned data")
+	}
+}
+
+func TestNewBufferZeroSize(t *testing.T) {
+	b, err := NewBuffer(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+
+	if len(b.Bytes()) != 0 {
+		t.Fatal("expected empty buffer")
+	}
+}
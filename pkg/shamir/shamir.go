@@ -0,0 +1,140 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+//
+// Each byte of the secret is shared independently using a random polynomial
+// of degree threshold-1 whose constant term is the secret byte. A share is
+// serialized as its x coordinate followed by one y coordinate per secret byte.
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+var (
+	// expTable and logTable hold powers and discrete logarithms of the
+	// generator 3 in GF(2^8) with AES reduction polynomial x^8+x^4+x^3+x+1
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		// multiply by generator 3, i.e. x*2 xor x
+		x ^= xtime(x)
+	}
+}
+
+// xtime multiplies by 2 in GF(2^8)
+func xtime(x byte) byte {
+	if x&0x80 != 0 {
+		return x<<1 ^ 0x1b
+	}
+	return x << 1
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+// Split splits secret into n shares such that any threshold of them can
+// reconstruct the secret while fewer reveal nothing about it
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	switch {
+	case len(secret) == 0:
+		return nil, fmt.Errorf("secret cannot be empty")
+	case threshold < 2:
+		return nil, fmt.Errorf("threshold must be at least 2")
+	case n < threshold:
+		return nil, fmt.Errorf("number of shares cannot be less than threshold")
+	case n > 255:
+		return nil, fmt.Errorf("number of shares cannot exceed 255")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for j, s := range secret {
+		coefficients[0] = s
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("could not generate random coefficients: %w", err)
+		}
+
+		for _, share := range shares {
+			// evaluate polynomial at x using Horner's method
+			x, y := share[0], byte(0)
+			for k := threshold - 1; k >= 0; k-- {
+				y = mul(y, x) ^ coefficients[k]
+			}
+			share[j+1] = y
+		}
+	}
+
+	for i := range coefficients {
+		coefficients[i] = 0
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs secret from shares using Lagrange interpolation at zero.
+// At least threshold shares used in Split must be provided, otherwise result
+// is garbage that cannot be detected as such here.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least two shares are required")
+	}
+
+	size := len(shares[0])
+	if size < 2 {
+		return nil, fmt.Errorf("share is too short")
+	}
+
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != size {
+			return nil, fmt.Errorf("shares have different lengths")
+		}
+		if share[0] == 0 {
+			return nil, fmt.Errorf("share has invalid x coordinate")
+		}
+		if seen[share[0]] {
+			return nil, fmt.Errorf("duplicate share %d", share[0])
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, size-1)
+	for i, share := range shares {
+		// lagrange basis polynomial for share i evaluated at zero
+		basis := byte(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(other[0], other[0]^share[0]))
+		}
+
+		for k := range secret {
+			secret[k] ^= mul(basis, share[k+1])
+		}
+	}
+
+	return secret, nil
+}
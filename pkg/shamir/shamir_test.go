@@ -0,0 +1,70 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, got %d", len(shares))
+	}
+
+	// every subset of threshold shares reconstructs the secret
+	for a := 0; a < 5; a++ {
+		for b := a + 1; b < 5; b++ {
+			for c := b + 1; c < 5; c++ {
+				combined, err := Combine([][]byte{shares[c], shares[a], shares[b]})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(combined, secret) {
+					t.Fatalf("shares %d, %d, %d did not reconstruct secret", a, b, c)
+				}
+			}
+		}
+	}
+
+	combined, err := Combine(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(combined, secret) {
+		t.Fatal("all shares did not reconstruct secret")
+	}
+
+	combined, err = Combine(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(combined, secret) {
+		t.Fatal("fewer than threshold shares reconstructed secret")
+	}
+
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Fatal("expected error combining duplicate shares")
+	}
+}
+
+func TestSplitValidation(t *testing.T) {
+	for _, tc := range []struct{ n, threshold int }{{3, 1}, {2, 3}, {256, 2}} {
+		if _, err := Split([]byte("secret"), tc.n, tc.threshold); err == nil {
+			t.Fatalf("expected error for %d shares with threshold %d", tc.n, tc.threshold)
+		}
+	}
+}
+
+func TestFieldInverse(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if mul(byte(a), div(1, byte(a))) != 1 {
+			t.Fatalf("%d has no inverse", a)
+		}
+	}
+}
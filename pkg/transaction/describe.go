@@ -0,0 +1,175 @@
+package transaction
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"unicode/utf8"
+
+	"github.com/portto/solana-go-sdk/common"
+)
+
+var (
+	ComputeBudgetProgramID      = common.PublicKeyFromString("ComputeBudget111111111111111111111111111111")
+	MemoProgramID               = common.PublicKeyFromString("MemoSq4gqABAXKb96qnH8TysNcWxMyWCqXgDLGmfcHr")
+	Token2022ProgramID          = common.PublicKeyFromString("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+	AddressLookupTableProgramID = common.PublicKeyFromString("AddressLookupTab1e1111111111111111111111111")
+)
+
+// programNames maps well known program IDs to human readable names
+var programNames = map[common.PublicKey]string{
+	common.SystemProgramID:                    "System Program",
+	common.StakeProgramID:                     "Stake Program",
+	common.VoteProgramID:                      "Vote Program",
+	common.ConfigProgramID:                    "Config Program",
+	common.TokenProgramID:                     "Token Program",
+	common.SPLAssociatedTokenAccountProgramID: "Associated Token Account Program",
+	common.MetaplexTokenMetaProgramID:         "Metaplex Token Metadata Program",
+	Token2022ProgramID:                        "Token-2022 Program",
+	ComputeBudgetProgramID:                    "Compute Budget Program",
+	MemoProgramID:                             "Memo Program",
+	AddressLookupTableProgramID:               "Address Lookup Table Program",
+}
+
+// systemInstructions names System Program instructions by their index
+var systemInstructions = []string{
+	"createAccount",
+	"assign",
+	"transfer",
+	"createAccountWithSeed",
+	"advanceNonceAccount",
+	"withdrawNonceAccount",
+	"initializeNonceAccount",
+	"authorizeNonceAccount",
+	"allocate",
+	"allocateWithSeed",
+	"assignWithSeed",
+	"transferWithSeed",
+	"upgradeNonceAccount",
+}
+
+// AccountInfo describes an account referenced by an instruction
+type AccountInfo struct {
+	PublicKey   string `json:"publicKey,omitempty"`
+	LookupTable string `json:"lookupTable,omitempty"`
+	LookupIndex *uint8 `json:"lookupIndex,omitempty"`
+	Signer      bool   `json:"signer"`
+	Writable    bool   `json:"writable"`
+}
+
+// InstructionInfo describes an instruction in human readable form. Type and
+// Parsed are only set for instructions of well known programs.
+type InstructionInfo struct {
+	ProgramID string                 `json:"programId"`
+	Program   string                 `json:"program,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	Parsed    map[string]interface{} `json:"parsed,omitempty"`
+	Accounts  []AccountInfo          `json:"accounts,omitempty"`
+	Data      string                 `json:"data,omitempty"`
+}
+
+// Account describes account at index, which for accounts loaded from an
+// address lookup table cannot be resolved without querying the table
+func (m *Message) Account(index int) AccountInfo {
+	info := AccountInfo{
+		Signer:   m.IsSigner(index),
+		Writable: m.IsWritable(index),
+	}
+
+	if table, tableIndex, ok := m.LookupAccount(index); ok {
+		info.LookupTable = table.ToBase58()
+		info.LookupIndex = &tableIndex
+		return info
+	}
+
+	info.PublicKey = m.AccountKeys[index].ToBase58()
+	return info
+}
+
+// DescribeInstructions describes instructions of the message
+func (m *Message) DescribeInstructions() []InstructionInfo {
+	infos := make([]InstructionInfo, len(m.Instructions))
+	for i, instruction := range m.Instructions {
+		programID := m.AccountKeys[instruction.ProgramIDIndex]
+
+		info := InstructionInfo{
+			ProgramID: programID.ToBase58(),
+			Program:   programNames[programID],
+			Accounts:  make([]AccountInfo, len(instruction.Accounts)),
+			Data:      hex.EncodeToString(instruction.Data),
+		}
+
+		for j, index := range instruction.Accounts {
+			info.Accounts[j] = m.Account(int(index))
+		}
+
+		switch programID {
+		case common.SystemProgramID:
+			info.Type, info.Parsed = describeSystemInstruction(instruction.Data)
+		case ComputeBudgetProgramID:
+			info.Type, info.Parsed = describeComputeBudgetInstruction(instruction.Data)
+		case MemoProgramID:
+			if utf8.Valid(instruction.Data) {
+				info.Type = "memo"
+				info.Parsed = map[string]interface{}{"memo": string(instruction.Data)}
+			}
+		}
+
+		infos[i] = info
+	}
+
+	return infos
+}
+
+// describeSystemInstruction names System Program instruction and decodes amount
+// of lamports it moves, when it does
+func describeSystemInstruction(data []byte) (string, map[string]interface{}) {
+	if len(data) < 4 {
+		return "", nil
+	}
+
+	index := binary.LittleEndian.Uint32(data)
+	if int(index) >= len(systemInstructions) {
+		return "", nil
+	}
+	name := systemInstructions[index]
+
+	var parsed map[string]interface{}
+	switch name {
+	case "createAccount":
+		// lamports, space and owner
+		if len(data) == 4+8+8+common.PublicKeyLength {
+			parsed = map[string]interface{}{
+				"lamports": binary.LittleEndian.Uint64(data[4:]),
+				"space":    binary.LittleEndian.Uint64(data[12:]),
+				"owner":    common.PublicKeyFromBytes(data[20:]).ToBase58(),
+			}
+		}
+	case "transfer", "withdrawNonceAccount":
+		if len(data) == 4+8 {
+			parsed = map[string]interface{}{"lamports": binary.LittleEndian.Uint64(data[4:])}
+		}
+	}
+
+	return name, parsed
+}
+
+// describeComputeBudgetInstruction names Compute Budget Program instruction
+// and decodes its value
+func describeComputeBudgetInstruction(data []byte) (string, map[string]interface{}) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	switch {
+	case data[0] == 1 && len(data) == 5:
+		return "requestHeapFrame", map[string]interface{}{"bytes": binary.LittleEndian.Uint32(data[1:])}
+	case data[0] == 2 && len(data) == 5:
+		return "setComputeUnitLimit", map[string]interface{}{"units": binary.LittleEndian.Uint32(data[1:])}
+	case data[0] == 3 && len(data) == 9:
+		return "setComputeUnitPrice", map[string]interface{}{"microLamports": binary.LittleEndian.Uint64(data[1:])}
+	case data[0] == 4 && len(data) == 5:
+		return "setLoadedAccountsDataSizeLimit", map[string]interface{}{"bytes": binary.LittleEndian.Uint32(data[1:])}
+	default:
+		return "", nil
+	}
+}
@@ -0,0 +1,492 @@
+// Package transaction decodes serialized Solana transactions, both legacy and
+// version 0 ones that reference address lookup tables, so that they can be
+// inspected and signed without altering the serialized message.
+package transaction
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/portto/solana-go-sdk/common"
+)
+
+// Legacy is version of messages that carry no version prefix
+const Legacy = -1
+
+// SignatureLength is length of ed25519 signature
+const SignatureLength = 64
+
+// Header describes how accounts of a message are to be treated
+type Header struct {
+	NumRequiredSignatures       uint8
+	NumReadonlySignedAccounts   uint8
+	NumReadonlyUnsignedAccounts uint8
+}
+
+// Instruction refers to program and accounts via indexes into account keys
+// of the message followed by accounts loaded from address lookup tables
+type Instruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// AddressTableLookup loads accounts from an address lookup table
+type AddressTableLookup struct {
+	AccountKey      common.PublicKey
+	WritableIndexes []uint8
+	ReadonlyIndexes []uint8
+}
+
+// Message is a decoded transaction message. Serialized form is kept as is so
+// that signatures are always computed over exactly the bytes that were decoded.
+type Message struct {
+	Version             int
+	Header              Header
+	AccountKeys         []common.PublicKey
+	RecentBlockhash     common.PublicKey
+	Instructions        []Instruction
+	AddressTableLookups []AddressTableLookup
+
+	data []byte
+}
+
+// Transaction is a message along with one signature slot per required signer.
+// Slots of missing signatures are zero filled.
+type Transaction struct {
+	Signatures [][]byte
+	Message    *Message
+}
+
+// Decode parses serialized transaction
+func Decode(data []byte) (*Transaction, error) {
+	d := &decoder{data: data}
+
+	numSignatures, err := d.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signature count: %w", err)
+	}
+
+	signatures := make([][]byte, numSignatures)
+	for i := range signatures {
+		if signatures[i], err = d.bytes(SignatureLength); err != nil {
+			return nil, fmt.Errorf("could not parse signature %d: %w", i, err)
+		}
+	}
+
+	message, err := ParseMessage(d.data)
+	if err != nil {
+		return nil, err
+	}
+
+	if numSignatures != int(message.Header.NumRequiredSignatures) {
+		return nil, fmt.Errorf(
+			"transaction has %d signature slots, however, message requires %d signatures",
+			numSignatures,
+			message.Header.NumRequiredSignatures,
+		)
+	}
+
+	return &Transaction{
+		Signatures: signatures,
+		Message:    message,
+	}, nil
+}
+
+// ParseMessage parses and sanitizes serialized legacy or version 0 message
+func ParseMessage(data []byte) (*Message, error) {
+	d := &decoder{data: data}
+
+	m, err := d.messagePrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	if m.RecentBlockhash, err = d.publicKey(); err != nil {
+		return nil, fmt.Errorf("could not parse recent blockhash: %w", err)
+	}
+
+	numInstructions, err := d.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse instruction count: %w", err)
+	}
+
+	m.Instructions = make([]Instruction, numInstructions)
+	for i := range m.Instructions {
+		if m.Instructions[i], err = d.instruction(); err != nil {
+			return nil, fmt.Errorf("could not parse instruction %d: %w", i, err)
+		}
+	}
+
+	if m.Version == 0 {
+		numLookups, err := d.compactU16()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse address table lookup count: %w", err)
+		}
+
+		m.AddressTableLookups = make([]AddressTableLookup, numLookups)
+		for i := range m.AddressTableLookups {
+			if m.AddressTableLookups[i], err = d.addressTableLookup(); err != nil {
+				return nil, fmt.Errorf("could not parse address table lookup %d: %w", i, err)
+			}
+		}
+	}
+
+	if len(d.data) > 0 {
+		return nil, fmt.Errorf("message has %d trailing bytes", len(d.data))
+	}
+
+	if err := m.sanitize(); err != nil {
+		return nil, err
+	}
+
+	m.data = append([]byte{}, data...)
+	return m, nil
+}
+
+// Signers parses serialized message only as far as its account keys and
+// returns public keys of accounts required to sign it. Unlike ParseMessage, rest
+// of the message is not validated, so that anything that could pass as a
+// transaction message is recognized as one.
+func Signers(data []byte) ([]common.PublicKey, error) {
+	d := &decoder{data: data}
+
+	m, err := d.messagePrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	if int(m.Header.NumRequiredSignatures) > len(m.AccountKeys) {
+		return nil, errors.New("message requires more signatures than there are account keys")
+	}
+
+	return m.Signers(), nil
+}
+
+// sanitize performs the same structural checks as the runtime does, so that
+// a message that would be rejected on chain is not signed
+func (m *Message) sanitize() error {
+	numAccounts := len(m.AccountKeys)
+
+	if int(m.Header.NumRequiredSignatures)+int(m.Header.NumReadonlyUnsignedAccounts) > numAccounts {
+		return errors.New("message header references more accounts than there are account keys")
+	}
+
+	// fee payer must be writable
+	if m.Header.NumReadonlySignedAccounts >= m.Header.NumRequiredSignatures {
+		return errors.New("message has no writable signer to pay fees")
+	}
+
+	for i, lookup := range m.AddressTableLookups {
+		if len(lookup.WritableIndexes) == 0 && len(lookup.ReadonlyIndexes) == 0 {
+			return fmt.Errorf("address table lookup %d loads no accounts", i)
+		}
+	}
+
+	total := m.NumAccounts()
+	if total > 256 {
+		return fmt.Errorf("message references %d accounts, at most 256 are allowed", total)
+	}
+
+	for i, instruction := range m.Instructions {
+		// programs cannot be loaded from lookup tables nor pay fees
+		if index := int(instruction.ProgramIDIndex); index == 0 || index >= numAccounts {
+			return fmt.Errorf("instruction %d has invalid program index %d", i, index)
+		}
+
+		for _, index := range instruction.Accounts {
+			if int(index) >= total {
+				return fmt.Errorf("instruction %d references account index %d out of range", i, index)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Serialize returns serialized message exactly as it was decoded
+func (m *Message) Serialize() []byte {
+	return append([]byte{}, m.data...)
+}
+
+// NumAccounts returns number of accounts including the ones loaded from
+// address lookup tables
+func (m *Message) NumAccounts() int {
+	n := len(m.AccountKeys)
+	for _, lookup := range m.AddressTableLookups {
+		n += len(lookup.WritableIndexes) + len(lookup.ReadonlyIndexes)
+	}
+
+	return n
+}
+
+// Signers returns public keys of accounts required to sign the message in the
+// order of signature slots
+func (m *Message) Signers() []common.PublicKey {
+	return m.AccountKeys[:m.Header.NumRequiredSignatures]
+}
+
+// SignerIndex returns signature slot of public key or -1 if it is not a
+// required signer
+func (m *Message) SignerIndex(publicKey common.PublicKey) int {
+	for i, signer := range m.Signers() {
+		if signer == publicKey {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// IsSigner reports whether account at index is required to sign
+func (m *Message) IsSigner(index int) bool {
+	return index < int(m.Header.NumRequiredSignatures)
+}
+
+// IsWritable reports whether account at index is writable. Accounts loaded
+// from lookup tables follow account keys, writable ones of all tables first.
+func (m *Message) IsWritable(index int) bool {
+	numRequiredSignatures := int(m.Header.NumRequiredSignatures)
+	numAccountKeys := len(m.AccountKeys)
+
+	switch {
+	case index < numRequiredSignatures:
+		return index < numRequiredSignatures-int(m.Header.NumReadonlySignedAccounts)
+	case index < numAccountKeys:
+		return index < numAccountKeys-int(m.Header.NumReadonlyUnsignedAccounts)
+	}
+
+	index -= numAccountKeys
+	for _, lookup := range m.AddressTableLookups {
+		index -= len(lookup.WritableIndexes)
+	}
+
+	return index < 0
+}
+
+// LookupAccount returns address lookup table and index within it for account
+// at index that is loaded from a lookup table. It returns false for accounts
+// that are listed in account keys.
+func (m *Message) LookupAccount(index int) (common.PublicKey, uint8, bool) {
+	index -= len(m.AccountKeys)
+	if index < 0 {
+		return common.PublicKey{}, 0, false
+	}
+
+	for _, lookup := range m.AddressTableLookups {
+		if index < len(lookup.WritableIndexes) {
+			return lookup.AccountKey, lookup.WritableIndexes[index], true
+		}
+		index -= len(lookup.WritableIndexes)
+	}
+
+	for _, lookup := range m.AddressTableLookups {
+		if index < len(lookup.ReadonlyIndexes) {
+			return lookup.AccountKey, lookup.ReadonlyIndexes[index], true
+		}
+		index -= len(lookup.ReadonlyIndexes)
+	}
+
+	return common.PublicKey{}, 0, false
+}
+
+// Serialize packs transaction along with its signatures
+func (tx *Transaction) Serialize() []byte {
+	data := encodeCompactU16(len(tx.Signatures))
+	for _, signature := range tx.Signatures {
+		data = append(data, signature...)
+	}
+
+	return append(data, tx.Message.data...)
+}
+
+// AddSignature places signature of public key in its signature slot,
+// replacing any existing signature
+func (tx *Transaction) AddSignature(publicKey common.PublicKey, signature []byte) error {
+	if len(signature) != SignatureLength {
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+
+	index := tx.Message.SignerIndex(publicKey)
+	if index < 0 {
+		return fmt.Errorf("%s is not a required signer of the transaction", publicKey.ToBase58())
+	}
+
+	tx.Signatures[index] = append([]byte{}, signature...)
+	return nil
+}
+
+// IsSigned reports whether signature slot at index is filled
+func (tx *Transaction) IsSigned(index int) bool {
+	for _, b := range tx.Signatures[index] {
+		if b != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decoder consumes serialized data from the front
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) bytes(n int) ([]byte, error) {
+	if len(d.data) < n {
+		return nil, errors.New("unexpected end of data")
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *decoder) byte() (uint8, error) {
+	b, err := d.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+func (d *decoder) publicKey() (common.PublicKey, error) {
+	b, err := d.bytes(common.PublicKeyLength)
+	if err != nil {
+		return common.PublicKey{}, err
+	}
+
+	return common.PublicKeyFromBytes(b), nil
+}
+
+// compactU16 decodes shortvec length prefix used in Solana serialization
+func (d *decoder) compactU16() (int, error) {
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 || n > 3 || value > 0xffff || (n > 1 && d.data[n-1] == 0) {
+		return 0, errors.New("invalid compact-u16 encoding")
+	}
+
+	d.data = d.data[n:]
+	return int(value), nil
+}
+
+// compactBytes decodes byte array prefixed with its compact-u16 length
+func (d *decoder) compactBytes() ([]byte, error) {
+	n, err := d.compactU16()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, b...), nil
+}
+
+// messagePrefix decodes version, header and account keys of a message
+func (d *decoder) messagePrefix() (*Message, error) {
+	m := &Message{Version: Legacy}
+
+	if len(d.data) == 0 {
+		return nil, errors.New("message is empty")
+	}
+
+	// versioned messages have the high bit of the first byte set
+	if d.data[0]&0x80 != 0 {
+		if m.Version = int(d.data[0] & 0x7f); m.Version != 0 {
+			return nil, fmt.Errorf("unsupported message version %d", m.Version)
+		}
+		d.data = d.data[1:]
+	}
+
+	header, err := d.bytes(3)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse message header: %w", err)
+	}
+	m.Header = Header{
+		NumRequiredSignatures:       header[0],
+		NumReadonlySignedAccounts:   header[1],
+		NumReadonlyUnsignedAccounts: header[2],
+	}
+
+	if m.Header.NumRequiredSignatures == 0 {
+		return nil, errors.New("message requires no signatures")
+	}
+
+	numAccounts, err := d.compactU16()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse account count: %w", err)
+	}
+
+	m.AccountKeys = make([]common.PublicKey, numAccounts)
+	for i := range m.AccountKeys {
+		if m.AccountKeys[i], err = d.publicKey(); err != nil {
+			return nil, fmt.Errorf("could not parse account key %d: %w", i, err)
+		}
+	}
+
+	return m, nil
+}
+
+func (d *decoder) instruction() (Instruction, error) {
+	programIDIndex, err := d.byte()
+	if err != nil {
+		return Instruction{}, err
+	}
+
+	accounts, err := d.compactBytes()
+	if err != nil {
+		return Instruction{}, fmt.Errorf("could not parse accounts: %w", err)
+	}
+
+	data, err := d.compactBytes()
+	if err != nil {
+		return Instruction{}, fmt.Errorf("could not parse data: %w", err)
+	}
+
+	return Instruction{
+		ProgramIDIndex: programIDIndex,
+		Accounts:       accounts,
+		Data:           data,
+	}, nil
+}
+
+func (d *decoder) addressTableLookup() (AddressTableLookup, error) {
+	accountKey, err := d.publicKey()
+	if err != nil {
+		return AddressTableLookup{}, err
+	}
+
+	writableIndexes, err := d.compactBytes()
+	if err != nil {
+		return AddressTableLookup{}, fmt.Errorf("could not parse writable indexes: %w", err)
+	}
+
+	readonlyIndexes, err := d.compactBytes()
+	if err != nil {
+		return AddressTableLookup{}, fmt.Errorf("could not parse readonly indexes: %w", err)
+	}
+
+	return AddressTableLookup{
+		AccountKey:      accountKey,
+		WritableIndexes: writableIndexes,
+		ReadonlyIndexes: readonlyIndexes,
+	}, nil
+}
+
+// encodeCompactU16 encodes shortvec length prefix
+func encodeCompactU16(n int) []byte {
+	var b []byte
+	for {
+		c := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
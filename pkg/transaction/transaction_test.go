@@ -0,0 +1,201 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/sysprog"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+var (
+	testFrom  = common.PublicKeyFromString("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g")
+	testTo    = common.PublicKeyFromString("BjGqpZpbLZEinDeAZnHLCp8GJR7pcrqmvXJB2G7BJpoq")
+	testTable = common.StakeProgramID
+)
+
+// legacyTransfer returns serialized legacy transfer transaction with empty
+// signature slots for fee payer and from accounts
+func legacyTransfer(t *testing.T, feePayer, from common.PublicKey) []byte {
+	t.Helper()
+
+	message := types.NewMessage(types.NewMessageParam{
+		FeePayer: feePayer,
+		Instructions: []types.Instruction{
+			sysprog.Transfer(sysprog.TransferParam{From: from, To: testTo, Amount: 1000}),
+		},
+		RecentBlockhash: common.SystemProgramID.ToBase58(),
+	})
+
+	data, err := message.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := int(message.Header.NumRequireSignatures)
+	tx := append([]byte{byte(n)}, make([]byte, n*SignatureLength)...)
+	return append(tx, data...)
+}
+
+// v0Transfer returns serialized v0 transfer message, where recipient is loaded
+// from an address lookup table
+func v0Transfer() []byte {
+	transfer := []byte{2, 0, 0, 0, 0xe8, 0x03, 0, 0, 0, 0, 0, 0}
+
+	message := []byte{0x80, 1, 0, 1, 2}
+	message = append(message, testFrom.Bytes()...)
+	message = append(message, common.SystemProgramID.Bytes()...)
+	message = append(message, common.SystemProgramID.Bytes()...) // blockhash
+	message = append(message, 1, 1, 2, 0, 2, byte(len(transfer)))
+	message = append(message, transfer...)
+	message = append(message, 1)
+	message = append(message, testTable.Bytes()...)
+	message = append(message, 1, 7, 0)
+
+	return message
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	data := legacyTransfer(t, testFrom, testFrom)
+
+	tx, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Message.Version != Legacy || len(tx.Signatures) != 1 || tx.IsSigned(0) {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+	if !bytes.Equal(tx.Serialize(), data) {
+		t.Fatal("serialized transaction does not round trip")
+	}
+
+	infos := tx.Message.DescribeInstructions()
+	if len(infos) != 1 || infos[0].Type != "transfer" || infos[0].Parsed["lamports"] != uint64(1000) {
+		t.Fatalf("unexpected instructions %+v", infos)
+	}
+	if from := infos[0].Accounts[0]; from.PublicKey != testFrom.ToBase58() || !from.Signer || !from.Writable {
+		t.Fatalf("unexpected from account %+v", from)
+	}
+	if to := infos[0].Accounts[1]; to.PublicKey != testTo.ToBase58() || to.Signer || !to.Writable {
+		t.Fatalf("unexpected to account %+v", to)
+	}
+}
+
+func TestAddSignature(t *testing.T) {
+	feePayer := types.NewAccount()
+	from := types.NewAccount()
+
+	tx, err := Decode(legacyTransfer(t, feePayer.PublicKey, from.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.Signatures) != 2 {
+		t.Fatalf("expected 2 signature slots, got %d", len(tx.Signatures))
+	}
+
+	message := tx.Message.Serialize()
+	if err := tx.AddSignature(from.PublicKey, ed25519.Sign(from.PrivateKey, message)); err != nil {
+		t.Fatal(err)
+	}
+
+	index := tx.Message.SignerIndex(from.PublicKey)
+	if tx.IsSigned(1-index) || !tx.IsSigned(index) {
+		t.Fatal("signature was not placed in signer slot")
+	}
+
+	if err := tx.AddSignature(testTo, make([]byte, SignatureLength)); err == nil {
+		t.Fatal("expected adding signature of non signer to fail")
+	}
+
+	// partially signed transaction decodes with its signature intact
+	decoded, err := Decode(tx.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(from.PublicKey.Bytes(), decoded.Message.Serialize(), decoded.Signatures[index]) {
+		t.Fatal("signature does not verify after round trip")
+	}
+}
+
+func TestParseMessageV0(t *testing.T) {
+	message, err := ParseMessage(v0Transfer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Version != 0 || message.NumAccounts() != 3 {
+		t.Fatalf("unexpected message %+v", message)
+	}
+
+	infos := message.DescribeInstructions()
+	if len(infos) != 1 || infos[0].Type != "transfer" {
+		t.Fatalf("unexpected instructions %+v", infos)
+	}
+
+	to := infos[0].Accounts[1]
+	if to.LookupTable != testTable.ToBase58() || to.LookupIndex == nil || *to.LookupIndex != 7 || !to.Writable {
+		t.Fatalf("unexpected lookup account %+v", to)
+	}
+}
+
+func TestParseMessageInvalid(t *testing.T) {
+	v0 := v0Transfer()
+
+	legacy := legacyTransfer(t, testFrom, testFrom)[1+SignatureLength:]
+	noSigners := append([]byte{}, legacy...)
+	noSigners[0] = 0
+
+	// program index of the only instruction follows header, two account keys,
+	// blockhash and instruction count
+	feePayerProgram := append([]byte{}, v0...)
+	feePayerProgram[1+3+1+2*32+32+1] = 0
+
+	for _, data := range [][]byte{
+		nil,
+		{0x81},
+		[]byte("hello"),
+		legacy[:40],
+		append(append([]byte{}, legacy...), 0),
+		v0[:len(v0)-1],
+		noSigners,
+		feePayerProgram,
+	} {
+		if _, err := ParseMessage(data); err == nil {
+			t.Fatalf("expected parsing %x to fail", data)
+		}
+	}
+}
+
+func TestSigners(t *testing.T) {
+	legacy := legacyTransfer(t, testFrom, testFrom)[1+SignatureLength:]
+
+	// v0 message shares the layout of legacy one up to account keys, rest of
+	// the message is not needed to recognize signers
+	v0 := append([]byte{0x80}, legacy...)
+
+	for _, message := range [][]byte{legacy, v0, v0Transfer()} {
+		signers, err := Signers(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(signers) != 1 || signers[0] != testFrom {
+			t.Fatalf("expected signer %s, got %v", testFrom.ToBase58(), signers)
+		}
+	}
+
+	invalid := [][]byte{
+		nil,
+		{0x81},
+		legacy[:40],
+		[]byte("hello"),
+	}
+	for _, message := range invalid {
+		if _, err := Signers(message); err == nil {
+			t.Fatalf("expected parsing %x to fail", message)
+		}
+	}
+}